@@ -0,0 +1,98 @@
+package endpoint
+
+import (
+	"errors"
+	"fiber_web/apps/admin/internal/endpoint/validate"
+	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/ctx"
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/response"
+	"fiber_web/pkg/utils/errorx"
+	"fiber_web/pkg/validator"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApiKeyHandler API密钥管理，仅管理员可用
+type ApiKeyHandler struct {
+	apiKeyUseCase usecase.ApiKeyUseCase
+	validator     *validator.Validator
+}
+
+func NewApiKeyHandler(apiKeyUseCase usecase.ApiKeyUseCase, validator *validator.Validator) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		apiKeyUseCase: apiKeyUseCase,
+		validator:     validator,
+	}
+}
+
+// CreateApiKey 创建API密钥，完整密钥只在响应中出现一次
+func (h *ApiKeyHandler) CreateApiKey(c *fiber.Ctx) error {
+	if _, ok := currentAdminID(c); !ok {
+		return response.Forbidden(c, "仅管理员可以管理API密钥")
+	}
+	claims := c.Locals("claims").(*auth.Claims)
+
+	var req validate.CreateApiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		errors := h.validator.TranslateError(err)
+		return response.ValidationError(c, errors)
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	apiKey, err := h.apiKeyUseCase.CreateApiKey(c.Context(), claims, req.Name, req.Roles, ttl)
+	if err != nil {
+		var xerr *errorx.Error
+		if errors.As(err, &xerr) {
+			switch xerr.GetCode() {
+			case errorx.CodeInvalidParam:
+				return response.BadRequest(c, xerr.Message)
+			case errorx.CodeForbidden:
+				return response.Forbidden(c, xerr.Message)
+			}
+		}
+		return response.ServerError(c, err)
+	}
+	return response.Created(c, apiKey)
+}
+
+// RevokeApiKey 吊销当前管理员创建的API密钥
+func (h *ApiKeyHandler) RevokeApiKey(c *fiber.Ctx) error {
+	adminID, ok := currentAdminID(c)
+	if !ok {
+		return response.Forbidden(c, "仅管理员可以管理API密钥")
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	if err := h.apiKeyUseCase.RevokeApiKey(c.Context(), adminID, uint(id)); err != nil {
+		if errors.Is(err, query.ErrNotFound) {
+			return response.NotFound(c, "API密钥不存在")
+		}
+		return response.ServerError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// ListApiKeys 分页查询当前管理员创建的API密钥，不包含密钥哈希
+func (h *ApiKeyHandler) ListApiKeys(c *fiber.Ctx) error {
+	adminID, ok := currentAdminID(c)
+	if !ok {
+		return response.Forbidden(c, "仅管理员可以管理API密钥")
+	}
+	params := query.NewQuery().
+		AddOrderBy("id DESC").
+		SetPagination(ctx.GetPagination(c))
+	result, err := h.apiKeyUseCase.List(c.Context(), adminID, params)
+	if err != nil {
+		return response.ServerError(c, err)
+	}
+	return response.Success(c, result)
+}
@@ -9,13 +9,20 @@ import (
 func InitHandlers(
 	uses *usecase.UseCases,
 	validator *validator.Validator) *Handlers {
-	return &Handlers{
-		UserHandler:      NewUserHandler(uses.UserUserCase, validator),
+	handlers := &Handlers{
+		UserHandler:      NewUserHandler(uses.UserUserCase, uses.AuthUseCase, validator),
 		AdminUserHandler: NewAdminUserHandler(uses.AdminUserUseCase, validator),
 		ApiHandler:       NewApiHandler(uses.ApiUseCase, validator),
 		MenuHandler:      NewMenuHandler(uses.MenuUseCase, validator),
 		RoleHandler:      NewRoleHandler(uses.RoleUseCase, validator),
+		TwoFactorHandler: NewTwoFactorHandler(uses.TwoFactorUseCase, validator),
+		ApiKeyHandler:    NewApiKeyHandler(uses.ApiKeyUseCase, validator),
+		SessionHandler:   NewSessionHandler(),
 	}
+	if uses.OidcUseCase != nil {
+		handlers.OidcHandler = NewOidcHandler(uses.OidcUseCase)
+	}
+	return handlers
 }
 
 // Handlers 集中管理所有的HTTP处理器
@@ -25,4 +32,8 @@ type Handlers struct {
 	ApiHandler       *ApiHandler
 	MenuHandler      *MenuHandler
 	RoleHandler      *RoleHandler
+	TwoFactorHandler *TwoFactorHandler
+	ApiKeyHandler    *ApiKeyHandler
+	SessionHandler   *SessionHandler
+	OidcHandler      *OidcHandler // 未启用单点登录时为 nil
 }
@@ -1,8 +1,10 @@
 package endpoint
 
 import (
+	"errors"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
 	"fiber_web/pkg/ctx"
 	"fiber_web/pkg/query"
 	"fiber_web/pkg/response"
@@ -105,3 +107,64 @@ func (h *MenuHandler) ListMenus(c *fiber.Ctx) error {
 
 	return response.Success(c, result)
 }
+
+// MenuTree 获取当前用户按角色可查看的菜单树
+func (h *MenuHandler) MenuTree(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return response.Unauthorized(c, "unauthorized")
+	}
+
+	tree, err := h.menuUseCase.Tree(c.Context(), tenantDomain(c), claims.AllRoles())
+	if err != nil {
+		return response.ServerError(c, err)
+	}
+	return response.Success(c, entity.GetMenuResponseData{List: tree})
+}
+
+// GrantToRole 允许角色查看菜单
+func (h *MenuHandler) GrantToRole(c *fiber.Ctx) error {
+	id, menuID, err := roleMenuParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	if err := h.menuUseCase.GrantToRole(c.Context(), id, menuID); err != nil {
+		return roleMenuError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// RevokeFromRole 收回角色查看菜单的权限
+func (h *MenuHandler) RevokeFromRole(c *fiber.Ctx) error {
+	id, menuID, err := roleMenuParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	if err := h.menuUseCase.RevokeFromRole(c.Context(), id, menuID); err != nil {
+		return roleMenuError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// roleMenuParams 解析路径中的角色ID与菜单ID
+func roleMenuParams(c *fiber.Ctx) (uint, uint, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, 0, err
+	}
+	menuID, err := c.ParamsInt("menuId")
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(id), uint(menuID), nil
+}
+
+// roleMenuError 将菜单授权错误映射为HTTP响应
+func roleMenuError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, query.ErrNotFound) {
+		return response.NotFound(c, "角色或菜单不存在")
+	}
+	return response.ServerError(c, err)
+}
@@ -1,11 +1,15 @@
 package endpoint
 
 import (
+	"errors"
+	"fiber_web/apps/admin/internal/endpoint/validate"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
 	"fiber_web/pkg/ctx"
 	"fiber_web/pkg/query"
 	"fiber_web/pkg/response"
+	"fiber_web/pkg/utils/errorx"
 	"fiber_web/pkg/validator"
 
 	"github.com/gofiber/fiber/v2"
@@ -105,3 +109,101 @@ func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
 
 	return response.Success(c, result)
 }
+
+// ListRoleApis 获取角色已授权的接口
+func (h *RoleHandler) ListRoleApis(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	apis, err := h.roleUseCase.ListApis(c.Context(), tenantDomain(c), uint(id))
+	if err != nil {
+		return roleApiError(c, err)
+	}
+	return response.Success(c, apis)
+}
+
+// GrantApi 授予角色访问接口的权限
+func (h *RoleHandler) GrantApi(c *fiber.Ctx) error {
+	id, apiID, err := roleApiParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	if err := h.roleUseCase.GrantApi(c.Context(), tenantDomain(c), id, apiID); err != nil {
+		return roleApiError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// RevokeApi 收回角色访问接口的权限
+func (h *RoleHandler) RevokeApi(c *fiber.Ctx) error {
+	id, apiID, err := roleApiParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的ID")
+	}
+
+	if err := h.roleUseCase.RevokeApi(c.Context(), tenantDomain(c), id, apiID); err != nil {
+		return roleApiError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// ExplainPermission 模拟任意主体的权限判定，用于排查请求被拒绝的原因
+func (h *RoleHandler) ExplainPermission(c *fiber.Ctx) error {
+	req := new(validate.ExplainPermissionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		return response.ValidationError(c, h.validator.TranslateError(err))
+	}
+
+	decision, err := h.roleUseCase.ExplainPermission(c.Context(), req.Subject, req.Domain, req.Path, req.Method)
+	if err != nil {
+		var xerr *errorx.Error
+		if errors.As(err, &xerr) && xerr.GetCode() == errorx.CodeInvalidParam {
+			return response.BadRequest(c, xerr.Message)
+		}
+		return response.ServerError(c, err)
+	}
+	return response.Success(c, decision)
+}
+
+// tenantDomain 与 middleware.Rbac 一致，启用多租户时使用令牌所属租户的域，否则为空
+func tenantDomain(c *fiber.Ctx) string {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return ""
+	}
+	if enforcer := auth.GetEnforcer(); enforcer != nil && enforcer.DomainsEnabled() {
+		return claims.Tenant()
+	}
+	return ""
+}
+
+// roleApiParams 解析路径中的角色ID与接口ID
+func roleApiParams(c *fiber.Ctx) (uint, uint, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, 0, err
+	}
+	apiID, err := c.ParamsInt("apiId")
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(id), uint(apiID), nil
+}
+
+// roleApiError 将角色授权错误映射为HTTP响应
+func roleApiError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, query.ErrNotFound) {
+		return response.NotFound(c, "角色或接口不存在")
+	}
+	var xerr *errorx.Error
+	if errors.As(err, &xerr) && xerr.GetCode() == errorx.CodeBusinessError {
+		return response.Error(c, fiber.StatusConflict, xerr.Message)
+	}
+	return response.ServerError(c, err)
+}
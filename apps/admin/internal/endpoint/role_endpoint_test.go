@@ -0,0 +1,105 @@
+package endpoint
+
+import (
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/redis/redistest"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestRoleApiGrant 授权与收回接口写入令牌所属租户的域
+func TestRoleApiGrant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&entity.Role{}, &entity.Api{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.InitRbac(db, auth.WithDomains("default")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Create(&entity.Role{Name: "manager"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	apis := []entity.Api{
+		{ApiGroup: "orders", Name: "订单详情", Method: "GET", Path: "/orders/:id"},
+		{ApiGroup: "orders", Name: "旧接口", Method: "GET", Path: "/orders/legacy", Stale: true},
+	}
+	if err := db.Create(&apis).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cache := redistest.NewClient(t)
+	uc := usecase.NewRoleUseCase(repository.NewRoleRepository(db, cache), repository.NewApiRepository(db, cache))
+	handler := NewRoleHandler(uc, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("claims", &auth.Claims{Extra: map[string]any{auth.TenantClaim: "acme"}})
+		return c.Next()
+	})
+	app.Put("/admin/roles/:id/apis/:apiId", handler.GrantApi)
+	app.Delete("/admin/roles/:id/apis/:apiId", handler.RevokeApi)
+
+	enforcer := auth.GetEnforcer()
+	granted := func(domain string) bool {
+		policies, err := enforcer.GetPermissionsForRoleInDomain("manager", domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, policy := range policies {
+			if policy[len(policy)-2] == "/orders/:id" && policy[len(policy)-1] == "GET" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 按顺序执行，撤销基于前面的授权
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		want    int
+		granted bool // 执行后 acme 域中是否拥有订单详情的权限
+	}{
+		{"授权写入租户的域", fiber.MethodPut, "/admin/roles/1/apis/1", fiber.StatusNoContent, true},
+		{"已不存在的接口不能授权", fiber.MethodPut, "/admin/roles/1/apis/2", fiber.StatusConflict, true},
+		{"角色不存在", fiber.MethodPut, "/admin/roles/9/apis/1", fiber.StatusNotFound, true},
+		{"接口不存在", fiber.MethodDelete, "/admin/roles/1/apis/9", fiber.StatusNotFound, true},
+		{"无效的ID", fiber.MethodPut, "/admin/roles/abc/apis/1", fiber.StatusBadRequest, true},
+		{"收回租户域中的权限", fiber.MethodDelete, "/admin/roles/1/apis/1", fiber.StatusNoContent, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s 状态码 = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+			}
+			if got := granted("acme"); got != tt.granted {
+				t.Errorf("acme 域中的权限 = %v, want %v", got, tt.granted)
+			}
+			if granted("default") {
+				t.Error("默认域不应写入权限")
+			}
+		})
+	}
+}
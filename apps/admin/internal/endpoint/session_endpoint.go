@@ -0,0 +1,80 @@
+package endpoint
+
+import (
+	"errors"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandler 当前账号的登录会话管理
+type SessionHandler struct{}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{}
+}
+
+// sessionView 会话列表项，标记发起请求的会话
+type sessionView struct {
+	*auth.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions 列出当前账号的活跃会话
+func (h *SessionHandler) ListSessions(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return response.Unauthorized(c, "unauthorized")
+	}
+
+	sessions, err := auth.GetJWTManager().Sessions(c.Context(), claims.Subject)
+	if err != nil {
+		return response.ServerError(c, err)
+	}
+	views := make([]sessionView, len(sessions))
+	for i, session := range sessions {
+		views[i] = sessionView{Session: session, Current: session.ID == claims.Family}
+	}
+	return response.Success(c, views)
+}
+
+// RevokeSession 吊销当前账号的指定会话，吊销当前会话等同于登出
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return response.Unauthorized(c, "unauthorized")
+	}
+
+	err := auth.GetJWTManager().RevokeSession(c.Context(), claims.Subject, c.Params("id"))
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		return response.NotFound(c, "会话不存在")
+	}
+	if err != nil {
+		return response.ServerError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// RevokeOtherSessions 吊销当前会话以外的全部会话
+func (h *SessionHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return response.Unauthorized(c, "unauthorized")
+	}
+
+	jwtManager := auth.GetJWTManager()
+	sessions, err := jwtManager.Sessions(c.Context(), claims.Subject)
+	if err != nil {
+		return response.ServerError(c, err)
+	}
+	for _, session := range sessions {
+		if session.ID == claims.Family {
+			continue
+		}
+		if err := jwtManager.RevokeSession(c.Context(), claims.Subject, session.ID); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+			return response.ServerError(c, err)
+		}
+	}
+	return response.NoContent(c)
+}
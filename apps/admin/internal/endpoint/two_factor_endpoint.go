@@ -0,0 +1,117 @@
+package endpoint
+
+import (
+	"errors"
+	"fiber_web/apps/admin/internal/endpoint/validate"
+	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/response"
+	"fiber_web/pkg/utils/errorx"
+	"fiber_web/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorHandler 当前管理员的两步验证管理
+type TwoFactorHandler struct {
+	twoFactorUseCase usecase.TwoFactorUseCase
+	validator        *validator.Validator
+}
+
+func NewTwoFactorHandler(twoFactorUseCase usecase.TwoFactorUseCase, validator *validator.Validator) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorUseCase: twoFactorUseCase,
+		validator:        validator,
+	}
+}
+
+// Enroll 生成 TOTP 密钥与 otpauth:// 地址
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	id, ok := currentAdminID(c)
+	if !ok {
+		return response.Forbidden(c, "仅管理员可以使用两步验证")
+	}
+
+	enrollment, err := h.twoFactorUseCase.Enroll(c.Context(), id)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return response.Success(c, enrollment)
+}
+
+// Confirm 校验验证码并启用两步验证，返回恢复码
+func (h *TwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	id, ok := currentAdminID(c)
+	if !ok {
+		return response.Forbidden(c, "仅管理员可以使用两步验证")
+	}
+
+	var req validate.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		errors := h.validator.TranslateError(err)
+		return response.ValidationError(c, errors)
+	}
+
+	codes, err := h.twoFactorUseCase.Confirm(c.Context(), id, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return response.Success(c, fiber.Map{"recovery_codes": codes})
+}
+
+// Disable 校验验证码或恢复码并关闭两步验证
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	id, ok := currentAdminID(c)
+	if !ok {
+		return response.Forbidden(c, "仅管理员可以使用两步验证")
+	}
+
+	var req validate.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		errors := h.validator.TranslateError(err)
+		return response.ValidationError(c, errors)
+	}
+
+	if err := h.twoFactorUseCase.Disable(c.Context(), id, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// currentAdminID 获取当前令牌中的管理员ID，普通用户令牌返回 false
+func currentAdminID(c *fiber.Ctx) (uint, bool) {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return 0, false
+	}
+	if isAdmin, _ := auth.ExtraValue[bool](claims, usecase.AdminClaim); !isAdmin {
+		return 0, false
+	}
+	return uint(claims.UserID), true
+}
+
+// twoFactorError 将两步验证错误映射为HTTP响应
+func twoFactorError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, query.ErrNotFound) {
+		return response.NotFound(c, "管理员不存在")
+	}
+	var xerr *errorx.Error
+	if errors.As(err, &xerr) {
+		switch xerr.GetCode() {
+		case errorx.CodeInvalidParam:
+			return response.BadRequest(c, xerr.Message)
+		case errorx.CodeBusinessError:
+			return response.Error(c, fiber.StatusConflict, xerr.Message)
+		case errorx.CodeLocked:
+			return loginError(c, err)
+		}
+	}
+	return response.ServerError(c, err)
+}
@@ -1,27 +1,33 @@
 package endpoint
 
 import (
+	"context"
+	"errors"
 	"fiber_web/apps/admin/internal/endpoint/validate"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/usecase"
 	"fiber_web/pkg/auth"
 	"fiber_web/pkg/ctx"
 	"fiber_web/pkg/logger"
-	"fiber_web/pkg/query"
 	"fiber_web/pkg/response"
+	"fiber_web/pkg/utils/errorx"
 	"fiber_web/pkg/validator"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserHandler struct {
 	userUseCase usecase.UserUseCase
+	authUseCase usecase.AuthUseCase
 	validator   *validator.Validator
 }
 
-func NewUserHandler(userUseCase usecase.UserUseCase, validator *validator.Validator) *UserHandler {
+func NewUserHandler(userUseCase usecase.UserUseCase, authUseCase usecase.AuthUseCase, validator *validator.Validator) *UserHandler {
 	return &UserHandler{
 		userUseCase: userUseCase,
+		authUseCase: authUseCase,
 		validator:   validator,
 	}
 }
@@ -97,6 +103,7 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 	user.Username = req.Username
 	user.Email = req.Email
 	user.Password = req.Password
+	user.Status = entity.StatusEnabled
 	// 处理注册逻辑
 	if err := h.userUseCase.CreateUser(c.Context(), user); err != nil {
 		return response.Error(c, fiber.StatusInternalServerError, "注册用户失败")
@@ -116,14 +123,73 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 		return response.ValidationError(c, errors)
 	}
 
-	// TODO: 实现实际的登录逻辑
-	jwtMessage, err := auth.GetJWTManager().GenerateTokenPair(1, req.Username, "test")
+	jwtMessage, err := h.authUseCase.Login(clientContext(c, req.Device), req.Username, req.Password, req.Tenant)
 	if err != nil {
-		return response.ServerError(c, err)
+		return loginError(c, err)
+	}
+	return response.Success(c, jwtMessage)
+}
+
+// AdminLogin 管理员登录
+func (h *UserHandler) AdminLogin(c *fiber.Ctx) error {
+	var req validate.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		errors := h.validator.TranslateError(err)
+		return response.ValidationError(c, errors)
+	}
+
+	jwtMessage, err := h.authUseCase.AdminLogin(clientContext(c, req.Device), req.Username, req.Password, req.Tenant)
+	if err != nil {
+		return loginError(c, err)
+	}
+	return response.Success(c, jwtMessage)
+}
+
+// VerifyTwoFactor 提交质询令牌与验证码（或恢复码）完成管理员登录
+func (h *UserHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req validate.TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "无效的请求数据")
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		errors := h.validator.TranslateError(err)
+		return response.ValidationError(c, errors)
+	}
+
+	jwtMessage, err := h.authUseCase.VerifyTwoFactor(clientContext(c, req.Device), req.ChallengeToken, req.Code)
+	if err != nil {
+		return loginError(c, err)
 	}
 	return response.Success(c, jwtMessage)
 }
 
+// loginError 将登录错误映射为HTTP响应，锁定时设置 Retry-After 头
+func loginError(c *fiber.Ctx, err error) error {
+	var xerr *errorx.Error
+	if !errors.As(err, &xerr) {
+		return response.ServerError(c, err)
+	}
+	switch xerr.GetCode() {
+	case errorx.CodeInvalidParam:
+		return response.BadRequest(c, xerr.Message)
+	case errorx.CodeUnauthorized:
+		return response.Unauthorized(c, xerr.Message)
+	case errorx.CodeForbidden:
+		return response.Forbidden(c, xerr.Message)
+	case errorx.CodeLocked:
+		if seconds, ok := xerr.Context["retry_after"].(int64); ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+		}
+		return response.Error(c, fiber.StatusLocked, xerr.Message)
+	}
+	return response.ServerError(c, err)
+}
+
 func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 	var req validate.RefreshTokenRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -135,14 +201,42 @@ func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 		return response.ValidationError(c, errors)
 	}
 
-	// TODO: token相关逻辑
-	jwtMessage, err := auth.GetJWTManager().RefreshToken(req.Token)
+	jwtMessage, err := h.authUseCase.RefreshToken(clientContext(c, ""), req.Token, req.Tenant)
 	if err != nil {
-		return response.ServerError(c, err)
+		if isTokenError(err) {
+			return response.Unauthorized(c, err.Error())
+		}
+		return loginError(c, err)
 	}
 	return response.Success(c, jwtMessage)
 }
 
+// Logout 吊销当前访问令牌及其所属的令牌家族
+func (h *UserHandler) Logout(c *fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	if err := auth.GetJWTManager().Revoke(c.Context(), token); err != nil {
+		return response.ServerError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// clientContext 将客户端信息写入上下文，签发或刷新令牌时记录到会话
+func clientContext(c *fiber.Ctx, device string) context.Context {
+	return auth.WithClientInfo(c.Context(), auth.ClientInfo{
+		Device:    device,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+}
+
+// isTokenError 判断是否为令牌本身无效导致的错误
+func isTokenError(err error) bool {
+	return errors.Is(err, auth.ErrInvalidToken) ||
+		errors.Is(err, auth.ErrExpiredToken) ||
+		errors.Is(err, auth.ErrRevokedToken) ||
+		errors.Is(err, auth.ErrTokenReused)
+}
+
 func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 	// TODO: 实现获取用户资料的逻辑
 	return response.Success(c, fiber.Map{
@@ -160,15 +254,42 @@ func (h *UserHandler) TestUser(c *fiber.Ctx) error {
 
 // List List取用户列表
 func (h *UserHandler) List(c *fiber.Ctx) error {
-	params := query.NewQuery().
-		AddCondition("status", query.OpEq, 1).
-		AddOrderBy("id DESC").
-		SetPagination(ctx.GetPagination(c)).
-		Select("id", "username", "email", "role", "status", "created_at", "updated_at")
+	params := ctx.GetQuery(c).SetPagination(ctx.GetPagination(c))
+	if len(params.OrderBy) == 0 {
+		params.AddOrderBy("id DESC")
+	}
+	if len(params.SelectFields) == 0 {
+		params.Select("id", "username", "email", "role", "status", "created_at", "updated_at")
+	}
+
+	// 游标分页
+	if params.IsCursorMode() {
+		result, err := h.userUseCase.ListByCursor(c.Context(), params)
+		if msg, ok := queryParamError(err); ok {
+			return response.BadRequest(c, msg)
+		}
+		if err != nil {
+			return response.ServerError(c, err)
+		}
+		return response.Success(c, result)
+	}
+
 	result, err := h.userUseCase.List(c.Context(), params)
+	if msg, ok := queryParamError(err); ok {
+		return response.BadRequest(c, msg)
+	}
 	if err != nil {
 		return response.ServerError(c, err)
 	}
 
 	return response.Success(c, result)
 }
+
+// queryParamError 提取查询参数校验错误的提示信息
+func queryParamError(err error) (string, bool) {
+	var xerr *errorx.Error
+	if errors.As(err, &xerr) && xerr.GetCode() == errorx.CodeInvalidParam {
+		return xerr.Message, true
+	}
+	return "", false
+}
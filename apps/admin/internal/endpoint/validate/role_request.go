@@ -0,0 +1,9 @@
+package validate
+
+// ExplainPermissionRequest 模拟权限判定请求
+type ExplainPermissionRequest struct {
+	Subject string `json:"subject" validate:"required"` // Casbin 主体：管理员ID、user:{id} 或角色名
+	Domain  string `json:"domain"`                      // 租户域，为空时使用默认域，仅在启用多租户时有效
+	Path    string `json:"path" validate:"required,startswith=/"`
+	Method  string `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE get post put patch delete"`
+}
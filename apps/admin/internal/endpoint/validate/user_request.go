@@ -3,6 +3,8 @@ package validate
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device" validate:"max=100"` // 设备名称，记录在会话中
+	Tenant   string `json:"tenant" validate:"max=100"` // 登录的租户，为空时自动选择
 }
 
 type RegisterRequest struct {
@@ -17,5 +19,22 @@ type UpdateUserRequest struct {
 }
 
 type RefreshTokenRequest struct {
-	Token string `json:"token" validate:"required"`
+	Token  string `json:"token" validate:"required"`
+	Tenant string `json:"tenant" validate:"max=100"` // 切换到的租户，为空时沿用令牌中的租户
+}
+
+type CreateApiKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Roles         []string `json:"roles" validate:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"gte=0"` // 0 表示永不过期
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	Device         string `json:"device" validate:"max=100"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
 }
@@ -8,14 +8,19 @@ import (
 
 // AdminUser 实体模型
 type AdminUser struct {
-	Id        uint           // 主键ID
-	Name      string         // 用户名
-	Email     string         // 邮箱
-	Password  string         // 密码
-	Status    int8           // 状态
-	CreatedAt time.Time      // 创建时间
-	UpdatedAt time.Time      // 更新时间
-	DeletedAt gorm.DeletedAt // 删除时间
+	Id            uint           // 主键ID
+	Name          string         // 用户名
+	Email         string         // 邮箱
+	Password      string         // 密码
+	Status        int8           // 状态
+	TotpSecret    string         `json:"-"` // TOTP 密钥，启用前为待确认的密钥
+	TotpEnabled   bool           // 是否已启用两步验证
+	TotpCounter   int64          `json:"-"` // 最近一次使用的验证码时间步，防止重放
+	RecoveryCodes string         `json:"-"` // 恢复码哈希，逗号分隔
+	OidcSubject   *string        `json:"-"` // 绑定的 OIDC 身份标识 issuer|sub，未绑定时为 NULL
+	CreatedAt     time.Time      // 创建时间
+	UpdatedAt     time.Time      // 更新时间
+	DeletedAt     gorm.DeletedAt // 删除时间
 }
 
 // TableName 指定表名
@@ -13,6 +13,7 @@ type Api struct {
 	Name      string         // api分组名称
 	Path      string         // api路径
 	Method    string         // http方法
+	Stale     bool           // 路由已不存在，由启动时的路由同步标记
 	CreatedAt time.Time      // 创建时间
 	UpdatedAt time.Time      // 更新时间
 	DeletedAt gorm.DeletedAt // 删除时间
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// ApiKey API密钥实体模型，只保存密钥哈希
+type ApiKey struct {
+	Id         uint       // 主键ID
+	Name       string     // 密钥名称
+	Prefix     string     // 密钥公开前缀，用于查找
+	KeyHash    string     `json:"-"` // 密钥哈希
+	Roles      string     // 授权的 Casbin 角色，逗号分隔
+	CreatedBy  uint       // 创建者管理员ID
+	ExpiresAt  *time.Time // 过期时间，为空时永不过期
+	LastUsedAt *time.Time // 最近使用时间
+	RevokedAt  *time.Time // 吊销时间
+	CreatedAt  time.Time  // 创建时间
+	UpdatedAt  time.Time  // 更新时间
+}
+
+// TableName 指定表名
+func (api_key *ApiKey) TableName() string {
+	return "api_keys"
+}
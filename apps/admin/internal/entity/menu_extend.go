@@ -15,10 +15,12 @@ type MenuDataItem struct {
 	HideInMenu bool   `json:"hide_in_menu,omitempty"` // 是否保活
 	URL        string `json:"url,omitempty"`          // iframe模式下的跳转url，不能与path重复
 	UpdatedAt  string `json:"updated_at,omitempty"`   // 是否保活
+
+	Children []*MenuDataItem `json:"children,omitempty"` // 子菜单，按排序权重升序
 }
 
 type GetMenuResponseData struct {
-	List []MenuDataItem `json:"list"`
+	List []*MenuDataItem `json:"list"`
 }
 
 type GetMenuResponse struct {
@@ -2,6 +2,12 @@ package entity
 
 import "time"
 
+// 账号状态
+const (
+	StatusDisabled int8 = 0 // 禁用
+	StatusEnabled  int8 = 1 // 启用
+)
+
 type User struct {
 	ID        uint      `json:"id"`
 	Username  string    `json:"username"`
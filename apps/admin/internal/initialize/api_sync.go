@@ -0,0 +1,81 @@
+package initialize
+
+import (
+	"context"
+	"errors"
+	"fiber_web/apps/admin/internal/transport"
+	"fiber_web/pkg/lock"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/server"
+	"time"
+)
+
+const (
+	// apiSyncLockKey 路由同步锁，多实例同时启动时依次同步，避免重复创建接口
+	apiSyncLockKey = "api:sync"
+	// apiSyncLockTTL 路由同步锁的租期，持锁期间自动续期
+	apiSyncLockTTL = 30 * time.Second
+	// apiSyncLockTimeout 等待其他实例同步完成的最长时间
+	apiSyncLockTimeout = time.Minute
+)
+
+// ApiSync 启动时将已注册的路由同步到接口表，需在路由注册之后初始化
+type ApiSync struct {
+	infra  *Infra
+	domain *Domain
+	server *server.FiberServer
+}
+
+func NewApiSync(infra *Infra, domain *Domain, server *server.FiberServer) *ApiSync {
+	return &ApiSync{
+		infra:  infra,
+		domain: domain,
+		server: server,
+	}
+}
+
+// Init 实现 Component 接口，多实例持有分布式锁依次同步，同步失败不影响启动
+func (s *ApiSync) Init(ctx context.Context) error {
+	routes := transport.RegisteredApis(s.server.App())
+	// 未注册路由的应用类型不同步，避免把全部接口标记为已不存在
+	if len(routes) == 0 {
+		return nil
+	}
+	owner, err := lock.NewOwner()
+	if err != nil {
+		s.infra.Logger.Warn("Failed to sync api routes", logger.ErrorField(err))
+		return nil
+	}
+	guard, err := s.infra.Locker.LockWithTimeout(ctx, apiSyncLockKey, owner, apiSyncLockTTL, apiSyncLockTimeout)
+	if err != nil {
+		s.infra.Logger.Warn("Failed to acquire api sync lock", logger.ErrorField(err))
+		return nil
+	}
+	defer func() {
+		if err := guard.Release(context.Background()); err != nil && !errors.Is(err, lock.ErrNotOwner) {
+			s.infra.Logger.Warn("Failed to release api sync lock", logger.ErrorField(err))
+		}
+	}()
+
+	result, err := s.domain.Uses.ApiUseCase.SyncRoutes(guard.Context(), routes)
+	if err != nil {
+		s.infra.Logger.Warn("Failed to sync api routes", logger.ErrorField(err))
+		return nil
+	}
+	s.infra.Logger.Info("Api routes synced",
+		logger.Int("routes", len(routes)),
+		logger.Int("created", result.Created),
+		logger.Int("stale", result.Stale),
+		logger.Int("restored", result.Restored))
+	return nil
+}
+
+// Start 实现 Component 接口
+func (s *ApiSync) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 实现 Component 接口
+func (s *ApiSync) Stop(ctx context.Context) error {
+	return nil
+}
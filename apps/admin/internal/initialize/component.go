@@ -71,6 +71,9 @@ func (c *Component) Initialize(ctx context.Context) error {
 	c.app = NewApp(c.infra, domain, c.servers, c.boot, c.appType)
 	c.boot.AddComponent(c.app)
 
+	// 路由注册后同步接口表
+	c.boot.AddComponent(NewApiSync(c.infra, domain, c.servers["admin"]))
+
 	return c.boot.Bootstrap(ctx)
 }
 
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fiber_web/apps/admin/internal/repository"
 	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/config"
 	"sync"
 )
 
@@ -32,8 +34,18 @@ func (d *Domain) Init(ctx context.Context) error {
 	d.Repos = repository.InitRepositories(defaultDB.DB(), defaultRedis)
 	d.infra.Logger.Info("Repositories initialized")
 
+	// 初始化单点登录，启动时获取身份提供方元数据
+	var oidcProvider *auth.OIDCProvider
+	if config.Data.OIDC.Enabled {
+		oidcProvider, err = auth.NewOIDCProvider(ctx, &config.Data.OIDC, auth.NewRedisOIDCStateStore(defaultRedis))
+		if err != nil {
+			return err
+		}
+		d.infra.Logger.Info("OIDC provider initialized")
+	}
+
 	// 初始化用例层
-	d.Uses = usecase.InitUseCases(d.Repos)
+	d.Uses = usecase.InitUseCases(d.Repos, defaultRedis, oidcProvider)
 	d.infra.Logger.Info("UseCases initialized")
 
 	return nil
@@ -6,7 +6,9 @@ import (
 	"fiber_web/pkg/config"
 	"fiber_web/pkg/cron"
 	"fiber_web/pkg/database"
+	"fiber_web/pkg/lock"
 	"fiber_web/pkg/logger"
+	"fiber_web/pkg/query"
 	"fiber_web/pkg/queue"
 	"fiber_web/pkg/redis"
 	"fmt"
@@ -22,6 +24,8 @@ type Infra struct {
 	DefaultProducer *queue.Producer
 	Logger          *logger.Logger
 	Cron            *cron.Scheduler
+	Locker          lock.Lock      // 基于默认 Redis 的分布式锁
+	DecisionLog     *logger.Logger // 权限判定日志
 	mu              sync.RWMutex
 }
 
@@ -81,17 +85,54 @@ func (i *Infra) Init(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if err = auth.InitRbac(defaultDB.DB()); err != nil {
+	var rbacOpts []auth.RbacOption
+	if config.Data.Rbac.Domains {
+		domain := config.Data.Rbac.DefaultDomain
+		if domain == "" {
+			domain = "default"
+		}
+		rbacOpts = append(rbacOpts, auth.WithDomains(domain))
+	}
+	defaultRedis, err := i.Redis.GetClient("default")
+	if err != nil {
+		return err
+	}
+	if config.Data.Rbac.Watcher {
+		rbacOpts = append(rbacOpts, auth.WithWatcher(auth.NewRedisPolicyBus(defaultRedis, config.Data.Rbac.Channel)))
+	}
+	if config.Data.Rbac.ReloadInterval > 0 {
+		rbacOpts = append(rbacOpts, auth.WithAutoReload(config.Data.Rbac.ReloadInterval))
+	}
+	if mode := config.Data.Rbac.DecisionLog; mode != "" {
+		collection := config.Data.Rbac.DecisionCollection
+		if collection == "" {
+			collection = "rbac_decisions"
+		}
+		i.DecisionLog = logger.NewMongoLogger(defaultMongoDb, collection, logger.WithAsync(2, 1024))
+		rbacOpts = append(rbacOpts, auth.WithDecisionLog(i.DecisionLog, mode == "all"))
+	}
+	if err = auth.InitRbac(defaultDB.DB(), rbacOpts...); err != nil {
 		return err
 	}
 	i.Logger.Info("RBAC initialized")
 
-	// 初始化jwt
-	auth.InitJWTManager(&config.Data.JWT)
+	// 初始化jwt，令牌状态保存在默认 Redis 中
+	jwtKeys, err := auth.LoadKeySet(&config.Data.JWT)
+	if err != nil {
+		return err
+	}
+	auth.InitJWTManager(&config.Data.JWT,
+		auth.WithTokenStore(auth.NewRedisTokenStore(defaultRedis)),
+		auth.WithSessionStore(auth.NewRedisSessionStore(defaultRedis)),
+		auth.WithKeySet(jwtKeys))
 	i.Logger.Info("jwt initialized")
 
-	// 启动 Cron
-	i.Cron = cron.NewScheduler(logger.GetLogger())
+	// 游标分页签名密钥，未配置时保留进程内随机密钥
+	query.SetCursorSecret([]byte(config.Data.App.CursorSecret))
+
+	// 启动 Cron，集群单例任务使用默认 Redis 加锁
+	i.Locker = lock.NewRedisLock(defaultRedis)
+	i.Cron = cron.NewScheduler(logger.GetLogger(), cron.WithLocker(i.Locker, 0))
 	i.Logger.Info("Cron initialized")
 
 	return nil
@@ -100,6 +141,17 @@ func (i *Infra) Init(ctx context.Context) error {
 // Start 实现 Component 接口
 func (i *Infra) Start(ctx context.Context) error {
 	i.Cron.Start()
+
+	// 启动签名密钥自动轮换，密钥通过默认 Redis 在实例间共享，旧密钥默认在刷新令牌有效期内继续用于验证
+	grace := config.Data.JWT.RotationGrace
+	if grace <= 0 {
+		grace = config.Data.JWT.RefreshTokenExpiry
+	}
+	defaultRedis, err := i.Redis.GetClient("default")
+	if err != nil {
+		return err
+	}
+	auth.GetJWTManager().Keys().StartRotation(config.Data.JWT.RotationInterval, grace, auth.NewRedisKeyStore(defaultRedis))
 	return nil
 }
 
@@ -121,8 +173,22 @@ func (i *Infra) Shutdown() error {
 		i.Logger.Info("Cron scheduler stopped")
 	}
 
+	if jwtManager := auth.GetJWTManager(); jwtManager != nil {
+		jwtManager.Keys().Stop()
+	}
 	i.Logger.Info("jwt stopped")
 
+	// 停止策略同步，需在关闭 Redis 之前
+	if enforcer := auth.GetEnforcer(); enforcer != nil {
+		enforcer.Close()
+		i.Logger.Info("RBAC watcher stopped")
+	}
+	if i.DecisionLog != nil {
+		if err := i.DecisionLog.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// 关闭 NSQ
 	if i.DefaultProducer != nil {
 		i.DefaultProducer.Stop()
@@ -26,12 +26,18 @@ func Jwt() fiber.Handler {
 			return response.Unauthorized(c, "invalid authorization header format")
 		}
 
-		claims, err := auth.GetJWTManager().ValidateToken(parts[1])
+		claims, err := auth.GetJWTManager().VerifyToken(c.Context(), parts[1], auth.AccessToken)
 		if err != nil {
 			message := "invalid token"
 
-			if errors.Is(err, auth.ErrExpiredToken) {
+			switch {
+			case errors.Is(err, auth.ErrExpiredToken):
 				message = "token has expired"
+			case errors.Is(err, auth.ErrRevokedToken):
+				message = "token has been revoked"
+			case !errors.Is(err, auth.ErrInvalidToken):
+				logger.ErrorLog("Failed to verify token", logger.ErrorField(err))
+				return response.ServerError(c, errorx.NewSystemError("failed to verify token"))
 			}
 
 			return response.Unauthorized(c, message)
@@ -43,6 +49,37 @@ func Jwt() fiber.Handler {
 	}
 }
 
+// JwtOrApiKey 携带 X-API-Key 时使用API密钥认证，否则使用JWT认证，两种方式写入相同的 claims
+func JwtOrApiKey(verifier auth.APIKeyVerifier) fiber.Handler {
+	jwt := Jwt()
+	return func(c *fiber.Ctx) error {
+		key := c.Get(auth.APIKeyHeader)
+		if key == "" {
+			return jwt(c)
+		}
+
+		claims, err := verifier.VerifyAPIKey(c.Context(), key)
+		if err != nil {
+			message := "invalid api key"
+
+			switch {
+			case errors.Is(err, auth.ErrExpiredAPIKey):
+				message = "api key has expired"
+			case errors.Is(err, auth.ErrRevokedAPIKey):
+				message = "api key has been revoked"
+			case !errors.Is(err, auth.ErrInvalidAPIKey):
+				logger.ErrorLog("Failed to verify api key", logger.ErrorField(err))
+				return response.ServerError(c, errorx.NewSystemError("failed to verify api key"))
+			}
+
+			return response.Unauthorized(c, message)
+		}
+
+		c.Locals("claims", claims)
+		return c.Next()
+	}
+}
+
 func Rbac() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		claims, ok := c.Locals("claims").(*auth.Claims)
@@ -50,19 +87,52 @@ func Rbac() fiber.Handler {
 			return response.Unauthorized(c, "unauthorized")
 		}
 
-		allowed, err := auth.GetEnforcer().HasPermission(claims.Role, c.Path(), c.Method())
-		if err != nil {
-			logger.ErrorLog("Failed to check permission",
-				logger.String("role", claims.Role),
-				logger.String("path", c.Path()),
-				logger.String("method", c.Method()),
-				logger.ErrorField(err))
-			return response.ServerError(c, errorx.NewSystemError("failed to check permission"))
+		// 启用多租户时在令牌所属租户的域中鉴权，未携带租户的令牌使用默认域
+		enforcer := auth.GetEnforcer()
+		tenant := claims.Tenant()
+		decision := &auth.Decision{Subject: claims.Subject, Object: c.Path(), Action: c.Method()}
+		explain := enforcer.Explain
+		if enforcer.DomainsEnabled() {
+			decision.Domain = tenant
+			if decision.Domain == "" {
+				decision.Domain = enforcer.DefaultDomain()
+			}
+			explain = func(role, path, method string) (*auth.Decision, error) {
+				return enforcer.ExplainInDomain(role, tenant, path, method)
+			}
+		}
+
+		// 任一角色具有权限即放行
+		roles := claims.AllRoles()
+		for _, role := range roles {
+			d, err := explain(role, c.Path(), c.Method())
+			if err != nil {
+				logger.ErrorLog("Failed to check permission",
+					logger.String("role", role),
+					logger.String("tenant", tenant),
+					logger.String("path", c.Path()),
+					logger.String("method", c.Method()),
+					logger.ErrorField(err))
+				return response.ServerError(c, errorx.NewSystemError("failed to check permission"))
+			}
+			if d.Allowed {
+				decision.Allowed = true
+				decision.Policy = d.Policy
+				decision.Roles = d.Roles
+				break
+			}
 		}
 
-		if !allowed {
+		requestID := c.GetRespHeader("X-Request-ID")
+		enforcer.LogDecision(decision,
+			logger.String("request_id", requestID),
+			logger.Any("roles", roles))
+		if !decision.Allowed {
 			logger.Warn("Permission denied",
-				logger.String("role", claims.Role),
+				logger.String("request_id", requestID),
+				logger.String("subject", claims.Subject),
+				logger.Any("roles", roles),
+				logger.String("tenant", tenant),
 				logger.String("path", c.Path()),
 				logger.String("method", c.Method()))
 			return response.Forbidden(c, "permission denied")
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/security"
+	"fiber_web/pkg/utils/errorx"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// noopLockoutCache 测试用登录锁定缓存，不记录失败次数
+type noopLockoutCache struct{}
+
+func (noopLockoutCache) Set(context.Context, string, interface{}, time.Duration) error { return nil }
+func (noopLockoutCache) Delete(context.Context, ...string) error                       { return nil }
+func (noopLockoutCache) Incr(context.Context, string) (int64, error)                   { return 1, nil }
+func (noopLockoutCache) Expire(context.Context, string, time.Duration) error           { return nil }
+func (noopLockoutCache) TTL(context.Context, string) (time.Duration, error)            { return 0, nil }
+
+// isForbidden 判断是否为禁止访问错误
+func isForbidden(err error) bool {
+	var xerr *errorx.Error
+	return errors.As(err, &xerr) && xerr.GetCode() == errorx.CodeForbidden
+}
+
+// TestRbacTenantLogin 管理员登录后令牌携带所属租户与该租户的角色，Rbac 在该租户的域中鉴权
+func TestRbacTenantLogin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&entity.AdminUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := auth.InitRbac(db, auth.WithDomains("default")); err != nil {
+		t.Fatal(err)
+	}
+	auth.InitJWTManager(&config.JWTConfig{SecretKey: "test-secret", AccessTokenExpiry: time.Minute, RefreshTokenExpiry: time.Hour})
+
+	password, err := security.HashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := &entity.AdminUser{Name: "alice", Password: password, Status: entity.StatusEnabled}
+	if err := db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 同名角色在两个租户中授予不同的接口，管理员只属于 acme
+	enforcer := auth.GetEnforcer()
+	if err := enforcer.AddPolicyInDomain("manager", "acme", "/orders/:id", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	if err := enforcer.AddPolicyInDomain("manager", "default", "/users/:id", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	if err := enforcer.AddRoleForUserInDomain("1", "manager", "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	uc := usecase.NewAuthUseCase(nil, repository.NewAdminUserRepository(db, nil), auth.NewLoginLimiter(noopLockoutCache{}, auth.LockoutOptions{}))
+	result, err := uc.AdminLogin(context.Background(), "alice", "secret123", "")
+	if err != nil {
+		t.Fatalf("AdminLogin() error = %v", err)
+	}
+	if result.TokenPair == nil {
+		t.Fatal("未启用两步验证时应直接签发令牌")
+	}
+	claims, err := auth.GetJWTManager().ValidateToken(result.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Tenant() != "acme" {
+		t.Errorf("Tenant() = %q, want acme", claims.Tenant())
+	}
+	if roles := claims.AllRoles(); len(roles) != 1 || roles[0] != "manager" {
+		t.Errorf("AllRoles() = %v, want [manager]", roles)
+	}
+
+	// 指定租户登录与刷新时切换租户，只能选择已分配角色的租户
+	if err := enforcer.AddRoleForUserInDomain("1", "viewer", "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.AdminLogin(context.Background(), "alice", "secret123", "default"); !isForbidden(err) {
+		t.Errorf("未分配角色的租户 AdminLogin() error = %v, want 禁止访问", err)
+	}
+	beta, err := uc.AdminLogin(context.Background(), "alice", "secret123", "beta")
+	if err != nil {
+		t.Fatalf("AdminLogin(beta) error = %v", err)
+	}
+	if claims, _ := auth.GetJWTManager().ValidateToken(beta.AccessToken); claims == nil || claims.Tenant() != "beta" {
+		t.Errorf("指定租户登录后 Tenant() 应为 beta, claims = %+v", claims)
+	}
+	if _, err := uc.RefreshToken(context.Background(), result.RefreshToken, "default"); !isForbidden(err) {
+		t.Errorf("切换到未分配角色的租户 RefreshToken() error = %v, want 禁止访问", err)
+	}
+	switched, err := uc.RefreshToken(context.Background(), result.RefreshToken, "beta")
+	if err != nil {
+		t.Fatalf("RefreshToken(beta) error = %v", err)
+	}
+	claims, err = auth.GetJWTManager().ValidateToken(switched.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roles := claims.AllRoles(); claims.Tenant() != "beta" || len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("切换租户后 Tenant() = %q, AllRoles() = %v, want beta [viewer]", claims.Tenant(), roles)
+	}
+
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/orders/:id", Jwt(), Rbac(), ok)
+	app.Get("/users/:id", Jwt(), Rbac(), ok)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"所属租户的策略", "/orders/1", fiber.StatusOK},
+		{"默认域的策略不生效", "/users/1", fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+result.AccessToken)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s 状态码 = %d, want %d", tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
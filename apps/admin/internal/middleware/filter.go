@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Filter 查询参数解析中间件
+// 将 filter[字段][操作符]、sort、fields 参数解析为 query.Query，按实体 T 注册的 Schema 校验字段
+func Filter[T any]() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		values := make(map[string][]string)
+		c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+			values[string(key)] = append(values[string(key)], string(value))
+		})
+
+		q, err := query.ParseValues(values, query.LookupSchema[T]())
+		if err != nil {
+			return response.ValidationError(c, err)
+		}
+
+		c.Locals("query", q)
+		return c.Next()
+	}
+}
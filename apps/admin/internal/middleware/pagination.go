@@ -8,12 +8,16 @@ import (
 )
 
 // Pagination 分页中间件
+// 携带 cursor 参数时（首页可为空值）使用游标分页，否则使用页码分页
 func Pagination() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		page, _ := strconv.Atoi(c.Query("page", "1"))
 		pageSize, _ := strconv.Atoi(c.Query("size", "10"))
 
 		req := query.NewPagination(page, pageSize)
+		if c.Context().QueryArgs().Has("cursor") {
+			req = query.NewCursorPagination(c.Query("cursor"), pageSize)
+		}
 
 		c.Locals("pagination", req)
 		return c.Next()
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/query"
+	"fmt"
 
 	"fiber_web/pkg/redis"
 
@@ -11,43 +14,88 @@ import (
 )
 
 type AdminUserRepository interface {
-	Create(ctx context.Context, admin_user *entity.AdminUser) error
-	FindByID(ctx context.Context, id uint) (*entity.AdminUser, error)
-	Update(ctx context.Context, admin_user *entity.AdminUser) error
-	Delete(ctx context.Context, id uint) error
+	query.Repository[entity.AdminUser]
+	FindByName(ctx context.Context, name string) (*entity.AdminUser, error)
+	FindByEmail(ctx context.Context, email string) (*entity.AdminUser, error)
+	FindByOidcSubject(ctx context.Context, subject string) (*entity.AdminUser, error)
+	// BindOidcSubject 管理员尚未绑定 OIDC 身份时绑定，已绑定时返回 false
+	BindOidcSubject(ctx context.Context, id uint, subject string) (bool, error)
+	// UseTOTPCounter 记录已使用的验证码时间步，时间步不大于已记录值时返回 false
+	UseTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error)
+	// ReplaceRecoveryCodes 恢复码仍为 old 时替换为 codes，用于原子地消耗恢复码
+	ReplaceRecoveryCodes(ctx context.Context, id uint, old, codes string) (bool, error)
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.AdminUser], error)
 }
 
+// admin_userRepository 不使用 CachedRepository：实体包含密码哈希、TOTP 密钥与恢复码，不能写入缓存
 type admin_userRepository struct {
+	query.Repository[entity.AdminUser]
 	db    *gorm.DB
 	cache *redis.Client
 }
 
 func NewAdminUserRepository(db *gorm.DB, cache *redis.Client) AdminUserRepository {
-	return &admin_userRepository{db: db, cache: cache}
+	return &admin_userRepository{
+		Repository: query.NewMySQLRepository[entity.AdminUser](db),
+		db:         db,
+		cache:      cache,
+	}
+}
+
+func (r *admin_userRepository) FindByName(ctx context.Context, name string) (*entity.AdminUser, error) {
+	return r.First(ctx, query.NewQuery().AddCondition("name", query.OpEq, name))
 }
 
-func (r *admin_userRepository) Create(ctx context.Context, admin_user *entity.AdminUser) error {
-	return r.db.WithContext(ctx).Create(admin_user).Error
+func (r *admin_userRepository) FindByEmail(ctx context.Context, email string) (*entity.AdminUser, error) {
+	return r.First(ctx, query.NewQuery().AddCondition("email", query.OpEq, email))
 }
 
-func (r *admin_userRepository) FindByID(ctx context.Context, id uint) (*entity.AdminUser, error) {
+func (r *admin_userRepository) FindByOidcSubject(ctx context.Context, subject string) (*entity.AdminUser, error) {
 	var admin_user entity.AdminUser
-	err := r.db.WithContext(ctx).First(&admin_user, id).Error
+	// oidc_subject 不在查询白名单中，直接查询
+	err := database.Conn(ctx, r.db).Where("oidc_subject = ?", subject).First(&admin_user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %w", query.ErrNotFound, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &admin_user, nil
 }
 
-func (r *admin_userRepository) Update(ctx context.Context, admin_user *entity.AdminUser) error {
-	return r.db.WithContext(ctx).Save(admin_user).Error
+func (r *admin_userRepository) BindOidcSubject(ctx context.Context, id uint, subject string) (bool, error) {
+	// 未绑定时为 NULL，唯一索引允许多个 NULL
+	result := database.Conn(ctx, r.db).Model(&entity.AdminUser{}).
+		Where("id = ? AND oidc_subject IS NULL", id).
+		Update("oidc_subject", subject)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
-func (r *admin_userRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.AdminUser{}, id).Error
+func (r *admin_userRepository) UseTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error) {
+	return r.updateIf(ctx, id, "totp_counter < ?", counter, "totp_counter", counter)
+}
+
+func (r *admin_userRepository) ReplaceRecoveryCodes(ctx context.Context, id uint, old, codes string) (bool, error) {
+	return r.updateIf(ctx, id, "recovery_codes = ?", old, "recovery_codes", codes)
+}
+
+// updateIf 满足条件时更新单个字段，返回是否更新成功
+func (r *admin_userRepository) updateIf(ctx context.Context, id uint, cond string, arg any, column string, value any) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&entity.AdminUser{}).
+		Where("id = ?", id).Where(cond, arg).
+		Update(column, value)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	return true, nil
 }
 
 func (r *admin_userRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.AdminUser], error) {
-	return query.NewMySQLQuerier[entity.AdminUser](r.db).FindPage(ctx, param)
+	return r.FindPage(ctx, param)
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/query"
+	"time"
+
+	"fiber_web/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	query.Repository[entity.ApiKey]
+	FindByPrefix(ctx context.Context, prefix string) (*entity.ApiKey, error)
+	// RevokeByCreator 吊销管理员创建的全部未吊销的API密钥
+	RevokeByCreator(ctx context.Context, createdBy uint) error
+	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.ApiKey], error)
+}
+
+// apiKeyRepository 不使用 CachedRepository：实体包含密钥哈希，不能写入缓存
+type apiKeyRepository struct {
+	query.Repository[entity.ApiKey]
+	db    *gorm.DB
+	cache *redis.Client
+}
+
+func NewApiKeyRepository(db *gorm.DB, cache *redis.Client) ApiKeyRepository {
+	return &apiKeyRepository{
+		Repository: query.NewMySQLRepository[entity.ApiKey](db),
+		db:         db,
+		cache:      cache,
+	}
+}
+
+// FindByPrefix 按前缀查询
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*entity.ApiKey, error) {
+	return r.First(ctx, query.NewQuery().AddCondition("prefix", query.OpEq, prefix))
+}
+
+// RevokeByCreator 吊销管理员创建的全部未吊销的API密钥
+func (r *apiKeyRepository) RevokeByCreator(ctx context.Context, createdBy uint) error {
+	return database.Conn(ctx, r.db).Model(&entity.ApiKey{}).
+		Where("created_by = ? AND revoked_at IS NULL", createdBy).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.ApiKey], error) {
+	return r.FindPage(ctx, param)
+}
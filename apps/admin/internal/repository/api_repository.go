@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/query"
 
 	"fiber_web/pkg/redis"
@@ -11,43 +12,53 @@ import (
 )
 
 type ApiRepository interface {
-	Create(ctx context.Context, api *entity.Api) error
-	FindByID(ctx context.Context, id uint) (*entity.Api, error)
-	Update(ctx context.Context, api *entity.Api) error
-	Delete(ctx context.Context, id uint) error
+	query.Repository[entity.Api]
+	// FindAll 查询全部接口，不经过缓存，用于路由同步
+	FindAll(ctx context.Context) ([]entity.Api, error)
+	// MarkStale 批量设置接口是否已不存在
+	MarkStale(ctx context.Context, ids []uint, stale bool) error
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Api], error)
 }
 
 type apiRepository struct {
+	*query.CachedRepository[entity.Api]
 	db    *gorm.DB
 	cache *redis.Client
 }
 
 func NewApiRepository(db *gorm.DB, cache *redis.Client) ApiRepository {
-	return &apiRepository{db: db, cache: cache}
-}
-
-func (r *apiRepository) Create(ctx context.Context, api *entity.Api) error {
-	return r.db.WithContext(ctx).Create(api).Error
+	return &apiRepository{
+		CachedRepository: query.NewCachedRepository(query.NewMySQLRepository[entity.Api](db), cache, query.CacheOptions{Namespace: "apis"}),
+		db:               db,
+		cache:            cache,
+	}
 }
 
-func (r *apiRepository) FindByID(ctx context.Context, id uint) (*entity.Api, error) {
-	var api entity.Api
-	err := r.db.WithContext(ctx).First(&api, id).Error
-	if err != nil {
+func (r *apiRepository) FindAll(ctx context.Context) ([]entity.Api, error) {
+	var apis []entity.Api
+	if err := database.Conn(ctx, r.db).Order("id").Find(&apis).Error; err != nil {
 		return nil, err
 	}
-	return &api, nil
-}
-
-func (r *apiRepository) Update(ctx context.Context, api *entity.Api) error {
-	return r.db.WithContext(ctx).Save(api).Error
+	return apis, nil
 }
 
-func (r *apiRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Api{}, id).Error
+func (r *apiRepository) MarkStale(ctx context.Context, ids []uint, stale bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := database.Conn(ctx, r.db).Model(&entity.Api{}).
+		Where("id IN ?", ids).
+		Update("stale", stale).Error
+	if err != nil {
+		return err
+	}
+	keys := make([]any, len(ids))
+	for i, id := range ids {
+		keys[i] = id
+	}
+	return r.Invalidate(ctx, keys...)
 }
 
 func (r *apiRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Api], error) {
-	return query.NewMySQLQuerier[entity.Api](r.db).FindPage(ctx, param)
+	return r.FindPage(ctx, param)
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/redis"
 
 	"gorm.io/gorm"
@@ -8,21 +9,28 @@ import (
 
 // InitRepositories 初始化所有仓储
 func InitRepositories(db *gorm.DB, redisClient *redis.Client) *Repositories {
+	registerQuerySchemas()
+
 	return &Repositories{
+		Tx:             database.NewTxManager(db, database.TxOptions{}),
 		UserRepository: NewUserRepository(db, redisClient),
 		// 在这里添加其他仓储的初始化
 		AdminUserRepository: NewAdminUserRepository(db, redisClient),
 		ApiRepository:       NewApiRepository(db, redisClient),
 		MenuRepository:      NewMenuRepository(db, redisClient),
+		RoleRepository:      NewRoleRepository(db, redisClient),
+		ApiKeyRepository:    NewApiKeyRepository(db, redisClient),
 	}
 }
 
 // Repositories 仓储集合
 type Repositories struct {
+	Tx             database.TxManager // 事务管理器，跨仓储的写操作通过它保证原子性
 	UserRepository UserRepository
 	// 在这里添加其他仓储
 	AdminUserRepository AdminUserRepository
 	ApiRepository       ApiRepository
 	MenuRepository      MenuRepository
 	RoleRepository      RoleRepository
+	ApiKeyRepository    ApiKeyRepository
 }
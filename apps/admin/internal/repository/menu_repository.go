@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/query"
 
 	"fiber_web/pkg/redis"
@@ -11,43 +12,34 @@ import (
 )
 
 type MenuRepository interface {
-	Create(ctx context.Context, menu *entity.Menu) error
-	FindByID(ctx context.Context, id uint) (*entity.Menu, error)
-	Update(ctx context.Context, menu *entity.Menu) error
-	Delete(ctx context.Context, id uint) error
+	query.Repository[entity.Menu]
+	// FindAll 查询全部菜单，不经过缓存，用于组装菜单树
+	FindAll(ctx context.Context) ([]entity.Menu, error)
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Menu], error)
 }
 
 type menuRepository struct {
+	query.Repository[entity.Menu]
 	db    *gorm.DB
 	cache *redis.Client
 }
 
 func NewMenuRepository(db *gorm.DB, cache *redis.Client) MenuRepository {
-	return &menuRepository{db: db, cache: cache}
-}
-
-func (r *menuRepository) Create(ctx context.Context, menu *entity.Menu) error {
-	return r.db.WithContext(ctx).Create(menu).Error
+	return &menuRepository{
+		Repository: query.NewCachedRepository(query.NewMySQLRepository[entity.Menu](db), cache, query.CacheOptions{Namespace: "menus"}),
+		db:         db,
+		cache:      cache,
+	}
 }
 
-func (r *menuRepository) FindByID(ctx context.Context, id uint) (*entity.Menu, error) {
-	var menu entity.Menu
-	err := r.db.WithContext(ctx).First(&menu, id).Error
-	if err != nil {
+func (r *menuRepository) FindAll(ctx context.Context) ([]entity.Menu, error) {
+	var menus []entity.Menu
+	if err := database.Conn(ctx, r.db).Order("id").Find(&menus).Error; err != nil {
 		return nil, err
 	}
-	return &menu, nil
-}
-
-func (r *menuRepository) Update(ctx context.Context, menu *entity.Menu) error {
-	return r.db.WithContext(ctx).Save(menu).Error
-}
-
-func (r *menuRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Menu{}, id).Error
+	return menus, nil
 }
 
 func (r *menuRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Menu], error) {
-	return query.NewMySQLQuerier[entity.Menu](r.db).FindPage(ctx, param)
+	return r.FindPage(ctx, param)
 }
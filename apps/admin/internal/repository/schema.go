@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/pkg/query"
+)
+
+// registerQuerySchemas 注册实体查询字段白名单，未注册的字段不能用于查询条件和排序
+func registerQuerySchemas() {
+	query.RegisterSchema[entity.User](query.MustParseSchema(&entity.User{}))
+	query.RegisterSchema[entity.AdminUser](query.MustParseSchema(&entity.AdminUser{}).Omit("password", "totp_secret", "totp_counter", "recovery_codes", "oidc_subject"))
+	query.RegisterSchema[entity.Role](query.MustParseSchema(&entity.Role{}))
+	query.RegisterSchema[entity.Api](query.MustParseSchema(&entity.Api{}))
+	query.RegisterSchema[entity.Menu](query.MustParseSchema(&entity.Menu{}))
+	query.RegisterSchema[entity.ApiKey](query.MustParseSchema(&entity.ApiKey{}).Omit("key_hash"))
+}
@@ -11,55 +11,39 @@ import (
 
 // UserRepository 用户仓库接口
 type UserRepository interface {
-	Create(ctx context.Context, user *entity.User) error
-	FindByID(ctx context.Context, id uint) (*entity.User, error)
+	query.Repository[entity.User]
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
-	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id uint) error
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.User], error)
+	ListByCursor(ctx context.Context, param *query.Query) (*query.CursorResult[entity.User], error)
 }
 
 // userRepository 用户仓库实现
 type userRepository struct {
-	db    *gorm.DB
+	query.Repository[entity.User]
 	cache *redis.Client
 }
 
 // NewUserRepository 创建用户仓库
 func NewUserRepository(db *gorm.DB, cache *redis.Client) UserRepository {
-	return &userRepository{db: db, cache: cache}
-}
-
-func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
-}
-
-func (r *userRepository) FindByID(ctx context.Context, id uint) (*entity.User, error) {
-	var user entity.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
-	if err != nil {
-		return nil, err
+	return &userRepository{
+		Repository: query.NewCachedRepository(query.NewMySQLRepository[entity.User](db), cache, query.CacheOptions{Namespace: "users"}),
+		cache:      cache,
 	}
-	return &user, nil
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
-	var user entity.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return r.First(ctx, query.NewQuery().AddCondition("email", query.OpEq, email))
 }
 
-func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return r.First(ctx, query.NewQuery().AddCondition("username", query.OpEq, username))
 }
 
-func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.User{}, id).Error
+func (r *userRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.User], error) {
+	return r.FindPage(ctx, param)
 }
 
-func (r *userRepository) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.User], error) {
-	return query.NewMySQLQuerier[entity.User](r.db).FindPage(ctx, param)
+func (r *userRepository) ListByCursor(ctx context.Context, param *query.Query) (*query.CursorResult[entity.User], error) {
+	return r.FindCursor(ctx, param)
 }
@@ -2,16 +2,41 @@ package transport
 
 import (
 	"fiber_web/apps/admin/internal/endpoint"
+	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/middleware"
+	"fiber_web/apps/admin/internal/usecase"
 	"github.com/gofiber/fiber/v2"
 	"time"
 )
 
-func RegisterApiHttp(app fiber.Router, handlers *endpoint.Handlers) {
+func RegisterApiHttp(app fiber.Router, handlers *endpoint.Handlers, uses *usecase.UseCases) {
 	app.Post("/register", handlers.UserHandler.Register)
 	app.Post("/login", handlers.UserHandler.Login)
-	app.Post("/refresh-token", middleware.Jwt(), middleware.RateLimit(3, time.Minute), handlers.UserHandler.RefreshToken)
-	app.Get("/users", middleware.Pagination(), handlers.UserHandler.List)
-	app.Get("/test", middleware.Jwt(), middleware.Pagination(), handlers.UserHandler.TestUser)
+	app.Post("/admin/login", handlers.UserHandler.AdminLogin)
+	app.Post("/admin/login/2fa", handlers.UserHandler.VerifyTwoFactor)
+	if handlers.OidcHandler != nil {
+		app.Get("/admin/oidc/login", middleware.RateLimit(10, time.Minute), handlers.OidcHandler.Login)
+		app.Get("/admin/oidc/callback", handlers.OidcHandler.Callback)
+	}
+	app.Post("/admin/2fa/enroll", middleware.Jwt(), handlers.TwoFactorHandler.Enroll)
+	app.Post("/admin/2fa/confirm", middleware.Jwt(), handlers.TwoFactorHandler.Confirm)
+	app.Post("/admin/2fa/disable", middleware.Jwt(), handlers.TwoFactorHandler.Disable)
+	app.Get("/admin/api-keys", middleware.Jwt(), middleware.Pagination(), handlers.ApiKeyHandler.ListApiKeys)
+	app.Post("/admin/api-keys", middleware.Jwt(), handlers.ApiKeyHandler.CreateApiKey)
+	app.Delete("/admin/api-keys/:id", middleware.Jwt(), handlers.ApiKeyHandler.RevokeApiKey)
+	app.Get("/admin/roles/:id/apis", middleware.Jwt(), middleware.Rbac(), handlers.RoleHandler.ListRoleApis)
+	app.Put("/admin/roles/:id/apis/:apiId", middleware.Jwt(), middleware.Rbac(), handlers.RoleHandler.GrantApi)
+	app.Delete("/admin/roles/:id/apis/:apiId", middleware.Jwt(), middleware.Rbac(), handlers.RoleHandler.RevokeApi)
+	app.Get("/admin/menus/tree", middleware.Jwt(), handlers.MenuHandler.MenuTree)
+	app.Put("/admin/roles/:id/menus/:menuId", middleware.Jwt(), middleware.Rbac(), handlers.MenuHandler.GrantToRole)
+	app.Delete("/admin/roles/:id/menus/:menuId", middleware.Jwt(), middleware.Rbac(), handlers.MenuHandler.RevokeFromRole)
+	app.Post("/admin/rbac/explain", middleware.Jwt(), middleware.Rbac(), handlers.RoleHandler.ExplainPermission)
+	app.Post("/logout", middleware.Jwt(), handlers.UserHandler.Logout)
+	app.Post("/refresh-token", middleware.RateLimit(3, time.Minute), handlers.UserHandler.RefreshToken)
+	app.Get("/sessions", middleware.Jwt(), handlers.SessionHandler.ListSessions)
+	app.Delete("/sessions", middleware.Jwt(), handlers.SessionHandler.RevokeOtherSessions)
+	app.Delete("/sessions/:id", middleware.Jwt(), handlers.SessionHandler.RevokeSession)
+	app.Get("/users", middleware.Pagination(), middleware.Filter[entity.User](), handlers.UserHandler.List)
+	app.Get("/test", middleware.JwtOrApiKey(uses.ApiKeyUseCase), middleware.Pagination(), handlers.UserHandler.TestUser)
 	app.Get("/users/me", middleware.Jwt(), handlers.UserHandler.GetProfile)
 }
@@ -4,8 +4,10 @@ import (
 	"fiber_web/apps/admin/internal/endpoint"
 	"fiber_web/apps/admin/internal/middleware"
 	"fiber_web/apps/admin/internal/usecase"
+	"fiber_web/pkg/auth"
 	"fiber_web/pkg/config"
 	"fiber_web/pkg/validator"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -28,10 +30,12 @@ func NewRouterInitializer(app *fiber.App, uses *usecase.UseCases) *RouterInitial
 func (r *RouterInitializer) InitAPIRoutes() error {
 	verify := validator.New(&validator.Config{Language: config.Data.App.Language})
 	handlers := endpoint.InitHandlers(r.uses, verify)
+	// 公开签名公钥，供其他服务验证令牌
+	r.app.Get(auth.JWKSPath, auth.JWKSHandler(auth.GetJWTManager().Keys(), 5*time.Minute))
 	v1 := r.app.Group("/api/v1", middleware.CommMiddleware(config.Data.App.Env)...)
 	{
 		// 注册路由
-		RegisterApiHttp(v1, handlers)
+		RegisterApiHttp(v1, handlers, r.uses)
 	}
 
 	return nil
@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"fiber_web/apps/admin/internal/entity"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// versionSegment 路径中的版本段，如 v1
+var versionSegment = regexp.MustCompile(`^v\d+$`)
+
+// RegisteredApis 获取应用已注册的路由，忽略中间件与 Fiber 为 GET 自动注册的 HEAD 路由
+func RegisteredApis(app *fiber.App) []entity.Api {
+	routes := app.GetRoutes(true)
+	apis := make([]entity.Api, 0, len(routes))
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || route.Path == "" || route.Path == "*" {
+			continue
+		}
+		name := route.Name
+		if name == "" {
+			name = route.Method + " " + route.Path
+		}
+		apis = append(apis, entity.Api{
+			ApiGroup: routeGroup(route.Path),
+			Name:     name,
+			Path:     route.Path,
+			Method:   route.Method,
+		})
+	}
+	return apis
+}
+
+// routeGroup 取路径中 api 与版本段之后的第一段作为分组，如 /api/v1/admin/api-keys 的分组为 admin
+func routeGroup(path string) string {
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "api" || versionSegment.MatchString(segment) {
+			continue
+		}
+		return segment
+	}
+	return ""
+}
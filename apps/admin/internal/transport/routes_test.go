@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRegisteredApis(t *testing.T) {
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	// 中间件不是接口
+	app.Use(func(c *fiber.Ctx) error { return c.Next() })
+	api := app.Group("/api/v1", func(c *fiber.Ctx) error { return c.Next() })
+	api.Get("/admin/users", ok).Name("用户列表")
+	api.Post("/admin/users", ok)
+	api.Get("/admin/users/:id", ok)
+	app.Get("/health", ok)
+
+	want := map[string]string{
+		"GET /api/v1/admin/users":     "用户列表",
+		"POST /api/v1/admin/users":    "POST /api/v1/admin/users",
+		"GET /api/v1/admin/users/:id": "GET /api/v1/admin/users/:id",
+		"GET /health":                 "GET /health",
+	}
+	apis := RegisteredApis(app)
+	if len(apis) != len(want) {
+		t.Fatalf("RegisteredApis() = %+v, want %d 个接口", apis, len(want))
+	}
+	for _, api := range apis {
+		key := api.Method + " " + api.Path
+		name, ok := want[key]
+		if !ok {
+			t.Errorf("不应返回 %s，HEAD 路由与中间件需忽略", key)
+			continue
+		}
+		if api.Name != name {
+			t.Errorf("%s 名称 = %q, want %q", key, api.Name, name)
+		}
+		if api.ApiGroup != routeGroup(api.Path) {
+			t.Errorf("%s 分组 = %q, want %q", key, api.ApiGroup, routeGroup(api.Path))
+		}
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/admin/api-keys", "admin"},
+		{"/api/v2/users/:id", "users"},
+		{"/v1/roles", "roles"},
+		{"/api/orders", "orders"},
+		{"/health", "health"},
+		{"/api/v1", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := routeGroup(tt.path); got != tt.want {
+				t.Errorf("routeGroup(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
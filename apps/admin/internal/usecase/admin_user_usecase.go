@@ -2,14 +2,19 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/query"
+	"strconv"
 )
 
 // AdminUserUseCase 用例接口
 type AdminUserUseCase interface {
 	CreateAdminUser(ctx context.Context, admin_user *entity.AdminUser) error
+	CreateAdminUserWithRoles(ctx context.Context, admin_user *entity.AdminUser, roles []string) error
 	GetAdminUser(ctx context.Context, id uint) (*entity.AdminUser, error)
 	UpdateAdminUser(ctx context.Context, admin_user *entity.AdminUser) error
 	DeleteAdminUser(ctx context.Context, id uint) error
@@ -18,30 +23,97 @@ type AdminUserUseCase interface {
 
 // admin_userUseCase 用例实现
 type admin_userUseCase struct {
+	tx             database.TxManager
 	admin_userRepo repository.AdminUserRepository
+	apiKeyRepo     repository.ApiKeyRepository
 }
 
 // NewAdminUserUseCase 创建用例实例
-func NewAdminUserUseCase(admin_userRepo repository.AdminUserRepository) AdminUserUseCase {
+func NewAdminUserUseCase(tx database.TxManager, admin_userRepo repository.AdminUserRepository, apiKeyRepo repository.ApiKeyRepository) AdminUserUseCase {
 	return &admin_userUseCase{
+		tx:             tx,
 		admin_userRepo: admin_userRepo,
+		apiKeyRepo:     apiKeyRepo,
 	}
 }
 
 func (uc *admin_userUseCase) CreateAdminUser(ctx context.Context, admin_user *entity.AdminUser) error {
+	copyProtected(admin_user, &entity.AdminUser{})
+	if err := hashPassword(&admin_user.Password); err != nil {
+		return err
+	}
 	return uc.admin_userRepo.Create(ctx, admin_user)
 }
 
+// CreateAdminUserWithRoles 在同一事务中创建管理员并分配 Casbin 角色，角色主体为管理员ID
+func (uc *admin_userUseCase) CreateAdminUserWithRoles(ctx context.Context, admin_user *entity.AdminUser, roles []string) error {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return errors.New("rbac enforcer not initialized")
+	}
+	copyProtected(admin_user, &entity.AdminUser{})
+	if err := hashPassword(&admin_user.Password); err != nil {
+		return err
+	}
+	return uc.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := uc.admin_userRepo.Create(ctx, admin_user); err != nil {
+			return err
+		}
+		return enforcer.AddRolesForUserCtx(ctx, strconv.FormatUint(uint64(admin_user.Id), 10), roles...)
+	})
+}
+
 func (uc *admin_userUseCase) GetAdminUser(ctx context.Context, id uint) (*entity.AdminUser, error) {
 	return uc.admin_userRepo.FindByID(ctx, id)
 }
 
 func (uc *admin_userUseCase) UpdateAdminUser(ctx context.Context, admin_user *entity.AdminUser) error {
-	return uc.admin_userRepo.Update(ctx, admin_user)
+	current, err := uc.admin_userRepo.FindByID(ctx, admin_user.Id)
+	if err != nil {
+		return err
+	}
+	copyProtected(admin_user, current)
+	if err := hashPassword(&admin_user.Password); err != nil {
+		return err
+	}
+	// 修改密码或禁用账号后强制下线，并吊销其创建的API密钥
+	revoke := (admin_user.Password != "" && admin_user.Password != current.Password) || admin_user.Status != entity.StatusEnabled
+	if err := uc.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := uc.admin_userRepo.Update(ctx, admin_user); err != nil {
+			return err
+		}
+		if revoke {
+			return uc.apiKeyRepo.RevokeByCreator(ctx, admin_user.Id)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if revoke {
+		return revokeTokens(ctx, adminSubject(admin_user.Id))
+	}
+	return nil
+}
+
+// copyProtected 复制两步验证与单点登录绑定字段，这些字段只能通过 TwoFactorUseCase 与 OidcUseCase 修改
+func copyProtected(dst, src *entity.AdminUser) {
+	dst.TotpSecret = src.TotpSecret
+	dst.TotpEnabled = src.TotpEnabled
+	dst.TotpCounter = src.TotpCounter
+	dst.RecoveryCodes = src.RecoveryCodes
+	dst.OidcSubject = src.OidcSubject
 }
 
 func (uc *admin_userUseCase) DeleteAdminUser(ctx context.Context, id uint) error {
-	return uc.admin_userRepo.Delete(ctx, id)
+	if err := uc.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := uc.admin_userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return uc.apiKeyRepo.RevokeByCreator(ctx, id)
+	}); err != nil {
+		return err
+	}
+	return revokeTokens(ctx, adminSubject(id))
 }
 
 func (uc *admin_userUseCase) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.AdminUser], error) {
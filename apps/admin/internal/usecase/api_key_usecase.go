@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/utils/errorx"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiKeyTouchInterval 最近使用时间的更新间隔，避免每次请求都写库
+const apiKeyTouchInterval = time.Minute
+
+// CreatedApiKey 新建的API密钥，Key 只在创建时返回一次
+type CreatedApiKey struct {
+	*entity.ApiKey
+	Key string `json:"key"`
+}
+
+// ApiKeyUseCase API密钥用例接口
+type ApiKeyUseCase interface {
+	auth.APIKeyVerifier
+	// CreateApiKey 创建API密钥，只能授予创建者自身拥有的角色；ttl 为 0 时永不过期
+	CreateApiKey(ctx context.Context, creator *auth.Claims, name string, roles []string, ttl time.Duration) (*CreatedApiKey, error)
+	// RevokeApiKey 吊销管理员创建的API密钥，立即生效；密钥不属于该管理员时返回 query.ErrNotFound
+	RevokeApiKey(ctx context.Context, ownerID, id uint) error
+	// List 分页查询管理员创建的API密钥
+	List(ctx context.Context, ownerID uint, param *query.Query) (*query.PageResult[entity.ApiKey], error)
+}
+
+// apiKeyUseCase API密钥用例实现
+type apiKeyUseCase struct {
+	apiKeyRepo    repository.ApiKeyRepository
+	adminUserRepo repository.AdminUserRepository
+}
+
+// NewApiKeyUseCase 创建API密钥用例实例
+func NewApiKeyUseCase(apiKeyRepo repository.ApiKeyRepository, adminUserRepo repository.AdminUserRepository) ApiKeyUseCase {
+	return &apiKeyUseCase{
+		apiKeyRepo:    apiKeyRepo,
+		adminUserRepo: adminUserRepo,
+	}
+}
+
+func (uc *apiKeyUseCase) CreateApiKey(ctx context.Context, creator *auth.Claims, name string, roles []string, ttl time.Duration) (*CreatedApiKey, error) {
+	if len(roles) == 0 {
+		return nil, errorx.NewParamError("至少授予一个角色")
+	}
+	for _, role := range roles {
+		if strings.Contains(role, ",") {
+			return nil, errorx.NewParamError("无效的角色: " + role)
+		}
+		// 防止通过API密钥提升权限
+		if !creator.HasRole(role) {
+			return nil, errorx.NewForbiddenError("不能授予自己没有的角色: " + role)
+		}
+	}
+
+	generated, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	apiKey := &entity.ApiKey{
+		Name:      name,
+		Prefix:    generated.Prefix,
+		KeyHash:   generated.Hash,
+		Roles:     strings.Join(roles, ","),
+		CreatedBy: uint(creator.UserID),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if err := uc.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return &CreatedApiKey{ApiKey: apiKey, Key: generated.Key}, nil
+}
+
+func (uc *apiKeyUseCase) RevokeApiKey(ctx context.Context, ownerID, id uint) error {
+	apiKey, err := uc.apiKeyRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 不区分不存在与无权访问，避免泄露其他管理员的密钥
+	if apiKey.CreatedBy != ownerID {
+		return query.ErrNotFound
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+	return uc.apiKeyRepo.UpdateFields(ctx, id, map[string]any{"revoked_at": time.Now()})
+}
+
+func (uc *apiKeyUseCase) List(ctx context.Context, ownerID uint, param *query.Query) (*query.PageResult[entity.ApiKey], error) {
+	return uc.apiKeyRepo.List(ctx, param.AddCondition("created_by", query.OpEq, ownerID))
+}
+
+// VerifyAPIKey 实现 auth.APIKeyVerifier 接口
+func (uc *apiKeyUseCase) VerifyAPIKey(ctx context.Context, key string) (*auth.Claims, error) {
+	prefix, err := auth.ParseAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := uc.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if errors.Is(err, query.ErrNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !auth.MatchAPIKey(key, apiKey.KeyHash) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, auth.ErrRevokedAPIKey
+	}
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, auth.ErrExpiredAPIKey
+	}
+	roles := strings.Split(apiKey.Roles, ",")
+	if err := uc.checkCreator(ctx, apiKey.CreatedBy, roles); err != nil {
+		return nil, err
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := uc.apiKeyRepo.UpdateFields(ctx, apiKey.Id, map[string]any{"last_used_at": now}); err != nil {
+			logger.Warn("Failed to update api key last used time", logger.Any("id", apiKey.Id), logger.ErrorField(err))
+		}
+	}
+	return auth.NewAPIKeyClaims(uint64(apiKey.Id), apiKey.Name, roles), nil
+}
+
+// checkCreator 检查创建者仍然启用且仍具有密钥的全部角色，否则按已吊销处理
+// API密钥在默认域中鉴权，因此只检查创建者在默认域中的角色。
+func (uc *apiKeyUseCase) checkCreator(ctx context.Context, createdBy uint, roles []string) error {
+	creator, err := uc.adminUserRepo.FindByID(ctx, createdBy)
+	if errors.Is(err, query.ErrNotFound) {
+		return auth.ErrRevokedAPIKey
+	}
+	if err != nil {
+		return err
+	}
+	if creator.Status != entity.StatusEnabled {
+		return auth.ErrRevokedAPIKey
+	}
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return errors.New("rbac enforcer not initialized")
+	}
+	held, err := enforcer.GetRolesForUser(strconv.FormatUint(uint64(createdBy), 10))
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !slices.Contains(held, role) {
+			return auth.ErrRevokedAPIKey
+		}
+	}
+	return nil
+}
@@ -4,9 +4,18 @@ import (
 	"context"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/query"
+	"strings"
 )
 
+// ApiSyncResult 路由同步结果
+type ApiSyncResult struct {
+	Created  int `json:"created"`  // 新增的接口数
+	Stale    int `json:"stale"`    // 新标记为已不存在的接口数
+	Restored int `json:"restored"` // 重新注册、取消标记的接口数
+}
+
 // ApiUseCase 用例接口
 type ApiUseCase interface {
 	CreateApi(ctx context.Context, api *entity.Api) error
@@ -14,16 +23,20 @@ type ApiUseCase interface {
 	UpdateApi(ctx context.Context, api *entity.Api) error
 	DeleteApi(ctx context.Context, id uint) error
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Api], error)
+	// SyncRoutes 按已注册的路由同步接口表：新增缺少的接口，标记已不存在的接口，已有接口的名称与分组不覆盖
+	SyncRoutes(ctx context.Context, routes []entity.Api) (*ApiSyncResult, error)
 }
 
 // apiUseCase 用例实现
 type apiUseCase struct {
+	tx      database.TxManager
 	apiRepo repository.ApiRepository
 }
 
 // NewApiUseCase 创建用例实例
-func NewApiUseCase(apiRepo repository.ApiRepository) ApiUseCase {
+func NewApiUseCase(tx database.TxManager, apiRepo repository.ApiRepository) ApiUseCase {
 	return &apiUseCase{
+		tx:      tx,
 		apiRepo: apiRepo,
 	}
 }
@@ -47,3 +60,65 @@ func (uc *apiUseCase) DeleteApi(ctx context.Context, id uint) error {
 func (uc *apiUseCase) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Api], error) {
 	return uc.apiRepo.List(ctx, param)
 }
+
+func (uc *apiUseCase) SyncRoutes(ctx context.Context, routes []entity.Api) (*ApiSyncResult, error) {
+	result := &ApiSyncResult{}
+	err := uc.tx.Transaction(ctx, func(ctx context.Context) error {
+		apis, err := uc.apiRepo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]*entity.Api, len(apis))
+		for i := range apis {
+			existing[routeKey(apis[i].Method, apis[i].Path)] = &apis[i]
+		}
+
+		registered := make(map[string]bool, len(routes))
+		var restore []uint
+		for _, route := range routes {
+			key := routeKey(route.Method, route.Path)
+			if registered[key] {
+				continue
+			}
+			registered[key] = true
+
+			if api, ok := existing[key]; ok {
+				if api.Stale {
+					restore = append(restore, api.Id)
+				}
+				continue
+			}
+			api := route
+			api.Id = 0
+			api.Stale = false
+			if err := uc.apiRepo.Create(ctx, &api); err != nil {
+				return err
+			}
+			result.Created++
+		}
+
+		var stale []uint
+		for key, api := range existing {
+			if !registered[key] && !api.Stale {
+				stale = append(stale, api.Id)
+			}
+		}
+		if err := uc.apiRepo.MarkStale(ctx, restore, false); err != nil {
+			return err
+		}
+		if err := uc.apiRepo.MarkStale(ctx, stale, true); err != nil {
+			return err
+		}
+		result.Stale, result.Restored = len(stale), len(restore)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// routeKey 接口的唯一标识
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/redis/redistest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB 创建内存 SQLite 数据库并建表
+func setupTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSyncRoutes(t *testing.T) {
+	db := setupTestDB(t, &entity.Api{})
+	uc := NewApiUseCase(database.NewTxManager(db, database.TxOptions{}), repository.NewApiRepository(db, redistest.NewClient(t)))
+	ctx := context.Background()
+
+	route := func(method, path string) entity.Api {
+		return entity.Api{ApiGroup: "admin", Name: method + " " + path, Method: method, Path: path}
+	}
+	// 按顺序执行，每一步基于上一步同步后的接口表
+	tests := []struct {
+		name   string
+		routes []entity.Api
+		want   ApiSyncResult
+		stale  []string // 同步后标记为已不存在的接口
+	}{
+		{
+			name:   "首次同步新增全部接口，重复路由只新增一次",
+			routes: []entity.Api{route("GET", "/users"), route("POST", "/users"), route("GET", "/users/:id"), route("GET", "/users")},
+			want:   ApiSyncResult{Created: 3},
+		},
+		{
+			name:   "路由移除后标记为已不存在",
+			routes: []entity.Api{route("GET", "/users"), route("GET", "/roles")},
+			want:   ApiSyncResult{Created: 1, Stale: 2},
+			stale:  []string{"POST /users", "GET /users/:id"},
+		},
+		{
+			name:   "路由重新注册后取消标记",
+			routes: []entity.Api{route("GET", "/users"), route("get", "/users/:id"), route("GET", "/roles")},
+			want:   ApiSyncResult{Restored: 1},
+			stale:  []string{"POST /users"},
+		},
+		{
+			name:   "路由不变时不做修改",
+			routes: []entity.Api{route("GET", "/users"), route("GET", "/users/:id"), route("GET", "/roles")},
+			want:   ApiSyncResult{},
+			stale:  []string{"POST /users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := uc.SyncRoutes(ctx, tt.routes)
+			if err != nil {
+				t.Fatalf("SyncRoutes() error = %v", err)
+			}
+			if *result != tt.want {
+				t.Errorf("SyncRoutes() = %+v, want %+v", *result, tt.want)
+			}
+
+			var stale []entity.Api
+			if err := db.Where("stale = ?", true).Find(&stale).Error; err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]bool, len(stale))
+			for _, api := range stale {
+				got[routeKey(api.Method, api.Path)] = true
+			}
+			if len(got) != len(tt.stale) {
+				t.Errorf("已不存在的接口 = %v, want %v", got, tt.stale)
+			}
+			for _, key := range tt.stale {
+				if !got[key] {
+					t.Errorf("接口 %s 应标记为已不存在", key)
+				}
+			}
+		})
+	}
+
+	// 已有接口的名称与分组不被覆盖
+	if err := db.Model(&entity.Api{}).Where("method = ? AND path = ?", "GET", "/users").Update("name", "用户列表").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.SyncRoutes(ctx, []entity.Api{route("GET", "/users")}); err != nil {
+		t.Fatalf("SyncRoutes() error = %v", err)
+	}
+	var api entity.Api
+	if err := db.Where("method = ? AND path = ?", "GET", "/users").First(&api).Error; err != nil {
+		t.Fatal(err)
+	}
+	if api.Name != "用户列表" {
+		t.Errorf("同步后名称 = %q, want 用户列表", api.Name)
+	}
+}
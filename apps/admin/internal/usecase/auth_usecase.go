@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/security"
+	"fiber_web/pkg/utils/errorx"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dummyPasswordHash 账号不存在时用于比对的哈希，使响应时间与密码错误时一致，避免枚举账号
+const dummyPasswordHash = "$2a$10$31g.cRDkyYdom/31V7fYT.3CSEbcvnr6w2yqpjNzNpFFFUtNazdgG"
+
+// AdminClaim 管理员令牌的自定义声明，用于区分ID可能相同的管理员与普通用户
+const AdminClaim = "admin"
+
+// AuthUseCase 认证用例接口
+type AuthUseCase interface {
+	// Login 普通用户登录，tenant 为登录的租户，为空时自动选择
+	Login(ctx context.Context, username, password, tenant string) (*auth.TokenPair, error)
+	// AdminLogin 管理员登录，启用两步验证时返回质询令牌；tenant 为登录的租户，为空时自动选择
+	AdminLogin(ctx context.Context, name, password, tenant string) (*LoginResult, error)
+	// VerifyTwoFactor 校验质询令牌与第二因素，通过后签发令牌
+	VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*auth.TokenPair, error)
+	// RefreshToken 刷新令牌，tenant 不为空时切换到该租户并重新获取角色，为空时沿用令牌中的租户
+	RefreshToken(ctx context.Context, refreshToken, tenant string) (*auth.TokenPair, error)
+}
+
+// LoginResult 管理员登录结果，启用两步验证时只返回质询令牌
+type LoginResult struct {
+	*auth.TokenPair
+	ChallengeToken   string `json:"challenge_token,omitempty"`
+	ChallengeExpires int64  `json:"challenge_expires,omitempty"` // 过期时间（秒）
+}
+
+// credential 待校验的账号信息
+type credential struct {
+	id           uint64
+	name         string
+	password     string
+	status       int8
+	subject      string         // Casbin 主体
+	tokenSubject string         // 令牌主体，会话按其分组
+	roles        []string       // Casbin 中没有角色时使用的默认角色
+	twoFactor    bool           // 是否需要两步验证
+	extra        map[string]any // 令牌自定义声明
+}
+
+// authUseCase 认证用例实现
+type authUseCase struct {
+	userRepo       repository.UserRepository
+	admin_userRepo repository.AdminUserRepository
+	limiter        *auth.LoginLimiter
+}
+
+// NewAuthUseCase 创建认证用例实例
+func NewAuthUseCase(userRepo repository.UserRepository, admin_userRepo repository.AdminUserRepository, limiter *auth.LoginLimiter) AuthUseCase {
+	return &authUseCase{
+		userRepo:       userRepo,
+		admin_userRepo: admin_userRepo,
+		limiter:        limiter,
+	}
+}
+
+func (uc *authUseCase) Login(ctx context.Context, username, password, tenant string) (*auth.TokenPair, error) {
+	id, _, err := uc.authenticate(ctx, "user:"+username, password, tenant, func() (*credential, error) {
+		user, err := uc.userRepo.FindByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		cred := &credential{
+			id:       uint64(user.ID),
+			name:     user.Username,
+			password: user.Password,
+			status:   user.Status,
+			// 普通用户的 Casbin 主体与令牌主体带 user: 前缀，避免与管理员ID冲突
+			subject:      userSubject(user.ID),
+			tokenSubject: userSubject(user.ID),
+		}
+		if user.Role != "" {
+			cred.roles = []string{user.Role}
+		}
+		return cred, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth.GetJWTManager().IssueTokenPair(ctx, id)
+}
+
+func (uc *authUseCase) AdminLogin(ctx context.Context, name, password, tenant string) (*LoginResult, error) {
+	id, cred, err := uc.authenticate(ctx, adminLockKey(name), password, tenant, func() (*credential, error) {
+		admin, err := uc.admin_userRepo.FindByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// 管理员的 Casbin 主体为管理员ID，与 CreateAdminUserWithRoles 一致
+		return &credential{
+			id:           uint64(admin.Id),
+			name:         admin.Name,
+			password:     admin.Password,
+			status:       admin.Status,
+			subject:      strconv.FormatUint(uint64(admin.Id), 10),
+			tokenSubject: adminSubject(admin.Id),
+			twoFactor:    admin.TotpEnabled,
+			extra:        map[string]any{AdminClaim: true},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jwtManager := auth.GetJWTManager()
+	if cred.twoFactor {
+		challenge, err := jwtManager.IssueChallengeToken(id)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			ChallengeToken:   challenge,
+			ChallengeExpires: int64(auth.ChallengeTokenExpiry.Seconds()),
+		}, nil
+	}
+	pair, err := jwtManager.IssueTokenPair(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// VerifyTwoFactor 第二因素校验失败与密码错误共用失败次数与锁定
+func (uc *authUseCase) VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*auth.TokenPair, error) {
+	jwtManager := auth.GetJWTManager()
+	claims, err := jwtManager.VerifyToken(ctx, challengeToken, auth.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrExpiredToken) || errors.Is(err, auth.ErrRevokedToken) {
+			return nil, errorx.NewAuthError("验证已失效，请重新登录")
+		}
+		return nil, err
+	}
+
+	lockKey := adminLockKey(claims.Username)
+	remaining, err := uc.limiter.Locked(ctx, lockKey)
+	if err != nil {
+		return nil, errorx.Wrap(err, "查询登录锁定状态失败").WithCode(errorx.CodeInternalError)
+	}
+	if remaining > 0 {
+		return nil, errorx.NewAccountLockedError(remaining)
+	}
+
+	admin, err := uc.admin_userRepo.FindByID(ctx, uint(claims.UserID))
+	if err != nil {
+		if errors.Is(err, query.ErrNotFound) {
+			return nil, errorx.NewAuthError("验证已失效，请重新登录")
+		}
+		return nil, errorx.Wrap(err, "查询账号失败").WithCode(errorx.CodeInternalError)
+	}
+	if admin.Status != entity.StatusEnabled {
+		return nil, errorx.NewForbiddenError("账号已禁用")
+	}
+	if admin.TotpEnabled {
+		ok, err := verifySecondFactor(ctx, uc.admin_userRepo, admin, code)
+		if err != nil {
+			return nil, errorx.Wrap(err, "校验验证码失败").WithCode(errorx.CodeInternalError)
+		}
+		if !ok {
+			return nil, uc.fail(ctx, lockKey, "验证码错误")
+		}
+	}
+
+	if err := uc.limiter.Reset(ctx, lockKey); err != nil {
+		logger.Warn("Failed to reset login failures", logger.String("key", lockKey), logger.ErrorField(err))
+	}
+	// 质询令牌只能使用一次，未配置令牌存储时依赖其较短的有效期
+	if err := jwtManager.Revoke(ctx, challengeToken); err != nil && !errors.Is(err, auth.ErrNoTokenStore) {
+		return nil, err
+	}
+	return jwtManager.IssueTokenPair(ctx, claims.Identity())
+}
+
+// RefreshToken 切换租户时在新租户中校验访问权限并重新获取角色，校验失败时刷新令牌不会被使用
+func (uc *authUseCase) RefreshToken(ctx context.Context, refreshToken, tenant string) (*auth.TokenPair, error) {
+	jwtManager := auth.GetJWTManager()
+	if tenant == "" {
+		return jwtManager.RefreshToken(ctx, refreshToken)
+	}
+	return jwtManager.RefreshTokenWith(ctx, refreshToken, func(claims *auth.Claims) (auth.Identity, error) {
+		enforcer := auth.GetEnforcer()
+		if enforcer == nil {
+			return auth.Identity{}, errorx.New("权限管理未初始化").WithCode(errorx.CodeInternalError)
+		}
+		// 与登录一致，管理员的 Casbin 主体为管理员ID，普通用户带 user: 前缀
+		subject := userSubject(uint(claims.UserID))
+		if isAdmin, _ := claims.Extra[AdminClaim].(bool); isAdmin {
+			subject = strconv.FormatUint(claims.UserID, 10)
+		}
+		selected, roles, err := tenantRoles(enforcer, subject, tenant)
+		if err != nil {
+			return auth.Identity{}, err
+		}
+		id := claims.Identity()
+		if len(roles) > 0 {
+			id.Roles = roles
+		}
+		id.Extra = withTenant(id.Extra, selected)
+		return id, nil
+	})
+}
+
+// adminLockKey 管理员登录锁定键
+func adminLockKey(name string) string {
+	return "admin:" + name
+}
+
+// authenticate 校验锁定状态、密码与账号状态，返回待签发的身份信息
+// 账号不存在与密码错误返回相同的错误并同样计入失败次数。
+func (uc *authUseCase) authenticate(ctx context.Context, lockKey, password, tenant string, find func() (*credential, error)) (auth.Identity, *credential, error) {
+	remaining, err := uc.limiter.Locked(ctx, lockKey)
+	if err != nil {
+		return auth.Identity{}, nil, errorx.Wrap(err, "查询登录锁定状态失败").WithCode(errorx.CodeInternalError)
+	}
+	if remaining > 0 {
+		return auth.Identity{}, nil, errorx.NewAccountLockedError(remaining)
+	}
+
+	cred, err := find()
+	if err != nil && !errors.Is(err, query.ErrNotFound) {
+		return auth.Identity{}, nil, errorx.Wrap(err, "查询账号失败").WithCode(errorx.CodeInternalError)
+	}
+	if cred == nil {
+		security.CheckPassword(password, dummyPasswordHash)
+		return auth.Identity{}, nil, uc.fail(ctx, lockKey, "用户名或密码错误")
+	}
+	if !security.CheckPassword(password, cred.password) {
+		return auth.Identity{}, nil, uc.fail(ctx, lockKey, "用户名或密码错误")
+	}
+	// 密码正确后再检查状态，避免泄露账号是否存在
+	if cred.status != entity.StatusEnabled {
+		return auth.Identity{}, nil, errorx.NewForbiddenError("账号已禁用")
+	}
+
+	// 需要两步验证时在第二因素通过后再清除失败记录
+	if !cred.twoFactor {
+		if err := uc.limiter.Reset(ctx, lockKey); err != nil {
+			logger.Warn("Failed to reset login failures", logger.String("key", lockKey), logger.ErrorField(err))
+		}
+	}
+
+	roles, extra := cred.roles, cred.extra
+	if enforcer := auth.GetEnforcer(); enforcer != nil {
+		tenant, casbinRoles, err := tenantRoles(enforcer, cred.subject, tenant)
+		if err != nil {
+			return auth.Identity{}, nil, err
+		}
+		if len(casbinRoles) > 0 {
+			roles = casbinRoles
+		}
+		extra = withTenant(extra, tenant)
+	}
+
+	return auth.Identity{
+		UserID:   cred.id,
+		Subject:  cred.tokenSubject,
+		Username: cred.name,
+		Roles:    roles,
+		Extra:    extra,
+	}, cred, nil
+}
+
+// tenantRoles 确定 Casbin 主体登录后所属的租户，并获取其在该租户中的角色，未启用多租户时租户为空
+// tenant 为空时由 Enforcer.DomainForUser 选择；指定 tenant 时主体须在该租户或通配域中分配了角色。
+func tenantRoles(enforcer *auth.Enforcer, subject, tenant string) (string, []string, error) {
+	if !enforcer.DomainsEnabled() {
+		if tenant != "" {
+			return "", nil, errorx.NewParamError("未启用多租户，不能指定租户")
+		}
+		roles, err := enforcer.GetRolesForUser(subject)
+		if err != nil {
+			return "", nil, errorx.Wrap(err, "查询角色失败").WithCode(errorx.CodeInternalError)
+		}
+		return "", roles, nil
+	}
+
+	if tenant == "" {
+		selected, err := enforcer.DomainForUser(subject)
+		if err != nil {
+			return "", nil, errorx.Wrap(err, "查询租户失败").WithCode(errorx.CodeInternalError)
+		}
+		tenant = selected
+	} else {
+		domains, err := enforcer.GetDomainsForUser(subject)
+		if err != nil {
+			return "", nil, errorx.Wrap(err, "查询租户失败").WithCode(errorx.CodeInternalError)
+		}
+		if tenant == auth.DomainWildcard || !(slices.Contains(domains, tenant) || slices.Contains(domains, auth.DomainWildcard)) {
+			return "", nil, errorx.NewForbiddenError("无权访问该租户")
+		}
+	}
+	roles, err := enforcer.GetRolesForUserInDomain(subject, tenant)
+	if err != nil {
+		return "", nil, errorx.Wrap(err, "查询角色失败").WithCode(errorx.CodeInternalError)
+	}
+	return tenant, roles, nil
+}
+
+// withTenant 返回写入租户声明后的自定义声明，middleware.Rbac 据此选择鉴权的域
+func withTenant(extra map[string]any, tenant string) map[string]any {
+	if tenant == "" {
+		return extra
+	}
+	merged := make(map[string]any, len(extra)+1)
+	maps.Copy(merged, extra)
+	merged[auth.TenantClaim] = tenant
+	return merged
+}
+
+// adminSubject 管理员的令牌主体
+func adminSubject(id uint) string {
+	return "admin:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// userSubject 普通用户的令牌主体，同时也是其 Casbin 主体
+func userSubject(id uint) string {
+	return "user:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// revokeTokens 吊销令牌主体的全部令牌与会话，用于修改密码、禁用或删除账号后强制下线
+func revokeTokens(ctx context.Context, subject string) error {
+	jwtManager := auth.GetJWTManager()
+	if jwtManager == nil {
+		return nil
+	}
+	if err := jwtManager.RevokeAllForUser(ctx, subject); err != nil && !errors.Is(err, auth.ErrNoTokenStore) {
+		return fmt.Errorf("吊销令牌失败: %w", err)
+	}
+	return nil
+}
+
+// hashPassword 哈希明文密码，已是哈希或为空时不处理
+func hashPassword(password *string) error {
+	if *password == "" || security.IsPasswordHash(*password) {
+		return nil
+	}
+	hash, err := security.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	*password = hash
+	return nil
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码，验证码不能重复使用，恢复码使用后失效
+func verifySecondFactor(ctx context.Context, repo repository.AdminUserRepository, admin *entity.AdminUser, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if counter, err := security.ValidateTOTP(admin.TotpSecret, code, time.Now(), security.TOTPSkew); err == nil {
+		return repo.UseTOTPCounter(ctx, admin.Id, counter)
+	}
+
+	codes := strings.Split(admin.RecoveryCodes, ",")
+	index := slices.Index(codes, security.HashRecoveryCode(code))
+	if admin.RecoveryCodes == "" || index < 0 {
+		return false, nil
+	}
+	remaining := slices.Delete(slices.Clone(codes), index, index+1)
+	return repo.ReplaceRecoveryCodes(ctx, admin.Id, admin.RecoveryCodes, strings.Join(remaining, ","))
+}
+
+// fail 记录登录失败，达到阈值时返回锁定错误
+func (uc *authUseCase) fail(ctx context.Context, lockKey, message string) error {
+	lockout, err := uc.limiter.Fail(ctx, lockKey)
+	if err != nil {
+		logger.Warn("Failed to record login failure", logger.String("key", lockKey), logger.ErrorField(err))
+	}
+	if lockout > 0 {
+		logger.Warn("Login locked", logger.String("key", lockKey), logger.Duration("lockout", lockout))
+		return errorx.NewAccountLockedError(lockout)
+	}
+	return errorx.NewAuthError(message)
+}
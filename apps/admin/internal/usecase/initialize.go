@@ -2,26 +2,42 @@ package usecase
 
 import (
 	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/redis"
 )
 
-// InitUseCases 初始化所有用例
-func InitUseCases(repos *repository.Repositories) *UseCases {
-	return &UseCases{
+// InitUseCases 初始化所有用例，oidcProvider 为 nil 时不启用单点登录
+func InitUseCases(repos *repository.Repositories, redisClient *redis.Client, oidcProvider *auth.OIDCProvider) *UseCases {
+	// 登录与两步验证共用失败次数与锁定
+	limiter := auth.NewLoginLimiter(redisClient, auth.LockoutOptions{})
+	uses := &UseCases{
 		UserUserCase: NewUserUseCase(repos.UserRepository),
+		AuthUseCase:  NewAuthUseCase(repos.UserRepository, repos.AdminUserRepository, limiter),
 		// 在这里添加其他用例的初始化
-		AdminUserUseCase: NewAdminUserUseCase(repos.AdminUserRepository),
-		ApiUseCase:       NewApiUseCase(repos.ApiRepository),
-		MenuUseCase:      NewMenuUseCase(repos.MenuRepository),
-		RoleUseCase:      NewRoleUseCase(repos.RoleRepository),
+		AdminUserUseCase: NewAdminUserUseCase(repos.Tx, repos.AdminUserRepository, repos.ApiKeyRepository),
+		TwoFactorUseCase: NewTwoFactorUseCase(repos.AdminUserRepository, limiter, config.Data.App.Name),
+		ApiKeyUseCase:    NewApiKeyUseCase(repos.ApiKeyRepository, repos.AdminUserRepository),
+		ApiUseCase:       NewApiUseCase(repos.Tx, repos.ApiRepository),
+		MenuUseCase:      NewMenuUseCase(repos.MenuRepository, repos.RoleRepository, redisClient),
+		RoleUseCase:      NewRoleUseCase(repos.RoleRepository, repos.ApiRepository),
 	}
+	if oidcProvider != nil {
+		uses.OidcUseCase = NewOidcUseCase(oidcProvider, &config.Data.OIDC, repos.Tx, repos.AdminUserRepository)
+	}
+	return uses
 }
 
 // UseCases 用例集合
 type UseCases struct {
 	UserUserCase UserUseCase
+	AuthUseCase  AuthUseCase
 	// 在这里添加其他用例
 	AdminUserUseCase AdminUserUseCase
+	TwoFactorUseCase TwoFactorUseCase
+	ApiKeyUseCase    ApiKeyUseCase
 	ApiUseCase       ApiUseCase
 	MenuUseCase      MenuUseCase
 	RoleUseCase      RoleUseCase
+	OidcUseCase      OidcUseCase // 未启用单点登录时为 nil
 }
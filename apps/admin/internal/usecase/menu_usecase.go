@@ -1,10 +1,26 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/logger"
 	"fiber_web/pkg/query"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// menuTreeVersionKey 菜单缓存版本，菜单或授权变更时递增，使所有角色的缓存失效
+	menuTreeVersionKey = "menus:tree:version"
+	// menuTreeTTL 角色菜单缓存有效期，绕过用例直接修改的 Casbin 策略最迟在此时间后生效
+	menuTreeTTL = 10 * time.Minute
 )
 
 // MenuUseCase 用例接口
@@ -12,24 +28,39 @@ type MenuUseCase interface {
 	CreateMenu(ctx context.Context, menu *entity.Menu) error
 	GetMenu(ctx context.Context, id uint) (*entity.Menu, error)
 	UpdateMenu(ctx context.Context, menu *entity.Menu) error
+	// DeleteMenu 删除菜单并收回所有角色查看该菜单的权限
 	DeleteMenu(ctx context.Context, id uint) error
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Menu], error)
+	// Tree 获取角色可查看的菜单树，授权的菜单会连同其上级菜单一起返回，domain 为空时使用默认域
+	Tree(ctx context.Context, domain string, roles []string) ([]*entity.MenuDataItem, error)
+	// GrantToRole 允许角色查看菜单，写入 Casbin 菜单策略 (角色名, 菜单ID)
+	GrantToRole(ctx context.Context, roleID, menuID uint) error
+	// RevokeFromRole 收回角色查看菜单的权限
+	RevokeFromRole(ctx context.Context, roleID, menuID uint) error
 }
 
 // menuUseCase 用例实现
 type menuUseCase struct {
 	menuRepo repository.MenuRepository
+	roleRepo repository.RoleRepository
+	cache    *redis.Client
 }
 
 // NewMenuUseCase 创建用例实例
-func NewMenuUseCase(menuRepo repository.MenuRepository) MenuUseCase {
+func NewMenuUseCase(menuRepo repository.MenuRepository, roleRepo repository.RoleRepository, cache *redis.Client) MenuUseCase {
 	return &menuUseCase{
 		menuRepo: menuRepo,
+		roleRepo: roleRepo,
+		cache:    cache,
 	}
 }
 
 func (uc *menuUseCase) CreateMenu(ctx context.Context, menu *entity.Menu) error {
-	return uc.menuRepo.Create(ctx, menu)
+	if err := uc.menuRepo.Create(ctx, menu); err != nil {
+		return err
+	}
+	uc.invalidateTree(ctx)
+	return nil
 }
 
 func (uc *menuUseCase) GetMenu(ctx context.Context, id uint) (*entity.Menu, error) {
@@ -37,13 +68,218 @@ func (uc *menuUseCase) GetMenu(ctx context.Context, id uint) (*entity.Menu, erro
 }
 
 func (uc *menuUseCase) UpdateMenu(ctx context.Context, menu *entity.Menu) error {
-	return uc.menuRepo.Update(ctx, menu)
+	if err := uc.menuRepo.Update(ctx, menu); err != nil {
+		return err
+	}
+	uc.invalidateTree(ctx)
+	return nil
 }
 
 func (uc *menuUseCase) DeleteMenu(ctx context.Context, id uint) error {
-	return uc.menuRepo.Delete(ctx, id)
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return errors.New("rbac enforcer not initialized")
+	}
+	if err := uc.menuRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.invalidateTree(ctx)
+	// 菜单已删除，清理所有角色的授权，避免残留的菜单策略
+	return enforcer.RevokeMenuFromAllRoles(strconv.FormatUint(uint64(id), 10))
 }
 
 func (uc *menuUseCase) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Menu], error) {
 	return uc.menuRepo.List(ctx, param)
 }
+
+func (uc *menuUseCase) Tree(ctx context.Context, domain string, roles []string) ([]*entity.MenuDataItem, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return nil, errors.New("rbac enforcer not initialized")
+	}
+	version := uc.treeVersion(ctx)
+
+	// 合并各角色可查看的菜单
+	merged := make(map[uint]entity.MenuDataItem)
+	for _, role := range roles {
+		items, err := uc.roleMenus(ctx, enforcer, version, domain, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			merged[item.ID] = item
+		}
+	}
+	return buildMenuTree(merged), nil
+}
+
+func (uc *menuUseCase) GrantToRole(ctx context.Context, roleID, menuID uint) error {
+	role, menu, enforcer, err := uc.roleMenu(ctx, roleID, menuID)
+	if err != nil {
+		return err
+	}
+	if err := enforcer.GrantMenu(role.Name, strconv.FormatUint(uint64(menu.Id), 10)); err != nil {
+		return err
+	}
+	uc.invalidateTree(ctx)
+	return nil
+}
+
+func (uc *menuUseCase) RevokeFromRole(ctx context.Context, roleID, menuID uint) error {
+	role, menu, enforcer, err := uc.roleMenu(ctx, roleID, menuID)
+	if err != nil {
+		return err
+	}
+	if err := enforcer.RevokeMenu(role.Name, strconv.FormatUint(uint64(menu.Id), 10)); err != nil {
+		return err
+	}
+	uc.invalidateTree(ctx)
+	return nil
+}
+
+// roleMenu 查询授权涉及的角色与菜单
+func (uc *menuUseCase) roleMenu(ctx context.Context, roleID, menuID uint) (*entity.Role, *entity.Menu, *auth.Enforcer, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return nil, nil, nil, errors.New("rbac enforcer not initialized")
+	}
+	role, err := uc.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	menu, err := uc.menuRepo.FindByID(ctx, menuID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return role, menu, enforcer, nil
+}
+
+// roleMenus 获取单个角色可查看的菜单（含上级菜单），优先读取缓存
+func (uc *menuUseCase) roleMenus(ctx context.Context, enforcer *auth.Enforcer, version int64, domain, role string) ([]entity.MenuDataItem, error) {
+	key := fmt.Sprintf("menus:tree:%d:%s:%s", version, domain, role)
+	var items []entity.MenuDataItem
+	err := uc.cache.Get(ctx, key, &items)
+	if err == nil {
+		return items, nil
+	}
+	if !errors.Is(err, redis.ErrNil) {
+		logger.Warn("Failed to read menu cache", logger.String("key", key), logger.ErrorField(err))
+	}
+
+	granted, err := enforcer.GetMenusForRoles(domain, role)
+	if err != nil {
+		return nil, err
+	}
+	menus, err := uc.menuRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items = visibleMenus(menus, granted)
+	if err := uc.cache.Set(ctx, key, items, menuTreeTTL); err != nil {
+		logger.Warn("Failed to write menu cache", logger.String("key", key), logger.ErrorField(err))
+	}
+	return items, nil
+}
+
+// treeVersion 获取菜单缓存版本，读取失败时返回 0
+func (uc *menuUseCase) treeVersion(ctx context.Context) int64 {
+	var version int64
+	if err := uc.cache.Get(ctx, menuTreeVersionKey, &version); err != nil && !errors.Is(err, redis.ErrNil) {
+		logger.Warn("Failed to read menu cache version", logger.ErrorField(err))
+	}
+	return version
+}
+
+// invalidateTree 递增缓存版本使所有角色的菜单缓存失效，旧版本的缓存随过期时间清除
+func (uc *menuUseCase) invalidateTree(ctx context.Context) {
+	if _, err := uc.cache.Incr(ctx, menuTreeVersionKey); err != nil {
+		logger.Warn("Failed to invalidate menu cache", logger.ErrorField(err))
+	}
+}
+
+// visibleMenus 筛选授权的菜单及其上级菜单，上级菜单不存在的菜单不可见
+func visibleMenus(menus []entity.Menu, granted []string) []entity.MenuDataItem {
+	byID := make(map[uint]*entity.Menu, len(menus))
+	for i := range menus {
+		byID[menus[i].Id] = &menus[i]
+	}
+	all := slices.Contains(granted, auth.MenuWildcard)
+
+	visible := make(map[uint]bool)
+	for _, menu := range menus {
+		if !all && !slices.Contains(granted, strconv.FormatUint(uint64(menu.Id), 10)) {
+			continue
+		}
+		var chain []uint
+		for id := menu.Id; id != 0 && !visible[id]; {
+			parent, ok := byID[id]
+			// 上级缺失或存在环时整条链不可见
+			if !ok || len(chain) > len(menus) {
+				chain = nil
+				break
+			}
+			chain = append(chain, id)
+			id = parent.ParentId
+		}
+		for _, id := range chain {
+			visible[id] = true
+		}
+	}
+
+	items := make([]entity.MenuDataItem, 0, len(visible))
+	for _, menu := range menus {
+		if visible[menu.Id] {
+			items = append(items, menuDataItem(&menu))
+		}
+	}
+	return items
+}
+
+// menuDataItem 转换为前端路由使用的菜单项
+func menuDataItem(menu *entity.Menu) entity.MenuDataItem {
+	return entity.MenuDataItem{
+		ID:         menu.Id,
+		ParentID:   menu.ParentId,
+		Weight:     menu.Weight,
+		Path:       menu.Path,
+		Title:      menu.Title,
+		Name:       menu.Name,
+		Component:  menu.Component,
+		Locale:     menu.Locale,
+		Icon:       menu.Icon,
+		Redirect:   menu.Redirect,
+		KeepAlive:  menu.KeepAlive == 1,
+		HideInMenu: menu.HideMenu == 1,
+		URL:        menu.Url,
+		UpdatedAt:  menu.UpdatedAt.Format(time.DateTime),
+	}
+}
+
+// buildMenuTree 按上级ID组装菜单树，同级菜单按排序权重升序、ID升序排列
+func buildMenuTree(items map[uint]entity.MenuDataItem) []*entity.MenuDataItem {
+	nodes := make(map[uint]*entity.MenuDataItem, len(items))
+	for id, item := range items {
+		nodes[id] = &item
+	}
+
+	var roots []*entity.MenuDataItem
+	for _, node := range nodes {
+		if parent, ok := nodes[node.ParentID]; ok && node.ParentID != 0 {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	var sortNodes func(nodes []*entity.MenuDataItem)
+	sortNodes = func(nodes []*entity.MenuDataItem) {
+		slices.SortFunc(nodes, func(a, b *entity.MenuDataItem) int {
+			return cmp.Or(cmp.Compare(a.Weight, b.Weight), cmp.Compare(a.ID, b.ID))
+		})
+		for _, node := range nodes {
+			sortNodes(node.Children)
+		}
+	}
+	sortNodes(roots)
+	return roots
+}
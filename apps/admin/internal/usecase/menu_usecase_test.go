@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/redis/redistest"
+	"slices"
+	"testing"
+)
+
+func TestVisibleMenus(t *testing.T) {
+	// 1 -> 2 -> 3 为正常的上级链，5 的上级 4 不存在，6 与 7 互为上级
+	menus := []entity.Menu{
+		{Id: 1},
+		{Id: 2, ParentId: 1},
+		{Id: 3, ParentId: 2},
+		{Id: 5, ParentId: 4},
+		{Id: 6, ParentId: 7},
+		{Id: 7, ParentId: 6},
+		{Id: 8},
+	}
+	tests := []struct {
+		name    string
+		granted []string
+		want    []uint
+	}{
+		{"授权的菜单连同上级链返回", []string{"3"}, []uint{1, 2, 3}},
+		{"只授权上级时不返回下级", []string{"1"}, []uint{1}},
+		{"上级不存在的菜单不可见", []string{"5"}, nil},
+		{"存在环的菜单不可见", []string{"6"}, nil},
+		{"未授权的菜单不可见", []string{"9"}, nil},
+		{"多个授权合并", []string{"2", "8"}, []uint{1, 2, 8}},
+		{"通配授权返回全部可见的菜单", []string{auth.MenuWildcard}, []uint{1, 2, 3, 8}},
+		{"无授权", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint
+			for _, item := range visibleMenus(menus, tt.granted) {
+				got = append(got, item.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("visibleMenus(%v) = %v, want %v", tt.granted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMenuTree(t *testing.T) {
+	items := map[uint]entity.MenuDataItem{
+		1: {ID: 1, Weight: 2},
+		2: {ID: 2, Weight: 1},
+		3: {ID: 3, Weight: 2},
+		4: {ID: 4, ParentID: 1, Weight: 5},
+		5: {ID: 5, ParentID: 1, Weight: 1},
+		6: {ID: 6, ParentID: 1, Weight: 1},
+		7: {ID: 7, ParentID: 5},
+		8: {ID: 8, ParentID: 9}, // 上级不在结果中时作为顶级菜单
+	}
+
+	// flatten 按先序遍历输出 ID 与所在层级
+	var flatten func(nodes []*entity.MenuDataItem, depth int) [][2]uint
+	flatten = func(nodes []*entity.MenuDataItem, depth int) [][2]uint {
+		var result [][2]uint
+		for _, node := range nodes {
+			result = append(result, [2]uint{node.ID, uint(depth)})
+			result = append(result, flatten(node.Children, depth+1)...)
+		}
+		return result
+	}
+	got := flatten(buildMenuTree(items), 0)
+	// 同级按权重升序，权重相同时按 ID 升序
+	want := [][2]uint{{8, 0}, {2, 0}, {1, 0}, {5, 1}, {7, 2}, {6, 1}, {4, 1}, {3, 0}}
+	if len(items) != len(got) {
+		t.Fatalf("buildMenuTree() 节点数 = %d, want %d", len(got), len(items))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("buildMenuTree() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteMenu(t *testing.T) {
+	db := setupTestDB(t, &entity.Menu{}, &entity.Role{})
+	if err := auth.InitRbac(db, auth.WithDomains("default")); err != nil {
+		t.Fatal(err)
+	}
+	menus := []entity.Menu{{Title: "系统"}, {Title: "订单"}}
+	if err := db.Create(&menus).Error; err != nil {
+		t.Fatal(err)
+	}
+	enforcer := auth.GetEnforcer()
+	for _, g := range [][3]string{{"editor", "default", "1"}, {"editor", "default", "2"}, {"viewer", "acme", "2"}} {
+		if err := enforcer.GrantMenuInDomain(g[0], g[1], g[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := redistest.NewClient(t)
+	uc := NewMenuUseCase(repository.NewMenuRepository(db, cache), repository.NewRoleRepository(db, cache), cache)
+	if err := uc.DeleteMenu(context.Background(), 2); err != nil {
+		t.Fatalf("DeleteMenu() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		role   string
+		want   []string
+	}{
+		{"保留其他菜单的授权", "default", "editor", []string{"1"}},
+		{"收回其他域中的授权", "acme", "viewer", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			menus, err := enforcer.GetMenusForRoles(tt.domain, tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(menus, tt.want) {
+				t.Errorf("GetMenusForRoles(%s, %s) = %v, want %v", tt.domain, tt.role, menus, tt.want)
+			}
+		})
+	}
+}
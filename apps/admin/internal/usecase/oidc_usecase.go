@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/query"
+	"fiber_web/pkg/utils/errorx"
+	"slices"
+	"strconv"
+)
+
+// OidcUseCase OIDC 单点登录用例接口
+type OidcUseCase interface {
+	// AuthCodeURL 生成跳转到身份提供方的授权地址，tenant 为登录后选择的租户，为空时自动选择
+	AuthCodeURL(ctx context.Context, tenant string) (string, error)
+	// Login 校验回调并登录对应的管理员，启用两步验证时返回质询令牌，由 VerifyTwoFactor 完成登录
+	Login(ctx context.Context, state, code string) (*LoginResult, error)
+}
+
+// oidcUseCase OIDC 单点登录用例实现
+type oidcUseCase struct {
+	provider       *auth.OIDCProvider
+	cfg            *config.OIDCConfig
+	tx             database.TxManager
+	admin_userRepo repository.AdminUserRepository
+}
+
+// NewOidcUseCase 创建 OIDC 单点登录用例实例
+func NewOidcUseCase(provider *auth.OIDCProvider, cfg *config.OIDCConfig, tx database.TxManager, admin_userRepo repository.AdminUserRepository) OidcUseCase {
+	return &oidcUseCase{
+		provider:       provider,
+		cfg:            cfg,
+		tx:             tx,
+		admin_userRepo: admin_userRepo,
+	}
+}
+
+func (uc *oidcUseCase) AuthCodeURL(ctx context.Context, tenant string) (string, error) {
+	return uc.provider.AuthCodeURL(ctx, tenant)
+}
+
+func (uc *oidcUseCase) Login(ctx context.Context, state, code string) (*LoginResult, error) {
+	identity, err := uc.provider.Exchange(ctx, state, code)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidOIDCState) || errors.Is(err, auth.ErrInvalidIDToken) || errors.Is(err, auth.ErrOIDCExchange) {
+			logger.Warn("OIDC login rejected", logger.ErrorField(err))
+			return nil, errorx.NewAuthError("单点登录验证失败，请重新登录")
+		}
+		return nil, errorx.Wrap(err, "单点登录失败").WithCode(errorx.CodeInternalError)
+	}
+
+	admin, err := uc.resolveAdmin(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Status != entity.StatusEnabled {
+		return nil, errorx.NewForbiddenError("账号已禁用")
+	}
+
+	tenant, roles, err := uc.syncRoles(ctx, admin, identity)
+	if err != nil {
+		return nil, err
+	}
+	id := auth.Identity{
+		UserID:   uint64(admin.Id),
+		Subject:  adminSubject(admin.Id),
+		Username: admin.Name,
+		Roles:    roles,
+		Extra:    withTenant(map[string]any{AdminClaim: true}, tenant),
+	}
+
+	jwtManager := auth.GetJWTManager()
+	// 与密码登录一致，启用两步验证的管理员需再校验第二因素，除非明确配置由身份提供方负责
+	if admin.TotpEnabled && !uc.cfg.SkipTwoFactor {
+		challenge, err := jwtManager.IssueChallengeToken(id)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			ChallengeToken:   challenge,
+			ChallengeExpires: int64(auth.ChallengeTokenExpiry.Seconds()),
+		}, nil
+	}
+	pair, err := jwtManager.IssueTokenPair(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// oidcSubject 管理员绑定的身份标识，包含签发者以区分不同身份提供方的同名 sub
+func (uc *oidcUseCase) oidcSubject(identity *auth.OIDCIdentity) string {
+	return uc.provider.Discovery().Issuer + "|" + identity.Subject
+}
+
+// resolveAdmin 查找身份对应的管理员
+// 依次按已绑定的身份标识、已验证的邮箱查找，邮箱匹配时绑定身份；都未找到且开启自动创建时创建管理员。
+func (uc *oidcUseCase) resolveAdmin(ctx context.Context, identity *auth.OIDCIdentity) (*entity.AdminUser, error) {
+	subject := uc.oidcSubject(identity)
+	admin, err := uc.admin_userRepo.FindByOidcSubject(ctx, subject)
+	if err == nil {
+		return admin, nil
+	}
+	if !errors.Is(err, query.ErrNotFound) {
+		return nil, errorx.Wrap(err, "查询账号失败").WithCode(errorx.CodeInternalError)
+	}
+
+	// 未验证的邮箱可能由任何人在身份提供方填写，不能用于关联已有账号
+	if identity.Email != "" && identity.EmailVerified {
+		admin, err = uc.admin_userRepo.FindByEmail(ctx, identity.Email)
+		if err == nil {
+			bound, err := uc.admin_userRepo.BindOidcSubject(ctx, admin.Id, subject)
+			if err != nil {
+				return nil, errorx.Wrap(err, "绑定单点登录身份失败").WithCode(errorx.CodeInternalError)
+			}
+			if !bound {
+				return nil, errorx.NewForbiddenError("账号已绑定其他单点登录身份")
+			}
+			admin.OidcSubject = &subject
+			return admin, nil
+		}
+		if !errors.Is(err, query.ErrNotFound) {
+			return nil, errorx.Wrap(err, "查询账号失败").WithCode(errorx.CodeInternalError)
+		}
+	}
+
+	if !uc.cfg.AutoCreate {
+		return nil, errorx.NewForbiddenError("未找到对应的管理员账号")
+	}
+	return uc.createAdmin(ctx, identity, subject)
+}
+
+// createAdmin 为身份自动创建管理员，密码为随机值，只能通过单点登录登录
+func (uc *oidcUseCase) createAdmin(ctx context.Context, identity *auth.OIDCIdentity, subject string) (*entity.AdminUser, error) {
+	name := identity.PreferredUsername
+	if name == "" {
+		name = identity.Email
+	}
+	if name == "" {
+		return nil, errorx.NewForbiddenError("身份信息缺少用户名，无法创建账号")
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	password := hex.EncodeToString(b)
+	if err := hashPassword(&password); err != nil {
+		return nil, err
+	}
+
+	email := ""
+	if identity.EmailVerified {
+		email = identity.Email
+	}
+	admin := &entity.AdminUser{
+		Name:        name,
+		Email:       email,
+		Password:    password,
+		Status:      entity.StatusEnabled,
+		OidcSubject: &subject,
+	}
+	if err := uc.admin_userRepo.Create(ctx, admin); err != nil {
+		return nil, errorx.Wrap(err, "创建管理员失败").WithCode(errorx.CodeInternalError)
+	}
+	logger.Info("Admin user created from OIDC login", logger.Any("id", admin.Id), logger.String("name", name))
+	return admin, nil
+}
+
+// syncRoles 按角色映射同步管理员的 Casbin 角色
+// 未配置映射时沿用 Casbin 中已有的角色；配置了映射但没有映射出任何角色时禁止登录。
+// 启用多租户时同步的是管理员登录所属租户中的角色，发起登录时指定的租户须已分配角色；增删角色在同一事务中完成，任一步失败时角色保持不变。
+func (uc *oidcUseCase) syncRoles(ctx context.Context, admin *entity.AdminUser, identity *auth.OIDCIdentity) (string, []string, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return "", nil, errorx.New("权限管理未初始化").WithCode(errorx.CodeInternalError)
+	}
+	user := strconv.FormatUint(uint64(admin.Id), 10)
+	tenant, current, err := tenantRoles(enforcer, user, identity.Tenant)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(uc.cfg.RoleMapping) == 0 {
+		return tenant, current, nil
+	}
+
+	roles := uc.provider.MapRoles(identity.Groups)
+	if len(roles) == 0 {
+		return "", nil, errorx.NewForbiddenError("没有可用的角色")
+	}
+	var removed []string
+	for _, role := range current {
+		if !slices.Contains(roles, role) {
+			removed = append(removed, role)
+		}
+	}
+	err = uc.tx.Transaction(ctx, func(ctx context.Context) error {
+		if tenant == "" {
+			if err := enforcer.RemoveRolesForUserCtx(ctx, user, removed...); err != nil {
+				return err
+			}
+			return enforcer.AddRolesForUserCtx(ctx, user, roles...)
+		}
+		if err := enforcer.RemoveRolesForUserInDomainCtx(ctx, user, tenant, removed...); err != nil {
+			return err
+		}
+		return enforcer.AddRolesForUserInDomainCtx(ctx, user, tenant, roles...)
+	})
+	if err != nil {
+		return "", nil, errorx.Wrap(err, "同步角色失败").WithCode(errorx.CodeInternalError)
+	}
+	return tenant, roles, nil
+}
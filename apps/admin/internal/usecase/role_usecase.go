@@ -2,9 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fiber_web/apps/admin/internal/entity"
 	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
 	"fiber_web/pkg/query"
+	"fiber_web/pkg/utils/errorx"
+	"strings"
 )
 
 // RoleUseCase 用例接口
@@ -14,17 +18,27 @@ type RoleUseCase interface {
 	UpdateRole(ctx context.Context, role *entity.Role) error
 	DeleteRole(ctx context.Context, id uint) error
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Role], error)
+	// GrantApi 授予角色在域中访问接口的权限，写入 Casbin 策略 (角色名, 域, 路径, 方法)；domain 为空或未启用多租户时使用默认域
+	GrantApi(ctx context.Context, domain string, roleID, apiID uint) error
+	// RevokeApi 收回角色在域中访问接口的权限
+	RevokeApi(ctx context.Context, domain string, roleID, apiID uint) error
+	// ListApis 获取角色在域中已授权的接口
+	ListApis(ctx context.Context, domain string, roleID uint) ([]entity.Api, error)
+	// ExplainPermission 模拟权限判定，返回是否放行、命中的策略与角色继承链
+	ExplainPermission(ctx context.Context, subject, domain, path, method string) (*auth.Decision, error)
 }
 
 // roleUseCase 用例实现
 type roleUseCase struct {
 	roleRepo repository.RoleRepository
+	apiRepo  repository.ApiRepository
 }
 
 // NewRoleUseCase 创建用例实例
-func NewRoleUseCase(roleRepo repository.RoleRepository) RoleUseCase {
+func NewRoleUseCase(roleRepo repository.RoleRepository, apiRepo repository.ApiRepository) RoleUseCase {
 	return &roleUseCase{
 		roleRepo: roleRepo,
+		apiRepo:  apiRepo,
 	}
 }
 
@@ -47,3 +61,99 @@ func (uc *roleUseCase) DeleteRole(ctx context.Context, id uint) error {
 func (uc *roleUseCase) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.Role], error) {
 	return uc.roleRepo.List(ctx, param)
 }
+
+func (uc *roleUseCase) GrantApi(ctx context.Context, domain string, roleID, apiID uint) error {
+	role, api, enforcer, err := uc.roleApi(ctx, roleID, apiID)
+	if err != nil {
+		return err
+	}
+	if api.Stale {
+		return errorx.NewBusinessError("接口路由已不存在，不能授权")
+	}
+	if domain == "" || !enforcer.DomainsEnabled() {
+		return enforcer.AddPolicy(role.Name, api.Path, api.Method)
+	}
+	return enforcer.AddPolicyInDomain(role.Name, domain, api.Path, api.Method)
+}
+
+func (uc *roleUseCase) RevokeApi(ctx context.Context, domain string, roleID, apiID uint) error {
+	role, api, enforcer, err := uc.roleApi(ctx, roleID, apiID)
+	if err != nil {
+		return err
+	}
+	if domain == "" || !enforcer.DomainsEnabled() {
+		return enforcer.RemovePolicy(role.Name, api.Path, api.Method)
+	}
+	return enforcer.RemovePolicyInDomain(role.Name, domain, api.Path, api.Method)
+}
+
+func (uc *roleUseCase) ListApis(ctx context.Context, domain string, roleID uint) ([]entity.Api, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return nil, errors.New("rbac enforcer not initialized")
+	}
+	role, err := uc.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	var policies [][]string
+	if domain == "" || !enforcer.DomainsEnabled() {
+		policies, err = enforcer.GetPermissionsForRole(role.Name)
+	} else {
+		policies, err = enforcer.GetPermissionsForRoleInDomain(role.Name, domain)
+	}
+	if err != nil {
+		return nil, err
+	}
+	granted := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		// 策略最后两项为路径与方法，启用多租户时前面多一项域
+		if len(policy) >= 3 {
+			granted[routeKey(policy[len(policy)-1], policy[len(policy)-2])] = true
+		}
+	}
+
+	apis, err := uc.apiRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]entity.Api, 0, len(granted))
+	for _, api := range apis {
+		if granted[routeKey(api.Method, api.Path)] {
+			result = append(result, api)
+		}
+	}
+	return result, nil
+}
+
+func (uc *roleUseCase) ExplainPermission(ctx context.Context, subject, domain, path, method string) (*auth.Decision, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return nil, errors.New("rbac enforcer not initialized")
+	}
+	method = strings.ToUpper(method)
+	if !enforcer.DomainsEnabled() {
+		if domain != "" {
+			return nil, errorx.NewParamError("未启用多租户，不能指定域")
+		}
+		return enforcer.Explain(subject, path, method)
+	}
+	return enforcer.ExplainInDomain(subject, domain, path, method)
+}
+
+// roleApi 查询授权涉及的角色与接口
+func (uc *roleUseCase) roleApi(ctx context.Context, roleID, apiID uint) (*entity.Role, *entity.Api, *auth.Enforcer, error) {
+	enforcer := auth.GetEnforcer()
+	if enforcer == nil {
+		return nil, nil, nil, errors.New("rbac enforcer not initialized")
+	}
+	role, err := uc.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	api, err := uc.apiRepo.FindByID(ctx, apiID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return role, api, enforcer, nil
+}
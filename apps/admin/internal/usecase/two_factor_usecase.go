@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"fiber_web/apps/admin/internal/entity"
+	"fiber_web/apps/admin/internal/repository"
+	"fiber_web/pkg/auth"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/security"
+	"fiber_web/pkg/utils/errorx"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount 启用两步验证时生成的恢复码数量
+const RecoveryCodeCount = 10
+
+// TOTPEnrollment 两步验证登记信息，用于身份验证器扫码或手动输入
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorUseCase 管理员两步验证用例接口
+type TwoFactorUseCase interface {
+	// Enroll 生成待确认的 TOTP 密钥，已启用时返回错误
+	Enroll(ctx context.Context, adminID uint) (*TOTPEnrollment, error)
+	// Confirm 校验验证码后启用两步验证，返回仅展示一次的恢复码
+	Confirm(ctx context.Context, adminID uint, code string) ([]string, error)
+	// Disable 校验验证码或恢复码后关闭两步验证
+	Disable(ctx context.Context, adminID uint, code string) error
+}
+
+// twoFactorUseCase 两步验证用例实现
+type twoFactorUseCase struct {
+	admin_userRepo repository.AdminUserRepository
+	limiter        *auth.LoginLimiter // 与登录共用，验证码错误计入同一账号的失败次数
+	issuer         string             // 身份验证器中显示的签发者
+}
+
+// NewTwoFactorUseCase 创建两步验证用例实例
+func NewTwoFactorUseCase(admin_userRepo repository.AdminUserRepository, limiter *auth.LoginLimiter, issuer string) TwoFactorUseCase {
+	return &twoFactorUseCase{
+		admin_userRepo: admin_userRepo,
+		limiter:        limiter,
+		issuer:         issuer,
+	}
+}
+
+func (uc *twoFactorUseCase) Enroll(ctx context.Context, adminID uint) (*TOTPEnrollment, error) {
+	admin, err := uc.admin_userRepo.FindByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TotpEnabled {
+		return nil, errorx.NewBusinessError("已启用两步验证")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.admin_userRepo.UpdateFields(ctx, adminID, map[string]any{"totp_secret": secret}); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    security.TOTPURI(uc.issuer, admin.Name, secret),
+	}, nil
+}
+
+func (uc *twoFactorUseCase) Confirm(ctx context.Context, adminID uint, code string) ([]string, error) {
+	admin, err := uc.admin_userRepo.FindByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TotpEnabled {
+		return nil, errorx.NewBusinessError("已启用两步验证")
+	}
+	if admin.TotpSecret == "" {
+		return nil, errorx.NewBusinessError("请先生成两步验证密钥")
+	}
+	var counter int64
+	err = uc.verify(ctx, admin, func() (bool, error) {
+		var err error
+		counter, err = security.ValidateTOTP(admin.TotpSecret, strings.TrimSpace(code), time.Now(), security.TOTPSkew)
+		return err == nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := security.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = security.HashRecoveryCode(code)
+	}
+	// 记录确认时使用的时间步，该验证码不能再用于登录
+	err = uc.admin_userRepo.UpdateFields(ctx, adminID, map[string]any{
+		"totp_enabled":   true,
+		"totp_counter":   counter,
+		"recovery_codes": strings.Join(hashes, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (uc *twoFactorUseCase) Disable(ctx context.Context, adminID uint, code string) error {
+	admin, err := uc.admin_userRepo.FindByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !admin.TotpEnabled {
+		return errorx.NewBusinessError("未启用两步验证")
+	}
+	err = uc.verify(ctx, admin, func() (bool, error) {
+		return verifySecondFactor(ctx, uc.admin_userRepo, admin, code)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.admin_userRepo.UpdateFields(ctx, adminID, map[string]any{
+		"totp_enabled":   false,
+		"totp_secret":    "",
+		"totp_counter":   0,
+		"recovery_codes": "",
+	})
+}
+
+// verify 按登录锁定规则校验验证码，错误次数与登录共用，防止持有访问令牌者暴力尝试
+func (uc *twoFactorUseCase) verify(ctx context.Context, admin *entity.AdminUser, check func() (bool, error)) error {
+	lockKey := adminLockKey(admin.Name)
+	remaining, err := uc.limiter.Locked(ctx, lockKey)
+	if err != nil {
+		return errorx.Wrap(err, "查询登录锁定状态失败").WithCode(errorx.CodeInternalError)
+	}
+	if remaining > 0 {
+		return errorx.NewAccountLockedError(remaining)
+	}
+
+	ok, err := check()
+	if err != nil {
+		return errorx.Wrap(err, "校验验证码失败").WithCode(errorx.CodeInternalError)
+	}
+	if !ok {
+		lockout, err := uc.limiter.Fail(ctx, lockKey)
+		if err != nil {
+			logger.Warn("Failed to record two-factor failure", logger.String("key", lockKey), logger.ErrorField(err))
+		}
+		if lockout > 0 {
+			logger.Warn("Login locked", logger.String("key", lockKey), logger.Duration("lockout", lockout))
+			return errorx.NewAccountLockedError(lockout)
+		}
+		return errorx.NewParamError("验证码错误")
+	}
+
+	if err := uc.limiter.Reset(ctx, lockKey); err != nil {
+		logger.Warn("Failed to reset login failures", logger.String("key", lockKey), logger.ErrorField(err))
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type UserUseCase interface {
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, id uint) error
 	List(ctx context.Context, param *query.Query) (*query.PageResult[entity.User], error)
+	ListByCursor(ctx context.Context, param *query.Query) (*query.CursorResult[entity.User], error)
 }
 
 // userUseCase 用户用例实现
@@ -27,6 +28,9 @@ func NewUserUseCase(userRepo repository.UserRepository) UserUseCase {
 }
 
 func (uc *userUseCase) CreateUser(ctx context.Context, user *entity.User) error {
+	if err := hashPassword(&user.Password); err != nil {
+		return err
+	}
 	return uc.userRepo.Create(ctx, user)
 }
 
@@ -35,14 +39,35 @@ func (uc *userUseCase) GetUser(ctx context.Context, id uint) (*entity.User, erro
 }
 
 func (uc *userUseCase) UpdateUser(ctx context.Context, user *entity.User) error {
-	return uc.userRepo.Update(ctx, user)
+	current, err := uc.userRepo.FindByID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := hashPassword(&user.Password); err != nil {
+		return err
+	}
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	// 修改密码或禁用账号后强制下线
+	if (user.Password != "" && user.Password != current.Password) || user.Status != entity.StatusEnabled {
+		return revokeTokens(ctx, userSubject(user.ID))
+	}
+	return nil
 }
 
 func (uc *userUseCase) DeleteUser(ctx context.Context, id uint) error {
-	return uc.userRepo.Delete(ctx, id)
+	if err := uc.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return revokeTokens(ctx, userSubject(id))
 }
 
 func (uc *userUseCase) List(ctx context.Context, param *query.Query) (*query.PageResult[entity.User], error) {
 	// 调用仓库层
 	return uc.userRepo.List(ctx, param)
 }
+
+func (uc *userUseCase) ListByCursor(ctx context.Context, param *query.Query) (*query.CursorResult[entity.User], error) {
+	return uc.userRepo.ListByCursor(ctx, param)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	generated, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(generated.Key, "fwk_"+generated.Prefix+".") {
+		t.Errorf("GenerateAPIKey() key = %q, prefix = %q", generated.Key, generated.Prefix)
+	}
+
+	prefix, err := ParseAPIKey(generated.Key)
+	if err != nil || prefix != generated.Prefix {
+		t.Errorf("ParseAPIKey() = %q, %v, want %q", prefix, err, generated.Prefix)
+	}
+	if !MatchAPIKey(generated.Key, generated.Hash) {
+		t.Error("MatchAPIKey() 应匹配生成时的哈希")
+	}
+
+	other, _ := GenerateAPIKey()
+	if MatchAPIKey(other.Key, generated.Hash) {
+		t.Error("MatchAPIKey() 不应匹配其他密钥的哈希")
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"空密钥", ""},
+		{"缺少标识", strings.TrimPrefix(generated.Key, "fwk_")},
+		{"缺少分隔符", strings.Replace(generated.Key, ".", "", 1)},
+		{"前缀长度错误", "fwk_abc." + strings.Repeat("x", 32)},
+		{"私密部分长度错误", "fwk_" + generated.Prefix + ".short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAPIKey(tt.key); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("ParseAPIKey(%q) error = %v, want %v", tt.key, err, ErrInvalidAPIKey)
+			}
+		})
+	}
+}
+
+func TestNewAPIKeyClaims(t *testing.T) {
+	claims := NewAPIKeyClaims(7, "ci", []string{"deployer", "auditor"})
+	if claims.Subject != "apikey:7" || claims.Role != "deployer" || !claims.HasRole("auditor") || claims.Type != string(AccessToken) {
+		t.Errorf("NewAPIKeyClaims() = %+v", claims)
+	}
+	if id, ok := ExtraValue[uint64](claims, APIKeyClaim); !ok || id != 7 {
+		t.Errorf("ExtraValue(%s) = %v, %v", APIKeyClaim, id, ok)
+	}
+}
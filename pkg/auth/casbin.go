@@ -1,24 +1,33 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fiber_web/pkg/database"
 	"fiber_web/pkg/logger"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
-	"github.com/casbin/casbin/v2"
-	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v3"
+	"github.com/casbin/casbin/v3/model"
+	"github.com/casbin/casbin/v3/util"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 
 	"gorm.io/gorm"
 )
 
-// RBAC 模型规则
+// RBAC 模型规则，路径按 keyMatch 或 keyMatch2 匹配
+// keyMatch 保持 * 前缀通配的原有语义，keyMatch2 额外支持 Fiber 路由的 :param 参数。
+// p2 为角色可见的菜单，不参与接口鉴权。
 const rbacModelRule = `
 [request_definition]
 r = sub, obj, act
 
 [policy_definition]
 p = sub, obj, act
+p2 = sub, menu
 
 [role_definition]
 g = _, _
@@ -27,13 +36,92 @@ g = _, _
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && (r.act == p.act || p.act == "*")
+m = g(r.sub, p.sub) && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && (r.act == p.act || p.act == "*")
 `
 
+// RBAC with domains 模型规则，域即租户
+// 域为 * 的策略与角色分配对所有域生效，用于平台级角色。
+const rbacDomainModelRule = `
+[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+p2 = sub, dom, menu
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && (r.dom == p.dom || p.dom == "*") && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && (r.act == p.act || p.act == "*")
+`
+
+// DomainWildcard 对所有域生效的域
+const DomainWildcard = "*"
+
+// 菜单策略
+const (
+	MenuPolicyType = "p2" // 菜单策略类型
+	MenuWildcard   = "*"  // 表示全部菜单
+)
+
+// ErrDomainsDisabled 未启用多租户时调用了指定域的方法
+var ErrDomainsDisabled = errors.New("rbac domains not enabled")
+
 // Enforcer Casbin权限管理器
 type Enforcer struct {
-	enforcer *casbin.Enforcer
-	mu       sync.RWMutex
+	enforcer      *casbin.Enforcer
+	db            *gorm.DB // 策略所在的数据库，用于在事务中写入策略
+	domains       bool     // 是否启用多租户
+	defaultDomain string   // 未指定域的方法使用的域
+	mu            sync.RWMutex
+
+	bus            PolicyBus      // 实例间同步策略变更的通道
+	watcher        *policyWatcher // 发布本实例的策略变更
+	reloadInterval time.Duration  // 定期全量加载策略的间隔
+	decisionLog    *logger.Logger // 权限判定日志
+	logAllowed     bool           // 判定日志是否记录放行的判定
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+// RbacOption Casbin 配置项
+type RbacOption func(*Enforcer)
+
+// WithDomains 启用多租户 RBAC，未指定域的方法（AddPolicy、HasPermission 等）作用于 defaultDomain
+// 启动时会将单租户格式的策略迁移到 defaultDomain，见 MigratePoliciesToDomain。
+func WithDomains(defaultDomain string) RbacOption {
+	return func(e *Enforcer) {
+		e.domains = true
+		e.defaultDomain = defaultDomain
+	}
+}
+
+// WithWatcher 通过 bus 在多个实例间同步策略变更
+// 本实例修改策略后发布增量变更，其他实例收到后直接更新内存中的策略，无需重新加载。
+func WithWatcher(bus PolicyBus) RbacOption {
+	return func(e *Enforcer) {
+		e.bus = bus
+	}
+}
+
+// WithAutoReload 每隔 interval 从数据库重新加载全部策略，作为变更通知丢失时的兜底
+func WithAutoReload(interval time.Duration) RbacOption {
+	return func(e *Enforcer) {
+		e.reloadInterval = interval
+	}
+}
+
+// WithDecisionLog 将 LogDecision 的判定结果写入 l，通常为 logger.NewMongoLogger 创建的审计日志
+// allowed 为 false 时只记录拒绝的判定。
+func WithDecisionLog(l *logger.Logger, allowed bool) RbacOption {
+	return func(e *Enforcer) {
+		e.decisionLog = l
+		e.logAllowed = allowed
+	}
 }
 
 var (
@@ -42,54 +130,130 @@ var (
 )
 
 // InitRbac 初始化 Casbin enforcer
-func InitRbac(db *gorm.DB) error {
+func InitRbac(db *gorm.DB, opts ...RbacOption) error {
 	var initErr error
 	once.Do(func() {
-		instance = &Enforcer{}
-		// 初始化 Casbin adapter
-		adapter, err := gormadapter.NewAdapterByDB(db)
-		if err != nil {
-			logger.ErrorLog("Failed to create Casbin adapter", logger.ErrorField(err))
-			initErr = err
-			return
-		}
+		instance, initErr = NewEnforcer(db, opts...)
+	})
 
-		// 加载 RBAC 模型
-		m, err := model.NewModelFromString(rbacModelRule)
-		if err != nil {
-			logger.ErrorLog("Failed to create Casbin model", logger.ErrorField(err))
-			initErr = err
-			return
-		}
+	if initErr != nil {
+		return fmt.Errorf("failed to initialize Casbin: %w", initErr)
+	}
+	return nil
+}
+
+// NewEnforcer 创建 Casbin enforcer，策略保存在 db 的 casbin_rule 表中
+func NewEnforcer(db *gorm.DB, opts ...RbacOption) (*Enforcer, error) {
+	e := &Enforcer{db: db, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.domains && (e.defaultDomain == "" || e.defaultDomain == DomainWildcard) {
+		return nil, fmt.Errorf("invalid default rbac domain %q", e.defaultDomain)
+	}
+
+	// 初始化 Casbin adapter
+	adapter, err := gormadapter.NewAdapterByDB(db)
+	if err != nil {
+		logger.ErrorLog("Failed to create Casbin adapter", logger.ErrorField(err))
+		return nil, err
+	}
 
-		// 创建 enforcer
-		instance.enforcer, err = casbin.NewEnforcer(m, adapter)
-		if err != nil {
-			logger.ErrorLog("Failed to create Casbin enforcer", logger.ErrorField(err))
-			initErr = err
-			return
+	// 迁移单租户策略，需在加载策略之前完成
+	modelRule := rbacModelRule
+	if e.domains {
+		if err := MigratePoliciesToDomain(db, e.defaultDomain); err != nil {
+			logger.ErrorLog("Failed to migrate Casbin policy to domain", logger.ErrorField(err))
+			return nil, err
 		}
+		modelRule = rbacDomainModelRule
+	}
+
+	// 加载 RBAC 模型
+	m, err := model.NewModelFromString(modelRule)
+	if err != nil {
+		logger.ErrorLog("Failed to create Casbin model", logger.ErrorField(err))
+		return nil, err
+	}
+
+	// 创建 enforcer
+	e.enforcer, err = casbin.NewEnforcer(m, adapter)
+	if err != nil {
+		logger.ErrorLog("Failed to create Casbin enforcer", logger.ErrorField(err))
+		return nil, err
+	}
+	if e.domains {
+		// 角色分配的域支持通配，域为 * 的分配在所有域生效
+		e.enforcer.AddNamedDomainMatchingFunc("g", "keyMatch", util.KeyMatch)
+	}
 
-		// 加载策略
-		if err := instance.LoadPolicy(); err != nil {
-			logger.ErrorLog("Failed to load Casbin policy", logger.ErrorField(err))
-			initErr = err
-			return
+	// 订阅其他实例的策略变更，需在加载策略之前完成，避免遗漏加载期间的变更
+	if e.bus != nil {
+		if err := e.startWatcher(); err != nil {
+			logger.ErrorLog("Failed to start Casbin policy watcher", logger.ErrorField(err))
+			return nil, err
 		}
+	}
 
-		// 启用自动保存
-		instance.enforcer.EnableAutoSave(true)
+	// 加载策略
+	if err := e.LoadPolicy(); err != nil {
+		logger.ErrorLog("Failed to load Casbin policy", logger.ErrorField(err))
+		e.Close()
+		return nil, err
+	}
 
-		// 添加默认策略
-		//instance.addDefaultPolicies()
-	})
+	// 启用自动保存
+	e.enforcer.EnableAutoSave(true)
 
-	if initErr != nil {
-		return fmt.Errorf("failed to initialize Casbin: %w", initErr)
+	if e.reloadInterval > 0 {
+		go e.autoReload(e.reloadInterval)
+	}
+
+	// 添加默认策略
+	//e.addDefaultPolicies()
+	return e, nil
+}
+
+// startWatcher 创建并启动策略变更的发布订阅
+func (e *Enforcer) startWatcher() error {
+	watcher, err := newPolicyWatcher(e.bus)
+	if err != nil {
+		return err
+	}
+	if err := e.enforcer.SetWatcher(watcher); err != nil {
+		watcher.Close()
+		return err
+	}
+	if err := watcher.SetUpdateCallback(e.onPolicyUpdate); err != nil {
+		watcher.Close()
+		return err
+	}
+	// 收到通知时需要 e.watcher 判断通知来源
+	e.watcher = watcher
+	if err := watcher.start(); err != nil {
+		e.watcher = nil
+		watcher.Close()
+		return err
 	}
 	return nil
 }
 
+// MigratePoliciesToDomain 将单租户格式的策略迁移到 domain，可重复执行
+// p 策略由 (sub, obj, act) 改为 (sub, domain, obj, act)，p2 菜单策略由 (sub, menu) 改为 (sub, domain, menu)，
+// g 角色分配由 (user, role) 改为 (user, role, domain)。
+func MigratePoliciesToDomain(db *gorm.DB, domain string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 单租户策略的 v3 为空；MySQL 按从左到右的顺序赋值，需先移动后面的列
+		if err := tx.Exec("UPDATE casbin_rule SET v3 = v2, v2 = v1, v1 = ? WHERE ptype = 'p' AND (v3 = '' OR v3 IS NULL)", domain).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE casbin_rule SET v2 = v1, v1 = ? WHERE ptype = ? AND (v2 = '' OR v2 IS NULL)", domain, MenuPolicyType).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE casbin_rule SET v2 = ? WHERE ptype = 'g' AND (v2 = '' OR v2 IS NULL)", domain).Error
+	})
+}
+
 // addDefaultPolicies 添加默认的 RBAC 策略
 func (e *Enforcer) addDefaultPolicies() {
 	e.mu.Lock()
@@ -119,14 +283,72 @@ func (e *Enforcer) LoadPolicy() error {
 	return e.enforcer.LoadPolicy()
 }
 
-// AddPolicy 添加策略规则
+// DomainsEnabled 是否启用多租户
+func (e *Enforcer) DomainsEnabled() bool {
+	return e.domains
+}
+
+// DefaultDomain 未指定域的方法使用的域，未启用多租户时为空
+func (e *Enforcer) DefaultDomain() string {
+	return e.defaultDomain
+}
+
+// domainOf 获取实际使用的域，未启用多租户时返回空，domain 为空时使用默认域
+func (e *Enforcer) domainOf(domain string) string {
+	if !e.domains {
+		return ""
+	}
+	if domain == "" {
+		return e.defaultDomain
+	}
+	return domain
+}
+
+// domainArgs 获取 casbin 角色相关方法的域参数
+func (e *Enforcer) domainArgs(domain string) []string {
+	if !e.domains {
+		return nil
+	}
+	return []string{e.domainOf(domain)}
+}
+
+// policy 按模型组装策略，启用多租户时在主体后插入域
+func (e *Enforcer) policy(sub, domain, obj, act string) []interface{} {
+	if !e.domains {
+		return []interface{}{sub, obj, act}
+	}
+	return []interface{}{sub, e.domainOf(domain), obj, act}
+}
+
+// grouping 按模型组装角色分配
+func (e *Enforcer) grouping(user, role, domain string) []interface{} {
+	if !e.domains {
+		return []interface{}{user, role}
+	}
+	return []interface{}{user, role, e.domainOf(domain)}
+}
+
+// AddPolicy 添加策略规则，启用多租户时作用于默认域
 func (e *Enforcer) AddPolicy(role, path, method string) error {
+	return e.addPolicy(role, "", path, method)
+}
+
+// AddPolicyInDomain 在指定域中添加策略规则，domain 为 * 时对所有域生效
+func (e *Enforcer) AddPolicyInDomain(role, domain, path, method string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.addPolicy(role, domain, path, method)
+}
+
+func (e *Enforcer) addPolicy(role, domain, path, method string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	_, err := e.enforcer.AddPolicy(role, path, method)
+	_, err := e.enforcer.AddPolicy(e.policy(role, domain, path, method)...)
 	if err != nil {
 		logger.ErrorLog("Failed to add policy",
 			logger.String("role", role),
+			logger.String("domain", domain),
 			logger.String("path", path),
 			logger.String("method", method),
 			logger.ErrorField(err))
@@ -135,14 +357,27 @@ func (e *Enforcer) AddPolicy(role, path, method string) error {
 	return nil
 }
 
-// RemovePolicy 删除策略规则
+// RemovePolicy 删除策略规则，启用多租户时作用于默认域
 func (e *Enforcer) RemovePolicy(role, path, method string) error {
+	return e.removePolicy(role, "", path, method)
+}
+
+// RemovePolicyInDomain 删除指定域中的策略规则
+func (e *Enforcer) RemovePolicyInDomain(role, domain, path, method string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.removePolicy(role, domain, path, method)
+}
+
+func (e *Enforcer) removePolicy(role, domain, path, method string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	_, err := e.enforcer.RemovePolicy(role, path, method)
+	_, err := e.enforcer.RemovePolicy(e.policy(role, domain, path, method)...)
 	if err != nil {
 		logger.ErrorLog("Failed to remove policy",
 			logger.String("role", role),
+			logger.String("domain", domain),
 			logger.String("path", path),
 			logger.String("method", method),
 			logger.ErrorField(err))
@@ -151,55 +386,266 @@ func (e *Enforcer) RemovePolicy(role, path, method string) error {
 	return nil
 }
 
-// AddRoleForUser 为用户分配角色
+// AddRoleForUser 为用户分配角色，启用多租户时作用于默认域
 func (e *Enforcer) AddRoleForUser(user, role string) error {
+	return e.addRoleForUser(user, role, "")
+}
+
+// AddRoleForUserInDomain 在指定域中为用户分配角色，domain 为 * 时在所有域生效
+func (e *Enforcer) AddRoleForUserInDomain(user, role, domain string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.addRoleForUser(user, role, domain)
+}
+
+func (e *Enforcer) addRoleForUser(user, role, domain string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	_, err := e.enforcer.AddGroupingPolicy(user, role)
+	_, err := e.enforcer.AddGroupingPolicy(e.grouping(user, role, domain)...)
 	if err != nil {
 		logger.ErrorLog("Failed to add role for user",
 			logger.String("user", user),
 			logger.String("role", role),
+			logger.String("domain", domain),
+			logger.ErrorField(err))
+		return err
+	}
+	return nil
+}
+
+// AddRolesForUserCtx 为用户分配多个角色，启用多租户时作用于默认域
+// ctx 处于事务中时，策略随事务写入数据库，提交后重新加载策略；回滚时不生效。
+func (e *Enforcer) AddRolesForUserCtx(ctx context.Context, user string, roles ...string) error {
+	return e.addRolesForUserCtx(ctx, user, "", roles)
+}
+
+// AddRolesForUserInDomainCtx 在指定域中为用户分配多个角色，事务语义同 AddRolesForUserCtx
+func (e *Enforcer) AddRolesForUserInDomainCtx(ctx context.Context, user, domain string, roles ...string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.addRolesForUserCtx(ctx, user, domain, roles)
+}
+
+func (e *Enforcer) addRolesForUserCtx(ctx context.Context, user, domain string, roles []string) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	if !database.InTransaction(ctx) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, err := e.enforcer.AddRolesForUser(user, roles, e.domainArgs(domain)...); err != nil {
+			logger.ErrorLog("Failed to add roles for user",
+				logger.String("user", user),
+				logger.String("domain", domain),
+				logger.Any("roles", roles),
+				logger.ErrorField(err))
+			return err
+		}
+		return nil
+	}
+
+	current, err := e.getRolesForUser(user, domain)
+	if err != nil {
+		return err
+	}
+	rules := make([]gormadapter.CasbinRule, 0, len(roles))
+	for _, role := range roles {
+		if !slices.Contains(current, role) {
+			rules = append(rules, gormadapter.CasbinRule{Ptype: "g", V0: user, V1: role, V2: e.domainOf(domain)})
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, e.db).Create(&rules).Error; err != nil {
+		logger.ErrorLog("Failed to add roles for user",
+			logger.String("user", user),
+			logger.String("domain", domain),
+			logger.Any("roles", roles),
 			logger.ErrorField(err))
 		return err
 	}
+
+	database.AfterCommit(ctx, func() {
+		if err := e.LoadPolicy(); err != nil {
+			logger.ErrorLog("Failed to reload Casbin policy", logger.ErrorField(err))
+		}
+		e.notifyReload()
+	})
 	return nil
 }
 
-// RemoveRoleForUser 移除用户的角色
+// RemoveRoleForUser 移除用户的角色，启用多租户时作用于默认域
 func (e *Enforcer) RemoveRoleForUser(user, role string) error {
+	return e.removeRoleForUser(user, role, "")
+}
+
+// RemoveRoleForUserInDomain 移除用户在指定域中的角色
+func (e *Enforcer) RemoveRoleForUserInDomain(user, role, domain string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.removeRoleForUser(user, role, domain)
+}
+
+func (e *Enforcer) removeRoleForUser(user, role, domain string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	_, err := e.enforcer.RemoveGroupingPolicy(user, role)
+	_, err := e.enforcer.RemoveGroupingPolicy(e.grouping(user, role, domain)...)
 	if err != nil {
 		logger.ErrorLog("Failed to remove role from user",
 			logger.String("user", user),
 			logger.String("role", role),
+			logger.String("domain", domain),
+			logger.ErrorField(err))
+		return err
+	}
+	return nil
+}
+
+// RemoveRolesForUserCtx 移除用户的多个角色，启用多租户时作用于默认域，事务语义同 AddRolesForUserCtx
+func (e *Enforcer) RemoveRolesForUserCtx(ctx context.Context, user string, roles ...string) error {
+	return e.removeRolesForUserCtx(ctx, user, "", roles)
+}
+
+// RemoveRolesForUserInDomainCtx 移除用户在指定域中的多个角色，事务语义同 AddRolesForUserCtx
+func (e *Enforcer) RemoveRolesForUserInDomainCtx(ctx context.Context, user, domain string, roles ...string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.removeRolesForUserCtx(ctx, user, domain, roles)
+}
+
+func (e *Enforcer) removeRolesForUserCtx(ctx context.Context, user, domain string, roles []string) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	if !database.InTransaction(ctx) {
+		rules := make([][]string, 0, len(roles))
+		for _, role := range roles {
+			rules = append(rules, append([]string{user, role}, e.domainArgs(domain)...))
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, err := e.enforcer.RemoveGroupingPolicies(rules); err != nil {
+			logger.ErrorLog("Failed to remove roles from user",
+				logger.String("user", user),
+				logger.String("domain", domain),
+				logger.Any("roles", roles),
+				logger.ErrorField(err))
+			return err
+		}
+		return nil
+	}
+
+	err := database.Conn(ctx, e.db).
+		Where("ptype = ? AND v0 = ? AND v1 IN ? AND v2 = ?", "g", user, roles, e.domainOf(domain)).
+		Delete(&gormadapter.CasbinRule{}).Error
+	if err != nil {
+		logger.ErrorLog("Failed to remove roles from user",
+			logger.String("user", user),
+			logger.String("domain", domain),
+			logger.Any("roles", roles),
 			logger.ErrorField(err))
 		return err
 	}
+
+	database.AfterCommit(ctx, func() {
+		if err := e.LoadPolicy(); err != nil {
+			logger.ErrorLog("Failed to reload Casbin policy", logger.ErrorField(err))
+		}
+		e.notifyReload()
+	})
 	return nil
 }
 
-// GetRolesForUser 获取用户的所有角色
+// GetRolesForUser 获取用户的所有角色，启用多租户时为默认域中的角色
 func (e *Enforcer) GetRolesForUser(user string) ([]string, error) {
+	return e.getRolesForUser(user, "")
+}
+
+// GetRolesForUserInDomain 获取用户在指定域中的角色，包括域为 * 的角色
+func (e *Enforcer) GetRolesForUserInDomain(user, domain string) ([]string, error) {
+	if !e.domains {
+		return nil, ErrDomainsDisabled
+	}
+	return e.getRolesForUser(user, domain)
+}
+
+func (e *Enforcer) getRolesForUser(user, domain string) ([]string, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.GetRolesForUser(user)
+	return e.enforcer.GetRolesForUser(user, e.domainArgs(domain)...)
 }
 
-// GetUsersForRole 获取具有指定角色的所有用户
+// GetUsersForRole 获取具有指定角色的所有用户，启用多租户时为默认域中的用户
 func (e *Enforcer) GetUsersForRole(role string) ([]string, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.GetUsersForRole(role)
+	return e.enforcer.GetUsersForRole(role, e.domainArgs("")...)
+}
+
+// GetUsersForRoleInDomain 获取指定域中具有指定角色的所有用户
+func (e *Enforcer) GetUsersForRoleInDomain(role, domain string) ([]string, error) {
+	if !e.domains {
+		return nil, ErrDomainsDisabled
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enforcer.GetUsersForRole(role, e.domainOf(domain))
+}
+
+// GetDomainsForUser 获取用户分配了角色的所有域
+func (e *Enforcer) GetDomainsForUser(user string) ([]string, error) {
+	if !e.domains {
+		return nil, ErrDomainsDisabled
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enforcer.GetDomainsForUser(user)
+}
+
+// DomainForUser 选择用户登录后所属的域，未启用多租户时返回空
+// 用户在默认域中有角色或只在通配域中有角色时使用默认域，否则使用按名称排序的第一个域。
+func (e *Enforcer) DomainForUser(user string) (string, error) {
+	if !e.domains {
+		return "", nil
+	}
+	domains, err := e.GetDomainsForUser(user)
+	if err != nil {
+		return "", err
+	}
+	domains = slices.DeleteFunc(domains, func(domain string) bool { return domain == DomainWildcard })
+	if len(domains) == 0 || slices.Contains(domains, e.defaultDomain) {
+		return e.defaultDomain, nil
+	}
+	slices.Sort(domains)
+	return domains[0], nil
 }
 
-// HasPermission 检查用户是否有权限
+// HasPermission 检查用户是否有权限，启用多租户时在默认域中检查
 func (e *Enforcer) HasPermission(user, path, method string) (bool, error) {
+	return e.hasPermission(user, "", path, method)
+}
+
+// HasPermissionInDomain 检查用户在指定域中是否有权限，domain 为空时使用默认域
+// domain 不能为 *，避免请求方通过通配域获得所有域的权限。
+func (e *Enforcer) HasPermissionInDomain(user, domain, path, method string) (bool, error) {
+	if !e.domains {
+		return false, ErrDomainsDisabled
+	}
+	if domain == DomainWildcard {
+		return false, nil
+	}
+	return e.hasPermission(user, domain, path, method)
+}
+
+func (e *Enforcer) hasPermission(user, domain, path, method string) (bool, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.Enforce(user, path, method)
+	return e.enforcer.Enforce(e.policy(user, domain, path, method)...)
 }
 
 // GetAllRoles 获取所有角色
@@ -230,41 +676,44 @@ func (e *Enforcer) GetAllActions() ([]string, error) {
 	return e.enforcer.GetAllActions()
 }
 
-// HasRole 检查用户是否具有指定角色
+// HasRole 检查用户是否具有指定角色，启用多租户时在默认域中检查
 func (e *Enforcer) HasRole(user, role string) (bool, error) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-	roles, err := e.enforcer.GetRolesForUser(user)
+	roles, err := e.getRolesForUser(user, "")
 	if err != nil {
 		return false, err
 	}
-	for _, r := range roles {
-		if r == role {
-			return true, nil
-		}
-	}
-	return false, nil
+	return slices.Contains(roles, role), nil
 }
 
-// GetPermissionsForRole 获取角色的所有权限
+// GetPermissionsForRole 获取角色的所有权限，启用多租户时为默认域中的权限
 func (e *Enforcer) GetPermissionsForRole(role string) ([][]string, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.GetPermissionsForUser(role)
+	return e.enforcer.GetPermissionsForUser(role, e.domainArgs("")...)
+}
+
+// GetPermissionsForRoleInDomain 获取角色在指定域中的所有权限
+func (e *Enforcer) GetPermissionsForRoleInDomain(role, domain string) ([][]string, error) {
+	if !e.domains {
+		return nil, ErrDomainsDisabled
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enforcer.GetPermissionsForUser(role, e.domainOf(domain))
 }
 
-// GetImplicitPermissionsForUser 获取用户的所有隐含权限（包括通过角色继承获得的权限）
+// GetImplicitPermissionsForUser 获取用户的所有隐含权限（包括通过角色继承获得的权限），启用多租户时为默认域中的权限
 func (e *Enforcer) GetImplicitPermissionsForUser(user string) ([][]string, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.GetImplicitPermissionsForUser(user)
+	return e.enforcer.GetImplicitPermissionsForUser(user, e.domainArgs("")...)
 }
 
-// GetImplicitRolesForUser 获取用户的所有隐含角色（包括通过角色继承获得的角色）
+// GetImplicitRolesForUser 获取用户的所有隐含角色（包括通过角色继承获得的角色），启用多租户时为默认域中的角色
 func (e *Enforcer) GetImplicitRolesForUser(user string) ([]string, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.enforcer.GetImplicitRolesForUser(user)
+	return e.enforcer.GetImplicitRolesForUser(user, e.domainArgs("")...)
 }
 
 // DeleteRole 删除角色及其所有相关策略
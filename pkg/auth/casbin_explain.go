@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"fiber_web/pkg/logger"
+)
+
+// Decision 权限判定结果
+type Decision struct {
+	Allowed bool     `json:"allowed"`
+	Subject string   `json:"subject"` // 判定的主体，用户或角色
+	Domain  string   `json:"domain,omitempty"`
+	Object  string   `json:"object"`
+	Action  string   `json:"action"`
+	Policy  []string `json:"policy,omitempty"` // 命中的策略，拒绝时为空
+	Roles   []string `json:"roles,omitempty"`  // 从主体到命中策略主体的角色继承链，首个元素为主体本身
+}
+
+// Explain 解释权限判定：是否放行、命中的策略以及主体经由哪些角色获得该策略，启用多租户时在默认域中判定
+func (e *Enforcer) Explain(user, path, method string) (*Decision, error) {
+	return e.explain(user, "", path, method)
+}
+
+// ExplainInDomain 解释指定域中的权限判定，domain 为 * 时与 HasPermissionInDomain 一致直接拒绝
+func (e *Enforcer) ExplainInDomain(user, domain, path, method string) (*Decision, error) {
+	if !e.domains {
+		return nil, ErrDomainsDisabled
+	}
+	if domain == DomainWildcard {
+		return &Decision{Subject: user, Domain: domain, Object: path, Action: method}, nil
+	}
+	return e.explain(user, domain, path, method)
+}
+
+func (e *Enforcer) explain(user, domain, path, method string) (*Decision, error) {
+	d := &Decision{Subject: user, Domain: e.domainOf(domain), Object: path, Action: method}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	allowed, explain, err := e.enforcer.EnforceEx(e.policy(user, domain, path, method)...)
+	if err != nil {
+		return nil, err
+	}
+	d.Allowed = allowed
+	if allowed && len(explain) > 0 {
+		d.Policy = explain
+		d.Roles, err = e.roleChain(user, explain[0], domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// roleChain 按广度优先查找从 user 到 role 的最短角色继承链，调用方需持有读锁
+func (e *Enforcer) roleChain(user, role, domain string) ([]string, error) {
+	parent := map[string]string{}
+	visited := map[string]bool{user: true}
+	queue := []string{user}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == role {
+			chain := []string{name}
+			for name != user {
+				name = parent[name]
+				chain = append([]string{name}, chain...)
+			}
+			return chain, nil
+		}
+
+		roles, err := e.enforcer.GetRolesForUser(name, e.domainArgs(domain)...)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roles {
+			if !visited[r] {
+				visited[r] = true
+				parent[r] = name
+				queue = append(queue, r)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// LogDecision 将权限判定结果写入判定日志，fields 为请求ID等附加字段
+// 未配置 WithDecisionLog 时忽略；未开启记录放行时只记录拒绝的判定。
+func (e *Enforcer) LogDecision(d *Decision, fields ...logger.Field) {
+	if e.decisionLog == nil || (d.Allowed && !e.logAllowed) {
+		return
+	}
+	fields = append([]logger.Field{
+		logger.Bool("allowed", d.Allowed),
+		logger.String("subject", d.Subject),
+		logger.String("domain", d.Domain),
+		logger.String("object", d.Object),
+		logger.String("action", d.Action),
+		logger.Any("policy", d.Policy),
+		logger.Any("role_chain", d.Roles),
+	}, fields...)
+	e.decisionLog.Info("RBAC decision", fields...)
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"fiber_web/pkg/logger"
+	"slices"
+)
+
+// menuPolicy 按模型组装菜单策略，启用多租户时在角色后插入域
+func (e *Enforcer) menuPolicy(role, domain, menu string) []interface{} {
+	if !e.domains {
+		return []interface{}{role, menu}
+	}
+	return []interface{}{role, e.domainOf(domain), menu}
+}
+
+// GrantMenu 允许角色查看菜单，menu 为 MenuWildcard 时可查看全部菜单，启用多租户时作用于默认域
+func (e *Enforcer) GrantMenu(role, menu string) error {
+	return e.grantMenu(role, "", menu)
+}
+
+// GrantMenuInDomain 在指定域中允许角色查看菜单，domain 为 * 时在所有域生效
+func (e *Enforcer) GrantMenuInDomain(role, domain, menu string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.grantMenu(role, domain, menu)
+}
+
+func (e *Enforcer) grantMenu(role, domain, menu string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.enforcer.AddNamedPolicy(MenuPolicyType, e.menuPolicy(role, domain, menu)...); err != nil {
+		logger.ErrorLog("Failed to grant menu",
+			logger.String("role", role),
+			logger.String("domain", domain),
+			logger.String("menu", menu),
+			logger.ErrorField(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeMenu 收回角色查看菜单的权限，启用多租户时作用于默认域
+func (e *Enforcer) RevokeMenu(role, menu string) error {
+	return e.revokeMenu(role, "", menu)
+}
+
+// RevokeMenuInDomain 收回角色在指定域中查看菜单的权限
+func (e *Enforcer) RevokeMenuInDomain(role, domain, menu string) error {
+	if !e.domains {
+		return ErrDomainsDisabled
+	}
+	return e.revokeMenu(role, domain, menu)
+}
+
+func (e *Enforcer) revokeMenu(role, domain, menu string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.enforcer.RemoveNamedPolicy(MenuPolicyType, e.menuPolicy(role, domain, menu)...); err != nil {
+		logger.ErrorLog("Failed to revoke menu",
+			logger.String("role", role),
+			logger.String("domain", domain),
+			logger.String("menu", menu),
+			logger.ErrorField(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeMenuFromAllRoles 收回所有角色在各个域中查看菜单的权限，用于删除菜单后清理菜单策略
+func (e *Enforcer) RevokeMenuFromAllRoles(menu string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	policies, err := e.enforcer.GetNamedPolicy(MenuPolicyType)
+	if err != nil {
+		return err
+	}
+	var rules [][]string
+	for _, p := range policies {
+		if p[len(p)-1] == menu {
+			rules = append(rules, p)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	if _, err := e.enforcer.RemoveNamedPolicies(MenuPolicyType, rules); err != nil {
+		logger.ErrorLog("Failed to revoke menu from all roles",
+			logger.String("menu", menu),
+			logger.ErrorField(err))
+		return err
+	}
+	return nil
+}
+
+// GetMenusForRoles 获取角色可查看的菜单，包括继承的角色与域为 * 的授权，domain 为空时使用默认域
+// 返回结果包含 MenuWildcard 时表示可查看全部菜单。
+func (e *Enforcer) GetMenusForRoles(domain string, roles ...string) ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	subjects := make([]string, 0, len(roles))
+	for _, role := range roles {
+		inherited, err := e.enforcer.GetImplicitRolesForUser(role, e.domainArgs(domain)...)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range append([]string{role}, inherited...) {
+			if !slices.Contains(subjects, r) {
+				subjects = append(subjects, r)
+			}
+		}
+	}
+
+	policies, err := e.enforcer.GetNamedPolicy(MenuPolicyType)
+	if err != nil {
+		return nil, err
+	}
+	var menus []string
+	for _, p := range policies {
+		if !slices.Contains(subjects, p[0]) {
+			continue
+		}
+		if e.domains {
+			if p[1] != e.domainOf(domain) && p[1] != DomainWildcard {
+				continue
+			}
+		}
+		if menu := p[len(p)-1]; !slices.Contains(menus, menu) {
+			menus = append(menus, menu)
+		}
+	}
+	slices.Sort(menus)
+	return menus, nil
+}
@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupCasbinTestDB 创建内存 SQLite 数据库
+func setupCasbinTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestEnforcerDomains(t *testing.T) {
+	db := setupCasbinTestDB(t)
+
+	// 单租户策略
+	single, err := NewEnforcer(db)
+	if err != nil {
+		t.Fatalf("NewEnforcer() error = %v", err)
+	}
+	if err := single.AddPolicy("admin", "/api/users/*", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	if err := single.AddRoleForUser("1", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := single.AddPolicyInDomain("admin", "acme", "/api/users/*", "GET"); !errors.Is(err, ErrDomainsDisabled) {
+		t.Errorf("未启用多租户 AddPolicyInDomain() error = %v, want %v", err, ErrDomainsDisabled)
+	}
+
+	// 启用多租户后已有策略迁移到默认域
+	e, err := NewEnforcer(db, WithDomains("default"))
+	if err != nil {
+		t.Fatalf("NewEnforcer(WithDomains) error = %v", err)
+	}
+	if ok, _ := e.HasPermission("1", "/api/users/1", "GET"); !ok {
+		t.Error("迁移后默认域中应保留原有权限")
+	}
+	if roles, _ := e.GetRolesForUserInDomain("1", "default"); !slices.Equal(roles, []string{"admin"}) {
+		t.Errorf("GetRolesForUserInDomain(default) = %v, want [admin]", roles)
+	}
+
+	// 迁移可重复执行
+	if err := MigratePoliciesToDomain(db, "other"); err != nil {
+		t.Fatalf("MigratePoliciesToDomain() error = %v", err)
+	}
+	var rules []gormadapter.CasbinRule
+	db.Order("id").Find(&rules)
+	if len(rules) != 2 || rules[0].V1 != "default" || rules[0].V3 != "GET" || rules[1].V2 != "default" {
+		t.Errorf("重复迁移后 casbin_rule = %+v", rules)
+	}
+
+	// 域之间相互隔离
+	if err := e.AddPolicyInDomain("admin", "acme", "/api/orders/*", "*"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddPolicyInDomain("viewer", "beta", "/api/orders/:id/items", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	// 未使用 /* 的前缀通配沿用 keyMatch 的语义
+	if err := e.AddPolicyInDomain("viewer", "beta", "/api/reports*", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddRoleForUserInDomain("3", "viewer", "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddRoleForUserInDomain("2", "admin", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	// 平台级角色与策略
+	if err := e.AddRoleForUserInDomain("root", "auditor", DomainWildcard); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddPolicyInDomain("auditor", DomainWildcard, "/api/audit", "GET"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		user   string
+		domain string
+		path   string
+		want   bool
+	}{
+		{"本域权限", "2", "acme", "/api/orders/1", true},
+		{"路由参数", "3", "beta", "/api/orders/1/items", true},
+		{"路由参数不跨段匹配", "3", "beta", "/api/orders/1/2/items", false},
+		{"前缀通配", "3", "beta", "/api/reports/2024/summary", true},
+		{"其他域的角色不生效", "1", "acme", "/api/orders/1", false},
+		{"其他域的策略不生效", "2", "acme", "/api/users/1", false},
+		{"空域使用默认域", "1", "", "/api/users/1", true},
+		{"通配域的角色与策略在所有域生效", "root", "acme", "/api/audit", true},
+		{"通配域的角色与策略在默认域生效", "root", "default", "/api/audit", true},
+		{"不能以通配域鉴权", "root", DomainWildcard, "/api/audit", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.HasPermissionInDomain(tt.user, tt.domain, tt.path, "GET")
+			if err != nil {
+				t.Fatalf("HasPermissionInDomain() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasPermissionInDomain(%s, %s, %s) = %v, want %v", tt.user, tt.domain, tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if domains, _ := e.GetDomainsForUser("2"); !slices.Equal(domains, []string{"acme"}) {
+		t.Errorf("GetDomainsForUser() = %v, want [acme]", domains)
+	}
+	if err := e.AddRoleForUserInDomain("3", "viewer", "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddRoleForUserInDomain("1", "viewer", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		user string
+		want string
+	}{
+		{"只属于一个域", "2", "acme"},
+		{"属于多个域时按名称排序", "3", "alpha"},
+		{"属于默认域时优先默认域", "1", "default"},
+		{"只属于通配域", "root", "default"},
+		{"没有角色", "nobody", "default"},
+	} {
+		if got, err := e.DomainForUser(tt.user); err != nil || got != tt.want {
+			t.Errorf("%s: DomainForUser(%s) = %q, %v, want %q", tt.name, tt.user, got, err, tt.want)
+		}
+	}
+	if got, err := single.DomainForUser("1"); err != nil || got != "" {
+		t.Errorf("未启用多租户时 DomainForUser() = %q, %v, want 空", got, err)
+	}
+	if err := e.RemoveRoleForUserInDomain("2", "admin", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := e.HasPermissionInDomain("2", "acme", "/api/orders/1", "GET"); ok {
+		t.Error("移除角色后不应有权限")
+	}
+}
+
+func TestEnforcerExplain(t *testing.T) {
+	e, err := NewEnforcer(setupCasbinTestDB(t), WithDomains("default"))
+	if err != nil {
+		t.Fatalf("NewEnforcer() error = %v", err)
+	}
+	// 1 -> editor -> writer，writer 拥有编辑文章的权限
+	for _, p := range [][2]string{{"writer", "/api/posts/:id"}, {"editor", "/api/tags"}} {
+		if err := e.AddPolicy(p[0], p[1], "PUT"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, g := range [][2]string{{"1", "editor"}, {"editor", "writer"}, {"2", "writer"}} {
+		if err := e.AddRoleForUser(g[0], g[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.AddRoleForUserInDomain("3", "editor", DomainWildcard); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		user       string
+		domain     string
+		path       string
+		wantPolicy []string
+		wantRoles  []string
+	}{
+		{"继承的角色", "1", "", "/api/posts/1", []string{"writer", "default", "/api/posts/:id", "PUT"}, []string{"1", "editor", "writer"}},
+		{"直接分配的角色", "1", "", "/api/tags", []string{"editor", "default", "/api/tags", "PUT"}, []string{"1", "editor"}},
+		{"主体为角色", "writer", "", "/api/posts/1", []string{"writer", "default", "/api/posts/:id", "PUT"}, []string{"writer"}},
+		{"通配域分配的角色", "3", "default", "/api/posts/1", []string{"writer", "default", "/api/posts/:id", "PUT"}, []string{"3", "editor", "writer"}},
+		{"无权限", "2", "", "/api/tags", nil, nil},
+		{"其他域", "1", "acme", "/api/posts/1", nil, nil},
+		{"通配域", "3", DomainWildcard, "/api/posts/1", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := e.ExplainInDomain(tt.user, tt.domain, tt.path, "PUT")
+			if err != nil {
+				t.Fatalf("ExplainInDomain() error = %v", err)
+			}
+			if d.Allowed != (tt.wantPolicy != nil) || !slices.Equal(d.Policy, tt.wantPolicy) || !slices.Equal(d.Roles, tt.wantRoles) {
+				t.Errorf("ExplainInDomain() = %+v, want policy %v roles %v", d, tt.wantPolicy, tt.wantRoles)
+			}
+		})
+	}
+
+	single, err := NewEnforcer(setupCasbinTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := single.ExplainInDomain("1", "acme", "/api/tags", "PUT"); !errors.Is(err, ErrDomainsDisabled) {
+		t.Errorf("未启用多租户 ExplainInDomain() error = %v, want %v", err, ErrDomainsDisabled)
+	}
+}
+
+func TestEnforcerMenus(t *testing.T) {
+	db := setupCasbinTestDB(t)
+	single, err := NewEnforcer(db)
+	if err != nil {
+		t.Fatalf("NewEnforcer() error = %v", err)
+	}
+	if err := single.GrantMenu("editor", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := single.AddRoleForUser("chief", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if menus, _ := single.GetMenusForRoles("", "chief"); !slices.Equal(menus, []string{"10"}) {
+		t.Errorf("继承的角色 GetMenusForRoles() = %v, want [10]", menus)
+	}
+
+	// 启用多租户后菜单策略迁移到默认域
+	e, err := NewEnforcer(db, WithDomains("default"))
+	if err != nil {
+		t.Fatalf("NewEnforcer(WithDomains) error = %v", err)
+	}
+	for _, g := range [][3]string{{"editor", "default", "11"}, {"editor", "acme", "20"}, {"admin", DomainWildcard, MenuWildcard}} {
+		if err := e.GrantMenuInDomain(g[0], g[1], g[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.RevokeMenu("editor", "11"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		roles  []string
+		want   []string
+	}{
+		{"迁移后的菜单与继承的角色", "", []string{"chief"}, []string{"10"}},
+		{"按域隔离", "acme", []string{"editor"}, []string{"20"}},
+		{"多个角色合并", "acme", []string{"editor", "admin"}, []string{MenuWildcard, "20"}},
+		{"通配域的菜单在所有域生效", "beta", []string{"admin"}, []string{MenuWildcard}},
+		{"无菜单", "beta", []string{"editor"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			menus, err := e.GetMenusForRoles(tt.domain, tt.roles...)
+			if err != nil {
+				t.Fatalf("GetMenusForRoles() error = %v", err)
+			}
+			if !slices.Equal(menus, tt.want) {
+				t.Errorf("GetMenusForRoles(%s, %v) = %v, want %v", tt.domain, tt.roles, menus, tt.want)
+			}
+		})
+	}
+
+	// 删除菜单时收回所有角色在各个域中的授权
+	if err := e.GrantMenuInDomain("viewer", DomainWildcard, "20"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RevokeMenuFromAllRoles("20"); err != nil {
+		t.Fatalf("RevokeMenuFromAllRoles() error = %v", err)
+	}
+	if menus, _ := e.GetMenusForRoles("acme", "editor", "viewer"); menus != nil {
+		t.Errorf("收回后 GetMenusForRoles() = %v, want 空", menus)
+	}
+	if menus, _ := e.GetMenusForRoles("", "chief"); !slices.Equal(menus, []string{"10"}) {
+		t.Errorf("其他菜单不受影响 GetMenusForRoles() = %v, want [10]", menus)
+	}
+}
@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v3/model"
+)
+
+// DefaultPolicyChannel 默认的策略变更通知频道
+const DefaultPolicyChannel = "casbin:policy"
+
+// 发布策略变更通知的超时时间
+const policyPublishTimeout = 3 * time.Second
+
+// 策略变更类型
+const (
+	policyOpReload         = "reload"          // 重新加载全部策略
+	policyOpAdd            = "add"             // 添加策略
+	policyOpRemove         = "remove"          // 删除策略
+	policyOpRemoveFiltered = "remove_filtered" // 按字段删除策略
+)
+
+// PolicyBus 策略变更通知的发布订阅通道
+type PolicyBus interface {
+	// Publish 发布通知
+	Publish(ctx context.Context, message string) error
+	// Subscribe 订阅通知，返回时订阅已生效，ctx 取消后停止订阅
+	Subscribe(ctx context.Context, handler func(message string)) error
+}
+
+// RedisPolicyBus 基于 Redis 发布订阅的策略变更通道
+// 发布订阅不保证送达，实例断线期间的通知会丢失，需配合 WithAutoReload 定期全量加载。
+type RedisPolicyBus struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisPolicyBus 创建 Redis 策略变更通道，channel 为空时使用 DefaultPolicyChannel
+func NewRedisPolicyBus(client *redis.Client, channel string) *RedisPolicyBus {
+	if channel == "" {
+		channel = DefaultPolicyChannel
+	}
+	return &RedisPolicyBus{client: client, channel: channel}
+}
+
+func (b *RedisPolicyBus) Publish(ctx context.Context, message string) error {
+	_, err := b.client.Publish(ctx, b.channel, message)
+	return err
+}
+
+func (b *RedisPolicyBus) Subscribe(ctx context.Context, handler func(message string)) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// 等待订阅确认，确保返回后发布的通知都能收到
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler(msg.Payload)
+			}
+		}
+	}()
+	return nil
+}
+
+// policyMessage 策略变更通知
+type policyMessage struct {
+	Source      string     `json:"source"` // 发布通知的实例，实例忽略自己发布的通知
+	Op          string     `json:"op"`
+	Sec         string     `json:"sec,omitempty"`
+	Ptype       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	FieldIndex  int        `json:"field_index,omitempty"`
+	FieldValues []string   `json:"field_values,omitempty"`
+}
+
+// queuedPolicyMessage 已编码、等待发布的通知
+type queuedPolicyMessage struct {
+	op   string
+	data string
+}
+
+// policyWatcher 实现 casbin 的 persist.WatcherEx，将本实例的策略变更发布到 PolicyBus
+// casbin 在持有 Enforcer.mu 写锁时调用 watcher，通知先放入队列，由后台协程按顺序发布，避免网络 I/O 阻塞鉴权。
+type policyWatcher struct {
+	bus      PolicyBus
+	source   string
+	mu       sync.RWMutex
+	callback func(string)
+	cancel   context.CancelFunc
+
+	queueMu    sync.Mutex
+	idle       *sync.Cond            // 队列清空时广播
+	queue      []queuedPolicyMessage // 待发布的通知
+	publishing bool                  // 正在发布队首取出的通知
+	closed     bool                  // 已关闭，不再接受通知
+	wake       chan struct{}         // 唤醒发布协程
+	done       chan struct{}
+}
+
+func newPolicyWatcher(bus PolicyBus) (*policyWatcher, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	w := &policyWatcher{
+		bus:    bus,
+		source: hex.EncodeToString(b),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	w.idle = sync.NewCond(&w.queueMu)
+	go w.run()
+	return w, nil
+}
+
+// start 开始订阅其他实例的通知
+func (w *policyWatcher) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := w.bus.Subscribe(ctx, w.receive); err != nil {
+		cancel()
+		return err
+	}
+	w.cancel = cancel
+	return nil
+}
+
+func (w *policyWatcher) receive(message string) {
+	w.mu.RLock()
+	callback := w.callback
+	w.mu.RUnlock()
+	if callback != nil {
+		callback(message)
+	}
+}
+
+// publish 将通知放入发布队列，不等待发布完成
+// 发布失败只记录日志，策略已写入数据库，其他实例会在下次全量加载时同步。
+func (w *policyWatcher) publish(msg *policyMessage) error {
+	msg.Source = w.source
+	// 立即编码，casbin 之后可能复用规则切片
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.queueMu.Lock()
+	if !w.closed {
+		w.queue = append(w.queue, queuedPolicyMessage{op: msg.Op, data: string(data)})
+	}
+	w.queueMu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run 按入队顺序发布通知，直到 Close
+func (w *policyWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+		for {
+			w.queueMu.Lock()
+			if len(w.queue) == 0 {
+				w.publishing = false
+				w.idle.Broadcast()
+				w.queueMu.Unlock()
+				break
+			}
+			msg := w.queue[0]
+			w.queue = w.queue[1:]
+			w.publishing = true
+			w.queueMu.Unlock()
+			w.send(msg)
+		}
+	}
+}
+
+func (w *policyWatcher) send(msg queuedPolicyMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), policyPublishTimeout)
+	defer cancel()
+	if err := w.bus.Publish(ctx, msg.data); err != nil {
+		logger.Warn("Failed to publish Casbin policy update",
+			logger.String("op", msg.op),
+			logger.ErrorField(err))
+	}
+}
+
+// flush 等待已入队的通知发布完成
+func (w *policyWatcher) flush() {
+	w.queueMu.Lock()
+	defer w.queueMu.Unlock()
+	for len(w.queue) > 0 || w.publishing {
+		w.idle.Wait()
+	}
+}
+
+func (w *policyWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+func (w *policyWatcher) Update() error {
+	return w.publish(&policyMessage{Op: policyOpReload})
+}
+
+// Close 停止订阅与发布，尚未发布的通知被丢弃，其他实例会在下次全量加载时同步
+func (w *policyWatcher) Close() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.queueMu.Lock()
+	defer w.queueMu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.queue = nil
+	w.idle.Broadcast()
+	close(w.done)
+}
+
+func (w *policyWatcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.publish(&policyMessage{Op: policyOpAdd, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *policyWatcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.publish(&policyMessage{Op: policyOpRemove, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *policyWatcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.publish(&policyMessage{Op: policyOpRemoveFiltered, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, FieldValues: fieldValues})
+}
+
+func (w *policyWatcher) UpdateForSavePolicy(model.Model) error {
+	return w.Update()
+}
+
+func (w *policyWatcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(&policyMessage{Op: policyOpAdd, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+func (w *policyWatcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(&policyMessage{Op: policyOpRemove, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// onPolicyUpdate 处理其他实例的策略变更通知，增量更新内存中的策略
+// 无法增量更新时重新加载全部策略。
+func (e *Enforcer) onPolicyUpdate(message string) {
+	var msg policyMessage
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		logger.Warn("Invalid Casbin policy update", logger.ErrorField(err))
+		return
+	}
+	if msg.Source == e.watcher.source {
+		return
+	}
+	if err := e.applyPolicyUpdate(&msg); err != nil {
+		logger.Warn("Failed to apply Casbin policy update, reloading",
+			logger.String("op", msg.Op),
+			logger.ErrorField(err))
+		if err := e.LoadPolicy(); err != nil {
+			logger.ErrorLog("Failed to reload Casbin policy", logger.ErrorField(err))
+		}
+	}
+}
+
+func (e *Enforcer) applyPolicyUpdate(msg *policyMessage) error {
+	if msg.Op == policyOpReload {
+		return e.LoadPolicy()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// 直接修改内存中的模型，策略已由发布通知的实例写入数据库
+	m := e.enforcer.GetModel()
+	var (
+		affected [][]string
+		op       model.PolicyOp
+		err      error
+	)
+	switch msg.Op {
+	case policyOpAdd:
+		op = model.PolicyAdd
+		affected, err = m.AddPoliciesWithAffected(msg.Sec, msg.Ptype, msg.Rules)
+	case policyOpRemove:
+		op = model.PolicyRemove
+		affected, err = m.RemovePoliciesWithAffected(msg.Sec, msg.Ptype, msg.Rules)
+	case policyOpRemoveFiltered:
+		op = model.PolicyRemove
+		_, affected, err = m.RemoveFilteredPolicy(msg.Sec, msg.Ptype, msg.FieldIndex, msg.FieldValues...)
+	default:
+		return fmt.Errorf("unknown policy update op %q", msg.Op)
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Sec == "g" && len(affected) > 0 {
+		return e.enforcer.BuildIncrementalRoleLinks(op, msg.Ptype, affected)
+	}
+	return nil
+}
+
+// notifyReload 通知其他实例重新加载全部策略，用于绕过 casbin 直接写入数据库的变更
+func (e *Enforcer) notifyReload() {
+	if e.watcher != nil {
+		_ = e.watcher.Update()
+	}
+}
+
+// autoReload 定期重新加载全部策略，直到 Close
+func (e *Enforcer) autoReload(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			if err := e.LoadPolicy(); err != nil {
+				logger.ErrorLog("Failed to reload Casbin policy", logger.ErrorField(err))
+			}
+		}
+	}
+}
+
+// Close 停止策略同步与定期加载
+func (e *Enforcer) Close() {
+	e.closeOnce.Do(func() {
+		close(e.done)
+		if e.watcher != nil {
+			e.watcher.Close()
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fiber_web/pkg/database"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+)
+
+// memoryPolicyBus 测试用进程内策略变更通道，同步投递给所有订阅者
+type memoryPolicyBus struct {
+	mu   sync.Mutex
+	subs []memoryPolicySub
+}
+
+type memoryPolicySub struct {
+	ctx     context.Context
+	handler func(string)
+}
+
+func (b *memoryPolicyBus) Publish(_ context.Context, message string) error {
+	b.mu.Lock()
+	subs := slices.Clone(b.subs)
+	b.mu.Unlock()
+	for _, sub := range subs {
+		if sub.ctx.Err() == nil {
+			sub.handler(message)
+		}
+	}
+	return nil
+}
+
+func (b *memoryPolicyBus) Subscribe(ctx context.Context, handler func(string)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, memoryPolicySub{ctx: ctx, handler: handler})
+	return nil
+}
+
+func TestEnforcerWatcher(t *testing.T) {
+	db := setupCasbinTestDB(t)
+	bus := &memoryPolicyBus{}
+
+	newEnforcer := func(opts ...RbacOption) *Enforcer {
+		t.Helper()
+		e, err := NewEnforcer(db, opts...)
+		if err != nil {
+			t.Fatalf("NewEnforcer() error = %v", err)
+		}
+		t.Cleanup(e.Close)
+		return e
+	}
+	a := newEnforcer(WithWatcher(bus))
+	b := newEnforcer(WithWatcher(bus))
+
+	// 绕过 casbin 直接写入数据库，只有全量加载才能看到
+	if err := db.Create(&gormadapter.CasbinRule{Ptype: "p", V0: "editor", V1: "/api/tags", V2: "GET"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 通知由后台协程发布，检查其他实例前等待 a 的通知发布完成
+	check := func(name string, e *Enforcer, user, path, method string, want bool) {
+		t.Helper()
+		a.watcher.flush()
+		got, err := e.HasPermission(user, path, method)
+		if err != nil {
+			t.Fatalf("%s: HasPermission() error = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: HasPermission(%s, %s, %s) = %v, want %v", name, user, path, method, got, want)
+		}
+	}
+
+	if err := a.AddPolicy("editor", "/api/posts/:id", "PUT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddRoleForUser("7", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	check("增量同步策略与角色", b, "7", "/api/posts/1", "PUT", true)
+	check("增量同步不重新加载", b, "7", "/api/tags", "GET", false)
+
+	if err := a.RemovePolicy("editor", "/api/posts/:id", "PUT"); err != nil {
+		t.Fatal(err)
+	}
+	check("同步删除策略", b, "7", "/api/posts/1", "PUT", false)
+
+	// 事务中写入的角色在提交后通知其他实例全量加载
+	tx := database.NewTxManager(db, database.TxOptions{})
+	if err := tx.Transaction(context.Background(), func(ctx context.Context) error {
+		return a.AddRolesForUserCtx(ctx, "8", "editor")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	check("事务提交后全量加载", b, "8", "/api/tags", "GET", true)
+
+	// 事务回滚时移除的角色不生效
+	rollback := errors.New("rollback")
+	if err := tx.Transaction(context.Background(), func(ctx context.Context) error {
+		if err := a.RemoveRolesForUserCtx(ctx, "8", "editor"); err != nil {
+			return err
+		}
+		return rollback
+	}); !errors.Is(err, rollback) {
+		t.Fatalf("Transaction() error = %v, want %v", err, rollback)
+	}
+	check("事务回滚不移除角色", a, "8", "/api/tags", "GET", true)
+	if err := tx.Transaction(context.Background(), func(ctx context.Context) error {
+		return a.RemoveRolesForUserCtx(ctx, "8", "editor")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	check("事务提交后移除角色", b, "8", "/api/tags", "GET", false)
+	if err := a.AddRolesForUserCtx(context.Background(), "8", "editor"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.DeleteRole("editor"); err != nil {
+		t.Fatal(err)
+	}
+	a.watcher.flush()
+	if roles, _ := b.GetRolesForUser("7"); len(roles) != 0 {
+		t.Errorf("同步删除角色后 GetRolesForUser() = %v, want 空", roles)
+	}
+	check("同步按字段删除策略", b, "8", "/api/tags", "GET", false)
+
+	// 关闭后不再接收通知
+	b.Close()
+	if err := a.AddPolicy("viewer", "/api/posts", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	a.watcher.flush()
+	if ok, _ := b.enforcer.HasPolicy("viewer", "/api/posts", "GET"); ok {
+		t.Error("Close() 后不应继续同步策略")
+	}
+
+	// 定期全量加载兜底
+	c := newEnforcer(WithAutoReload(10 * time.Millisecond))
+	if err := db.Create(&gormadapter.CasbinRule{Ptype: "g", V0: "9", V1: "viewer"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if ok, _ := c.HasPermission("9", "/api/posts", "GET"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("定期加载后应能看到直接写入数据库的角色")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// blockingPolicyBus 测试用策略变更通道，发布一直阻塞到 release 关闭
+type blockingPolicyBus struct {
+	published chan string
+	release   chan struct{}
+}
+
+func (b *blockingPolicyBus) Publish(_ context.Context, message string) error {
+	<-b.release
+	b.published <- message
+	return nil
+}
+
+func (b *blockingPolicyBus) Subscribe(context.Context, func(string)) error { return nil }
+
+// TestEnforcerWatcherAsync 发布通知不阻塞策略修改与鉴权，并保持修改的顺序
+func TestEnforcerWatcherAsync(t *testing.T) {
+	db := setupCasbinTestDB(t)
+	bus := &blockingPolicyBus{published: make(chan string, 2), release: make(chan struct{})}
+	e, err := NewEnforcer(db, WithWatcher(bus))
+	if err != nil {
+		t.Fatalf("NewEnforcer() error = %v", err)
+	}
+	t.Cleanup(e.Close)
+
+	done := make(chan error, 1)
+	go func() {
+		if err := e.AddPolicy("editor", "/api/posts", "GET"); err != nil {
+			done <- err
+			return
+		}
+		done <- e.RemovePolicy("editor", "/api/posts", "GET")
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("发布阻塞时修改策略不应等待发布完成")
+	}
+	if _, err := e.HasPermission("1", "/api/posts", "GET"); err != nil {
+		t.Fatal(err)
+	}
+
+	close(bus.release)
+	for _, want := range []string{policyOpAdd, policyOpRemove} {
+		var msg policyMessage
+		if err := json.Unmarshal([]byte(<-bus.published), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Op != want {
+			t.Errorf("通知顺序错误: op = %s, want %s", msg.Op, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantClaim 租户自定义声明，启用多租户 RBAC 时作为鉴权的域
+const TenantClaim = "tenant"
+
+// Claims 表示JWT的声明内容
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID   uint64         `json:"user_id"`
+	Username string         `json:"username"`
+	Role     string         `json:"role"`             // 主角色，与 Roles 的第一个元素相同，兼容只有单角色的旧令牌
+	Roles    []string       `json:"roles,omitempty"`  // 用户的全部角色
+	Scopes   []string       `json:"scopes,omitempty"` // 授权范围
+	Extra    map[string]any `json:"ext,omitempty"`    // 自定义载荷，如租户ID
+	Type     string         `json:"type"`
+	Family   string         `json:"fam,omitempty"` // 令牌家族，同一次登录及其后续刷新签发的令牌属于同一家族
+}
+
+// Identity 签发令牌的身份信息
+type Identity struct {
+	UserID   uint64
+	Subject  string // 令牌主体 sub，为空时使用 UserID；ID 可能重复的多类账号应使用带前缀的主体区分
+	Username string
+	Roles    []string
+	Scopes   []string
+	Extra    map[string]any
+}
+
+// Identity 获取令牌中的身份信息，用于刷新时重新签发
+func (c *Claims) Identity() Identity {
+	return Identity{
+		UserID:   c.UserID,
+		Subject:  c.Subject,
+		Username: c.Username,
+		Roles:    c.AllRoles(),
+		Scopes:   c.Scopes,
+		Extra:    c.Extra,
+	}
+}
+
+// subject 获取令牌主体
+func (id Identity) subject() string {
+	if id.Subject != "" {
+		return id.Subject
+	}
+	return strconv.FormatUint(id.UserID, 10)
+}
+
+// AllRoles 获取全部角色，旧令牌只有 Role 时返回该角色
+func (c *Claims) AllRoles() []string {
+	if len(c.Roles) > 0 {
+		return c.Roles
+	}
+	if c.Role != "" {
+		return []string{c.Role}
+	}
+	return nil
+}
+
+// HasRole 判断是否具有指定角色
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.AllRoles(), role)
+}
+
+// HasAnyRole 判断是否具有任一指定角色
+func (c *Claims) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if c.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tenant 获取令牌所属的租户，未设置时返回空
+func (c *Claims) Tenant() string {
+	tenant, _ := ExtraValue[string](c, TenantClaim)
+	return tenant
+}
+
+// HasScope 判断是否具有指定授权范围
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// ExtraValue 获取自定义载荷并转换为 T
+// 令牌解析后数字为 float64、对象为 map，类型不一致时通过 JSON 转换。
+func ExtraValue[T any](c *Claims, key string) (T, bool) {
+	var result T
+	raw, ok := c.Extra[key]
+	if !ok {
+		return result, false
+	}
+	if v, ok := raw.(T); ok {
+		return v, true
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return result, false
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, false
+	}
+	return result, true
+}
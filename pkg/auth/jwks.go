@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSPath JWKS 的标准路径
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler 返回 JWKS 公钥集合，供其他服务验证令牌
+// maxAge 为客户端缓存时间，应小于密钥轮换的宽限期，使验证方能及时获取新密钥。
+func JWKSHandler(keys *KeySet, maxAge time.Duration) fiber.Handler {
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+		return c.JSON(keys.JWKS())
+	}
+}
+
+// PublicKey 解码 JWK 中的公钥，支持 RSA、EC P-256 与 Ed25519
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 RSA 模数: %w", err)
+		}
+		e, err := dec(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("无效的 RSA 指数")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("不支持的椭圆曲线: %s", k.Crv)
+		}
+		x, errX := dec(k.X)
+		y, errY := dec(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("无效的 EC 公钥")
+		}
+		// 未压缩格式为 0x04 || X || Y
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	case "OKP":
+		x, err := dec(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("无效的 Ed25519 公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+	}
+}
@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fiber_web/pkg/config"
 	"fiber_web/pkg/logger"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,6 +18,9 @@ import (
 var (
 	ErrInvalidToken = errors.New("无效的令牌")
 	ErrExpiredToken = errors.New("令牌已过期")
+	ErrRevokedToken = errors.New("令牌已吊销")
+	ErrTokenReused  = errors.New("刷新令牌被重复使用")
+	ErrNoTokenStore = errors.New("未配置令牌存储")
 )
 
 var (
@@ -27,9 +34,9 @@ func GetJWTManager() *JWTManager {
 }
 
 // InitJWTManager 初始化JWTManager单例
-func InitJWTManager(cfg *config.JWTConfig) {
+func InitJWTManager(cfg *config.JWTConfig, opts ...JWTOption) {
 	jwtManagerOnce.Do(func() {
-		jwtManager = NewJWTManager(cfg)
+		jwtManager = NewJWTManager(cfg, opts...)
 	})
 }
 
@@ -37,18 +44,13 @@ func InitJWTManager(cfg *config.JWTConfig) {
 type TokenType string
 
 const (
-	AccessToken  TokenType = "access"
-	RefreshToken TokenType = "refresh"
+	AccessToken    TokenType = "access"
+	RefreshToken   TokenType = "refresh"
+	ChallengeToken TokenType = "challenge" // 两步验证质询令牌，只能用于提交第二因素
 )
 
-// Claims 表示JWT的声明内容
-type Claims struct {
-	jwt.RegisteredClaims
-	UserID   uint64 `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	Type     string `json:"type"`
-}
+// ChallengeTokenExpiry 质询令牌有效期
+const ChallengeTokenExpiry = 5 * time.Minute
 
 // TokenPair 表示访问令牌和刷新令牌的配对
 type TokenPair struct {
@@ -60,32 +62,119 @@ type TokenPair struct {
 
 // JWTManager 处理JWT令牌的操作
 type JWTManager struct {
-	secretKey          string
+	keys               *KeySet
 	accessTokenExpiry  time.Duration
 	refreshTokenExpiry time.Duration
+	issuer             string
+	audience           []string
+	leeway             time.Duration
+	store              TokenStore   // 令牌状态存储，为 nil 时不支持轮换检测与吊销
+	sessions           SessionStore // 会话存储，为 nil 时不记录会话
+	maxSessions        int          // 每个主体的最大并发会话数，0 表示不限制
+}
+
+// JWTOption JWT管理器配置项
+type JWTOption func(*JWTManager)
+
+// WithTokenStore 设置令牌状态存储，启用刷新令牌轮换、重放检测与吊销
+func WithTokenStore(store TokenStore) JWTOption {
+	return func(m *JWTManager) {
+		m.store = store
+	}
+}
+
+// WithSessionStore 设置会话存储，记录每个令牌家族的客户端信息并按配置限制并发会话数
+// 会话的吊销依赖令牌家族，未配置令牌存储时忽略。
+func WithSessionStore(sessions SessionStore) JWTOption {
+	return func(m *JWTManager) {
+		m.sessions = sessions
+	}
+}
+
+// WithKeySet 设置签名密钥集合，默认使用配置中的 secret_key 进行 HS256 签名
+func WithKeySet(keys *KeySet) JWTOption {
+	return func(m *JWTManager) {
+		m.keys = keys
+	}
 }
 
 // NewJWTManager 创建一个新的JWT管理器
-func NewJWTManager(cfg *config.JWTConfig) *JWTManager {
-	return &JWTManager{
-		secretKey:          cfg.SecretKey,
+func NewJWTManager(cfg *config.JWTConfig, opts ...JWTOption) *JWTManager {
+	m := &JWTManager{
 		accessTokenExpiry:  cfg.AccessTokenExpiry,
 		refreshTokenExpiry: cfg.RefreshTokenExpiry,
+		issuer:             cfg.Issuer,
+		audience:           cfg.Audience,
+		leeway:             cfg.Leeway,
+		maxSessions:        cfg.MaxSessions,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store == nil {
+		m.sessions = nil
+	}
+	if m.keys == nil {
+		m.keys = NewKeySet(NewHMACKey("", []byte(cfg.SecretKey)))
+	}
+	return m
+}
+
+// Keys 获取签名密钥集合
+func (m *JWTManager) Keys() *KeySet {
+	return m.keys
+}
+
+// GenerateTokenPair 生成新的访问令牌和刷新令牌配对，开启一个新的令牌家族
+func (m *JWTManager) GenerateTokenPair(ctx context.Context, userID uint64, username, role string) (*TokenPair, error) {
+	return m.IssueTokenPair(ctx, Identity{UserID: userID, Username: username, Roles: []string{role}})
+}
+
+// IssueTokenPair 为身份信息签发令牌配对，开启一个新的令牌家族
+func (m *JWTManager) IssueTokenPair(ctx context.Context, id Identity) (*TokenPair, error) {
+	family, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	pair, refreshID, err := m.generateTokenPair(id, family)
+	if err != nil {
+		return nil, err
 	}
+
+	if m.store != nil {
+		if err := m.store.CreateFamily(ctx, id.subject(), family, refreshID, m.refreshTokenExpiry); err != nil {
+			return nil, err
+		}
+	}
+	if m.sessions != nil {
+		if err := m.startSession(ctx, id.subject(), family); err != nil {
+			return nil, err
+		}
+	}
+	return pair, nil
 }
 
-// GenerateTokenPair 生成新的访问令牌和刷新令牌配对
-func (m *JWTManager) GenerateTokenPair(userID uint64, username, role string) (*TokenPair, error) {
+// IssueChallengeToken 为通过密码校验、尚未完成两步验证的身份签发质询令牌
+func (m *JWTManager) IssueChallengeToken(id Identity) (string, error) {
+	token, _, err := m.generateToken(id, "", ChallengeToken)
+	if err != nil {
+		return "", fmt.Errorf("生成质询令牌失败: %w", err)
+	}
+	return token, nil
+}
+
+// generateTokenPair 签发属于 family 的令牌配对，返回刷新令牌的 jti
+func (m *JWTManager) generateTokenPair(id Identity, family string) (*TokenPair, string, error) {
 	// 生成访问令牌
-	accessToken, err := m.generateToken(userID, username, role, AccessToken)
+	accessToken, _, err := m.generateToken(id, family, AccessToken)
 	if err != nil {
-		return nil, fmt.Errorf("生成访问令牌失败: %w", err)
+		return nil, "", fmt.Errorf("生成访问令牌失败: %w", err)
 	}
 
 	// 生成刷新令牌
-	refreshToken, err := m.generateToken(userID, username, role, RefreshToken)
+	refreshToken, refreshID, err := m.generateToken(id, family, RefreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("生成刷新令牌失败: %w", err)
+		return nil, "", fmt.Errorf("生成刷新令牌失败: %w", err)
 	}
 
 	return &TokenPair{
@@ -93,42 +182,90 @@ func (m *JWTManager) GenerateTokenPair(userID uint64, username, role string) (*T
 		RefreshToken:        refreshToken,
 		AccessTokenExpires:  int64(m.accessTokenExpiry.Seconds()),
 		RefreshTokenExpires: int64(m.refreshTokenExpiry.Seconds()),
-	}, nil
+	}, refreshID, nil
 }
 
-// generateToken 生成一个新的JWT令牌
-func (m *JWTManager) generateToken(userID uint64, username, role string, tokenType TokenType) (string, error) {
+// generateToken 生成一个新的JWT令牌，返回令牌及其 jti
+func (m *JWTManager) generateToken(id Identity, family string, tokenType TokenType) (string, string, error) {
 	expiry := m.accessTokenExpiry
-	if tokenType == RefreshToken {
+	switch tokenType {
+	case RefreshToken:
 		expiry = m.refreshTokenExpiry
+	case ChallengeToken:
+		expiry = ChallengeTokenExpiry
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", "", err
 	}
 
 	now := time.Now()
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    m.issuer,
+			Subject:   id.subject(),
+			Audience:  m.audience,
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:   id.UserID,
+		Username: id.Username,
+		Roles:    id.Roles,
+		Scopes:   id.Scopes,
+		Extra:    id.Extra,
 		Type:     string(tokenType),
+		Family:   family,
+	}
+	if len(id.Roles) > 0 {
+		claims.Role = id.Roles[0]
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	key := m.keys.Current()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.Kid != "" {
+		token.Header["kid"] = key.Kid
+	}
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, jti, nil
+}
+
+// newTokenID 生成随机的令牌 ID
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成令牌ID失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // ValidateToken 验证JWT令牌并返回其声明内容
 func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	// 验证令牌
+	// 验证令牌，配置了签发者和受众时一并校验
+	opts := []jwt.ParserOption{jwt.WithLeeway(m.leeway)}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if len(m.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(m.audience...))
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+		// 算法必须与密钥一致，防止算法混淆攻击
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
 		}
-		return []byte(m.secretKey), nil
-	})
+		return key.verifyKey, nil
+	}, opts...)
 
 	if err != nil {
 		logger.ErrorLog("解析令牌失败", logger.ErrorField(err))
@@ -146,8 +283,37 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// VerifyToken 验证令牌签名、类型及吊销状态
+func (m *JWTManager) VerifyToken(ctx context.Context, tokenString string, tokenType TokenType) (*Claims, error) {
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != string(tokenType) {
+		return nil, ErrInvalidToken
+	}
+
+	if m.store != nil {
+		revoked, err := m.store.IsRevoked(ctx, claims.Family, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+	return claims, nil
+}
+
 // RefreshToken 验证刷新令牌并生成新的令牌配对
-func (m *JWTManager) RefreshToken(refreshToken string) (*TokenPair, error) {
+// 配置了令牌存储时刷新令牌只能使用一次：使用后轮换为新的刷新令牌，已使用的刷新令牌再次出现时吊销整个令牌家族。
+func (m *JWTManager) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	return m.RefreshTokenWith(ctx, refreshToken, nil)
+}
+
+// RefreshTokenWith 与 RefreshToken 相同，identity 不为 nil 时由其根据刷新令牌的声明生成新令牌的身份，例如切换租户
+// identity 返回错误时刷新令牌不会被使用；返回的身份不能更换令牌主体。
+func (m *JWTManager) RefreshTokenWith(ctx context.Context, refreshToken string, identity func(*Claims) (Identity, error)) (*TokenPair, error) {
 	// 验证刷新令牌
 	claims, err := m.ValidateToken(refreshToken)
 	if err != nil {
@@ -158,6 +324,197 @@ func (m *JWTManager) RefreshToken(refreshToken string) (*TokenPair, error) {
 		return nil, ErrInvalidToken
 	}
 
-	// 生成新的令牌对
-	return m.GenerateTokenPair(claims.UserID, claims.Username, claims.Role)
+	id := claims.Identity()
+	if identity != nil {
+		if id, err = identity(claims); err != nil {
+			return nil, err
+		}
+		if id.subject() != claims.Identity().subject() {
+			return nil, errors.New("刷新令牌不能更换令牌主体")
+		}
+	}
+
+	if m.store == nil {
+		return m.IssueTokenPair(ctx, id)
+	}
+
+	// 生成同一家族的新令牌对，并原子地替换家族的有效刷新令牌
+	pair, refreshID, err := m.generateTokenPair(id, claims.Family)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Rotate(ctx, claims.Family, claims.ID, refreshID, m.refreshTokenExpiry); err != nil {
+		if errors.Is(err, ErrTokenReused) {
+			logger.Warn("检测到刷新令牌重放，已吊销令牌家族",
+				logger.Any("user_id", claims.UserID),
+				logger.String("family", claims.Family))
+			// 家族已被吊销，同时删除对应的会话
+			if m.sessions != nil {
+				if err := m.sessions.Delete(ctx, claims.Subject, claims.Family); err != nil {
+					logger.Warn("删除被吊销家族的会话失败", logger.String("family", claims.Family), logger.ErrorField(err))
+				}
+			}
+		}
+		return nil, err
+	}
+	if m.sessions != nil {
+		m.touchSession(ctx, claims)
+	}
+	return pair, nil
+}
+
+// Revoke 吊销令牌及其所属的令牌家族，用于登出；已过期的令牌无需吊销
+func (m *JWTManager) Revoke(ctx context.Context, tokenString string) error {
+	if m.store == nil {
+		return ErrNoTokenStore
+	}
+	claims, err := m.ValidateToken(tokenString)
+	if errors.Is(err, ErrExpiredToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := m.store.Revoke(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		return err
+	}
+	if claims.Family == "" {
+		return nil
+	}
+	if err := m.store.RevokeFamily(ctx, claims.Family); err != nil {
+		return err
+	}
+	if m.sessions != nil {
+		return m.sessions.Delete(ctx, claims.Subject, claims.Family)
+	}
+	return nil
+}
+
+// RevokeAllForUser 吊销令牌主体的所有令牌并清除其会话，用于修改密码、禁用账号或令牌泄露时强制下线
+// subject 为签发时的令牌主体，如 admin:1，未指定主体签发的令牌为用户ID。
+func (m *JWTManager) RevokeAllForUser(ctx context.Context, subject string) error {
+	if m.store == nil {
+		return ErrNoTokenStore
+	}
+	if err := m.store.RevokeSubject(ctx, subject); err != nil {
+		return err
+	}
+	if m.sessions != nil {
+		return m.sessions.DeleteAll(ctx, subject)
+	}
+	return nil
+}
+
+// Sessions 获取主体的活跃会话，按最近活跃时间倒序
+func (m *JWTManager) Sessions(ctx context.Context, subject string) ([]*Session, error) {
+	if m.sessions == nil {
+		return nil, ErrNoSessionStore
+	}
+	sessions, err := m.sessions.List(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+// RevokeSession 吊销主体的指定会话，会话不属于该主体时返回 ErrSessionNotFound
+func (m *JWTManager) RevokeSession(ctx context.Context, subject, id string) error {
+	if m.sessions == nil {
+		return ErrNoSessionStore
+	}
+	session, err := m.sessions.Get(ctx, subject, id)
+	if err != nil {
+		return err
+	}
+	return m.revokeSession(ctx, session)
+}
+
+// RevokeAllSessions 吊销主体的全部会话
+func (m *JWTManager) RevokeAllSessions(ctx context.Context, subject string) error {
+	if m.sessions == nil {
+		return ErrNoSessionStore
+	}
+	sessions, err := m.sessions.List(ctx, subject)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := m.store.RevokeFamily(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	return m.sessions.DeleteAll(ctx, subject)
+}
+
+// startSession 记录新会话，超出并发会话数时先吊销最久未活跃的会话
+func (m *JWTManager) startSession(ctx context.Context, subject, family string) error {
+	now := time.Now()
+	session := &Session{
+		ID:         family,
+		Subject:    subject,
+		CreatedAt:  now,
+		LastActive: now,
+		ExpiresAt:  now.Add(m.refreshTokenExpiry),
+	}
+	if info, ok := ClientInfoFrom(ctx); ok {
+		session.Device, session.IP, session.UserAgent = info.Device, info.IP, info.UserAgent
+	}
+
+	if m.maxSessions > 0 {
+		sessions, err := m.sessions.List(ctx, subject)
+		if err != nil {
+			return err
+		}
+		if len(sessions) >= m.maxSessions {
+			sortSessions(sessions)
+			for _, old := range sessions[m.maxSessions-1:] {
+				if err := m.revokeSession(ctx, old); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return m.sessions.Save(ctx, session)
+}
+
+// touchSession 刷新令牌后更新会话的活跃时间与过期时间，失败时仅记录日志
+func (m *JWTManager) touchSession(ctx context.Context, claims *Claims) {
+	now := time.Now()
+	session, err := m.sessions.Get(ctx, claims.Subject, claims.Family)
+	if errors.Is(err, ErrSessionNotFound) {
+		// 启用会话存储前签发的令牌，补记会话
+		session, err = &Session{ID: claims.Family, Subject: claims.Subject, CreatedAt: now}, nil
+	}
+	if err != nil {
+		logger.Warn("Failed to load session", logger.String("family", claims.Family), logger.ErrorField(err))
+		return
+	}
+	session.LastActive = now
+	session.ExpiresAt = now.Add(m.refreshTokenExpiry)
+	if info, ok := ClientInfoFrom(ctx); ok {
+		session.IP, session.UserAgent = info.IP, info.UserAgent
+		if info.Device != "" {
+			session.Device = info.Device
+		}
+	}
+	if err := m.sessions.Save(ctx, session); err != nil {
+		logger.Warn("Failed to save session", logger.String("family", claims.Family), logger.ErrorField(err))
+	}
+}
+
+// revokeSession 吊销会话对应的令牌家族并删除会话
+func (m *JWTManager) revokeSession(ctx context.Context, session *Session) error {
+	if err := m.store.RevokeFamily(ctx, session.ID); err != nil {
+		return err
+	}
+	return m.sessions.Delete(ctx, session.Subject, session.ID)
+}
+
+// sortSessions 按最近活跃时间倒序排列会话
+func sortSessions(sessions []*Session) {
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		return b.LastActive.Compare(a.LastActive)
+	})
 }
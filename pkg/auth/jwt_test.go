@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/config"
+)
+
+// memoryTokenStore 测试用内存令牌存储，语义与 RedisTokenStore 一致（不处理过期）
+type memoryTokenStore struct {
+	mu       sync.Mutex
+	families map[string]string
+	subjects map[string][]string
+	denied   map[string]bool
+}
+
+func newMemoryTokenStore() *memoryTokenStore {
+	return &memoryTokenStore{
+		families: make(map[string]string),
+		subjects: make(map[string][]string),
+		denied:   make(map[string]bool),
+	}
+}
+
+func (s *memoryTokenStore) CreateFamily(_ context.Context, subject, family, jti string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[family] = jti
+	s.subjects[subject] = append(s.subjects[subject], family)
+	return nil
+}
+
+func (s *memoryTokenStore) Rotate(_ context.Context, family, jti, next string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.families[family]
+	if !ok {
+		return ErrRevokedToken
+	}
+	if current != jti {
+		delete(s.families, family)
+		return ErrTokenReused
+	}
+	s.families[family] = next
+	return nil
+}
+
+func (s *memoryTokenStore) IsRevoked(_ context.Context, family, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.denied[jti] {
+		return true, nil
+	}
+	_, ok := s.families[family]
+	return family != "" && !ok, nil
+}
+
+func (s *memoryTokenStore) Revoke(_ context.Context, jti string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied[jti] = true
+	return nil
+}
+
+func (s *memoryTokenStore) RevokeFamily(_ context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.families, family)
+	return nil
+}
+
+func (s *memoryTokenStore) RevokeSubject(_ context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, family := range s.subjects[subject] {
+		delete(s.families, family)
+	}
+	delete(s.subjects, subject)
+	return nil
+}
+
+// newTestJWTManager 创建带内存令牌存储的JWT管理器
+func newTestJWTManager() *JWTManager {
+	return NewJWTManager(&config.JWTConfig{
+		SecretKey:          "test-secret",
+		AccessTokenExpiry:  time.Minute,
+		RefreshTokenExpiry: time.Hour,
+	}, WithTokenStore(newMemoryTokenStore()))
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	m := newTestJWTManager()
+	ctx := context.Background()
+
+	pair, err := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	// 刷新令牌不能作为访问令牌使用
+	if _, err := m.VerifyToken(ctx, pair.RefreshToken, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken(refresh) error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	rotated, err := m.RefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	claims, err := m.VerifyToken(ctx, rotated.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if claims.UserID != 1 || claims.Family == "" || claims.ID == "" {
+		t.Errorf("VerifyToken() claims = %+v", claims)
+	}
+
+	// 重放已使用的刷新令牌时吊销整个家族，包括轮换后签发的令牌
+	if _, err := m.RefreshToken(ctx, pair.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("重放 RefreshToken() error = %v, want %v", err, ErrTokenReused)
+	}
+	if _, err := m.RefreshToken(ctx, rotated.RefreshToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("家族吊销后 RefreshToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := m.VerifyToken(ctx, rotated.AccessToken, AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("家族吊销后 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	m := newTestJWTManager()
+	ctx := context.Background()
+
+	first, _ := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+	second, _ := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+	other, _ := m.GenerateTokenPair(ctx, 2, "bob", "user")
+	// ID 相同的其他类账号使用不同的主体
+	sameID, _ := m.IssueTokenPair(ctx, Identity{UserID: 1, Subject: "user:1", Username: "carol"})
+
+	// 登出只影响当前令牌家族
+	if err := m.Revoke(ctx, first.AccessToken); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.VerifyToken(ctx, first.AccessToken, AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("Revoke() 后 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := m.RefreshToken(ctx, first.RefreshToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("Revoke() 后 RefreshToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := m.VerifyToken(ctx, second.AccessToken, AccessToken); err != nil {
+		t.Errorf("其他会话 VerifyToken() error = %v", err)
+	}
+
+	if err := m.RevokeAllForUser(ctx, "1"); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+	if _, err := m.VerifyToken(ctx, second.AccessToken, AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("RevokeAllForUser() 后 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := m.VerifyToken(ctx, other.AccessToken, AccessToken); err != nil {
+		t.Errorf("其他用户 VerifyToken() error = %v", err)
+	}
+	if _, err := m.VerifyToken(ctx, sameID.AccessToken, AccessToken); err != nil {
+		t.Errorf("ID 相同的其他主体 VerifyToken() error = %v", err)
+	}
+
+	// 未配置令牌存储时不支持吊销
+	plain := NewJWTManager(&config.JWTConfig{SecretKey: "test-secret", AccessTokenExpiry: time.Minute})
+	if err := plain.Revoke(ctx, first.AccessToken); !errors.Is(err, ErrNoTokenStore) {
+		t.Errorf("Revoke() error = %v, want %v", err, ErrNoTokenStore)
+	}
+}
+
+func TestClaimsValidation(t *testing.T) {
+	ctx := context.Background()
+	base := config.JWTConfig{
+		SecretKey:          "test-secret",
+		AccessTokenExpiry:  time.Minute,
+		RefreshTokenExpiry: time.Hour,
+		Issuer:             "fiber_web",
+		Audience:           []string{"admin"},
+	}
+	issuer := NewJWTManager(&base)
+	pair, err := issuer.GenerateTokenPair(ctx, 1, "alice", "admin")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	expiredCfg := base
+	expiredCfg.AccessTokenExpiry = -10 * time.Second
+	expired, _ := NewJWTManager(&expiredCfg).GenerateTokenPair(ctx, 1, "alice", "admin")
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *config.JWTConfig)
+		token   string
+		wantErr error
+	}{
+		{"签发者与受众匹配", func(cfg *config.JWTConfig) {}, pair.AccessToken, nil},
+		{"受众之一匹配", func(cfg *config.JWTConfig) { cfg.Audience = []string{"other", "admin"} }, pair.AccessToken, nil},
+		{"签发者不匹配", func(cfg *config.JWTConfig) { cfg.Issuer = "other" }, pair.AccessToken, ErrInvalidToken},
+		{"受众不匹配", func(cfg *config.JWTConfig) { cfg.Audience = []string{"api"} }, pair.AccessToken, ErrInvalidToken},
+		{"超过时钟偏差", func(cfg *config.JWTConfig) {}, expired.AccessToken, ErrExpiredToken},
+		{"时钟偏差内", func(cfg *config.JWTConfig) { cfg.Leeway = 30 * time.Second }, expired.AccessToken, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+			_, err := NewJWTManager(&cfg).VerifyToken(ctx, tt.token, AccessToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaimsPayload(t *testing.T) {
+	m := newTestJWTManager()
+	ctx := context.Background()
+
+	pair, err := m.IssueTokenPair(ctx, Identity{
+		UserID:   1,
+		Username: "alice",
+		Roles:    []string{"editor", "auditor"},
+		Scopes:   []string{"menu:read"},
+		Extra:    map[string]any{"tenant_id": 42, "dept": map[string]any{"name": "dev"}},
+	})
+	if err != nil {
+		t.Fatalf("IssueTokenPair() error = %v", err)
+	}
+
+	// 刷新后保留全部声明
+	pair, err = m.RefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	claims, err := m.VerifyToken(ctx, pair.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+
+	if claims.Role != "editor" || !claims.HasRole("auditor") || !claims.HasAnyRole("admin", "editor") || claims.HasRole("admin") {
+		t.Errorf("角色声明 = %q %v", claims.Role, claims.Roles)
+	}
+	if !claims.HasScope("menu:read") || claims.HasScope("menu:write") {
+		t.Errorf("授权范围声明 = %v", claims.Scopes)
+	}
+	if tenant, ok := ExtraValue[int64](claims, "tenant_id"); !ok || tenant != 42 {
+		t.Errorf("ExtraValue(tenant_id) = %v, %v", tenant, ok)
+	}
+	type dept struct{ Name string }
+	if d, ok := ExtraValue[dept](claims, "dept"); !ok || d.Name != "dev" {
+		t.Errorf("ExtraValue(dept) = %+v, %v", d, ok)
+	}
+	if _, ok := ExtraValue[string](claims, "missing"); ok {
+		t.Error("ExtraValue() 不存在的键应返回 false")
+	}
+
+	// 只有 Role 的旧令牌
+	legacy := &Claims{Role: "admin"}
+	if roles := legacy.AllRoles(); len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("AllRoles() = %v, want [admin]", roles)
+	}
+}
+
+func TestChallengeToken(t *testing.T) {
+	m := newTestJWTManager()
+	ctx := context.Background()
+
+	challenge, err := m.IssueChallengeToken(Identity{UserID: 1, Username: "alice", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("IssueChallengeToken() error = %v", err)
+	}
+
+	// 质询令牌不能作为访问令牌或刷新令牌使用
+	if _, err := m.VerifyToken(ctx, challenge, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken(access) error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := m.RefreshToken(ctx, challenge); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RefreshToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	claims, err := m.VerifyToken(ctx, challenge, ChallengeToken)
+	if err != nil {
+		t.Fatalf("VerifyToken(challenge) error = %v", err)
+	}
+	if claims.UserID != 1 || !claims.HasRole("admin") || claims.ExpiresAt.Sub(claims.IssuedAt.Time) != ChallengeTokenExpiry {
+		t.Errorf("VerifyToken(challenge) claims = %+v", claims)
+	}
+
+	// 吊销后不能再次使用
+	if err := m.Revoke(ctx, challenge); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.VerifyToken(ctx, challenge, ChallengeToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("Revoke() 后 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+}
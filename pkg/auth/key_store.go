@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fiber_web/pkg/redis"
+	"fiber_web/pkg/security"
+	"fmt"
+	"time"
+)
+
+// StoredKey 共享存储中的签名密钥
+type StoredKey struct {
+	Kid        string    `json:"kid"`
+	Algorithm  string    `json:"alg"`
+	PrivateKey string    `json:"key"`       // PKCS#8 PEM 格式私钥
+	ActiveAt   time.Time `json:"active_at"` // 开始用于签名的时间
+}
+
+// KeyStore 签名密钥的共享存储，多实例自动轮换时通过它使用相同的密钥
+type KeyStore interface {
+	// Load 读取全部密钥，按 ActiveAt 升序排列，没有密钥时返回空
+	Load(ctx context.Context) ([]StoredKey, error)
+	// Save 仅当最新密钥的 kid 仍为 last 时替换全部密钥，last 为空表示存储中还没有密钥；其他实例已先写入时返回 false
+	Save(ctx context.Context, last string, keys []StoredKey) (bool, error)
+}
+
+// storeKey 将签名密钥编码为可共享的密钥
+func storeKey(key *SigningKey, activeAt time.Time) (StoredKey, error) {
+	signer, ok := key.signKey.(crypto.Signer)
+	if !ok {
+		return StoredKey{}, fmt.Errorf("密钥 %s 不是非对称密钥，无法共享", key.Kid)
+	}
+	data, err := security.EncodePKCS8PrivateKeyToPEM(signer)
+	if err != nil {
+		return StoredKey{}, fmt.Errorf("编码密钥 %s 失败: %w", key.Kid, err)
+	}
+	return StoredKey{Kid: key.Kid, Algorithm: key.Method.Alg(), PrivateKey: string(data), ActiveAt: activeAt}, nil
+}
+
+// signingKey 还原为签名密钥
+func (k StoredKey) signingKey() (*SigningKey, error) {
+	signer, err := security.ParseSignerFromPEM([]byte(k.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥 %s 失败: %w", k.Kid, err)
+	}
+	return NewSigningKey(k.Kid, k.Algorithm, signer)
+}
+
+// saveKeysScript 比较最新密钥的 kid 后写入全部密钥：1 成功，0 已被其他实例修改
+const saveKeysScript = `
+local last = redis.call('HGET', KEYS[1], 'last') or ''
+if last ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'last', ARGV[2], 'keys', ARGV[3])
+return 1
+`
+
+// RedisKeyStore 基于 Redis 的签名密钥存储
+// 键 jwt:keys 为哈希，last 为最新密钥的 kid，keys 为全部密钥的 JSON。私钥以明文保存，Redis 需与签发服务处于同一信任域。
+type RedisKeyStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisKeyStore 创建 Redis 签名密钥存储
+func NewRedisKeyStore(client *redis.Client) *RedisKeyStore {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisKeyStore{client: client, key: "jwt:keys"}
+}
+
+func (s *RedisKeyStore) Load(ctx context.Context) ([]StoredKey, error) {
+	var keys []StoredKey
+	if err := s.client.HGet(ctx, s.key, "keys", &keys); err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *RedisKeyStore) Save(ctx context.Context, last string, keys []StoredKey) (bool, error) {
+	if len(keys) == 0 {
+		return false, errors.New("keys cannot be empty")
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return false, err
+	}
+	n, err := s.client.Eval(ctx, saveKeysScript, []string{s.key}, last, keys[len(keys)-1].Kid, string(data)).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,390 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/logger"
+	"fiber_web/pkg/security"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var ErrUnknownKey = errors.New("未知的签名密钥")
+
+// SigningKey JWT 签名密钥
+type SigningKey struct {
+	Kid    string            // 密钥 ID，为空时令牌头部不写入 kid
+	Method jwt.SigningMethod // 签名算法
+
+	signKey   any       // 签名使用的密钥，HMAC 为 []byte，其余为私钥
+	verifyKey any       // 验证使用的密钥，HMAC 为 []byte，其余为公钥
+	expiresAt time.Time // 轮换退役后停止验证的时间，零值表示未退役
+}
+
+// NewHMACKey 创建 HS256 对称密钥，不会出现在 JWKS 中
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{Kid: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewSigningKey 使用私钥创建非对称签名密钥，私钥类型需与算法匹配
+func NewSigningKey(kid, alg string, signer crypto.Signer) (*SigningKey, error) {
+	var method jwt.SigningMethod
+	switch key := signer.(type) {
+	case *rsa.PrivateKey:
+		if alg == AlgRS256 {
+			method = jwt.SigningMethodRS256
+		}
+	case *ecdsa.PrivateKey:
+		if alg == AlgES256 && key.Curve == elliptic.P256() {
+			method = jwt.SigningMethodES256
+		}
+	case ed25519.PrivateKey:
+		if alg == AlgEdDSA {
+			method = jwt.SigningMethodEdDSA
+		}
+	}
+	if method == nil {
+		return nil, fmt.Errorf("密钥 %s 的类型 %T 与算法 %s 不匹配", kid, signer, alg)
+	}
+	return &SigningKey{Kid: kid, Method: method, signKey: signer, verifyKey: signer.Public()}, nil
+}
+
+// GenerateSigningKey 生成指定算法的新密钥，kid 随机生成
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	kid, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	switch alg {
+	case AlgRS256:
+		signer, _, err = security.GenerateRSAKeyPair(2048)
+	case AlgES256:
+		signer, err = security.GenerateECDSAKey()
+	case AlgEdDSA:
+		signer, err = security.GenerateEd25519Key()
+	default:
+		return nil, fmt.Errorf("不支持生成 %s 密钥", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return NewSigningKey(kid, alg, signer)
+}
+
+// KeySet 签名密钥集合
+// 当前密钥用于签名，其余密钥仅用于验证；令牌头部的 kid 决定验证使用的密钥。
+type KeySet struct {
+	mu       sync.RWMutex
+	current  *SigningKey
+	keys     map[string]*SigningKey
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewKeySet 创建密钥集合，current 为签名密钥，others 仅用于验证
+func NewKeySet(current *SigningKey, others ...*SigningKey) *KeySet {
+	s := &KeySet{
+		current: current,
+		keys:    make(map[string]*SigningKey, len(others)+1),
+		stop:    make(chan struct{}),
+	}
+	for _, key := range others {
+		s.keys[key.Kid] = key
+	}
+	s.keys[current.Kid] = current
+	return s
+}
+
+// LoadKeySet 根据配置加载密钥集合，未配置非对称密钥时使用 secret_key 进行 HS256 签名
+func LoadKeySet(cfg *config.JWTConfig) (*KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		return NewKeySet(NewHMACKey("", []byte(cfg.SecretKey))), nil
+	}
+
+	keys := make([]*SigningKey, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.Kid == "" {
+			return nil, errors.New("JWT 密钥的 kid 不能为空")
+		}
+		data := []byte(keyCfg.PrivateKey)
+		if len(data) == 0 {
+			var err error
+			if data, err = os.ReadFile(keyCfg.PrivateKeyFile); err != nil {
+				return nil, fmt.Errorf("读取密钥 %s 失败: %w", keyCfg.Kid, err)
+			}
+		}
+		signer, err := security.ParseSignerFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析密钥 %s 失败: %w", keyCfg.Kid, err)
+		}
+		key, err := NewSigningKey(keyCfg.Kid, keyCfg.Algorithm, signer)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(keys[0], keys[1:]...), nil
+}
+
+// Current 获取当前签名密钥
+func (s *KeySet) Current() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Lookup 根据 kid 获取验证密钥，已过宽限期的密钥视为不存在
+func (s *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	if !ok || (!key.expiresAt.IsZero() && time.Now().After(key.expiresAt)) {
+		return nil, false
+	}
+	return key, true
+}
+
+// Rotate 切换签名密钥，原签名密钥在 grace 时间内继续用于验证
+func (s *KeySet) Rotate(next *SigningKey, grace time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.current != nil && s.current.Kid != next.Kid {
+		s.current.expiresAt = now.Add(grace)
+	}
+	// 清理已过宽限期的密钥
+	for kid, key := range s.keys {
+		if !key.expiresAt.IsZero() && now.After(key.expiresAt) {
+			delete(s.keys, kid)
+		}
+	}
+	s.current = next
+	s.keys[next.Kid] = next
+}
+
+// keySyncInterval 自动轮换时从共享存储同步密钥的最长间隔
+const keySyncInterval = time.Minute
+
+// StartRotation 按 interval 定期生成与当前密钥同算法的新密钥并轮换
+// 密钥通过 store 在多个实例间共享：各实例定期同步，新密钥在一个同步周期后才开始签名，保证所有实例都已能验证。
+// store 为 nil 时不启动轮换，否则各实例各自生成的密钥无法相互验证。
+func (s *KeySet) StartRotation(interval, grace time.Duration, store KeyStore) {
+	if interval <= 0 {
+		return
+	}
+	if _, ok := s.Current().verifyKey.([]byte); ok {
+		logger.Warn("JWT key rotation requires an asymmetric signing key, skipped")
+		return
+	}
+	if store == nil {
+		logger.Warn("JWT key rotation requires a shared key store, skipped")
+		return
+	}
+	delay := min(keySyncInterval, interval)
+	sync := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.syncKeys(ctx, store, interval, grace, delay); err != nil {
+			logger.ErrorLog("Failed to sync JWT signing keys", logger.ErrorField(err))
+		}
+	}
+	sync()
+	go func() {
+		ticker := time.NewTicker(delay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sync()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// syncKeys 从共享存储同步密钥，存储为空时写入当前密钥，最新密钥启用超过 interval 时生成新密钥
+// 新密钥在 delay 之后开始签名；多个实例同时轮换时只有一个写入成功，其余使用其写入的密钥。
+func (s *KeySet) syncKeys(ctx context.Context, store KeyStore, interval, grace, delay time.Duration) error {
+	stored, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	last := ""
+	if len(stored) > 0 {
+		last = stored[len(stored)-1].Kid
+	}
+
+	if last == "" || now.Sub(stored[len(stored)-1].ActiveAt) >= interval {
+		var next StoredKey
+		if last == "" {
+			next, err = storeKey(s.Current(), now)
+		} else {
+			var key *SigningKey
+			if key, err = GenerateSigningKey(s.Current().Method.Alg()); err == nil {
+				next, err = storeKey(key, now.Add(delay))
+			}
+		}
+		if err != nil {
+			return err
+		}
+		keys := append(pruneStoredKeys(stored, now, grace), next)
+		saved, err := store.Save(ctx, last, keys)
+		if err != nil {
+			return err
+		}
+		if saved {
+			stored = keys
+			logger.Info("JWT signing key published", logger.String("kid", next.Kid), logger.Any("active_at", next.ActiveAt))
+		} else if stored, err = store.Load(ctx); err != nil {
+			return err
+		}
+	}
+	return s.applyStoredKeys(stored, now, grace)
+}
+
+// pruneStoredKeys 去掉已被替换且超过宽限期的密钥
+func pruneStoredKeys(stored []StoredKey, now time.Time, grace time.Duration) []StoredKey {
+	kept := make([]StoredKey, 0, len(stored)+1)
+	for i, key := range stored {
+		if i == len(stored)-1 || stored[i+1].ActiveAt.Add(grace).After(now) {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// applyStoredKeys 使用共享存储中的密钥，已启用的最新密钥用于签名，被替换的密钥在宽限期内继续用于验证
+func (s *KeySet) applyStoredKeys(stored []StoredKey, now time.Time, grace time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *SigningKey
+	shared := make(map[string]bool, len(stored))
+	for i, sk := range stored {
+		key, ok := s.keys[sk.Kid]
+		if !ok {
+			var err error
+			if key, err = sk.signingKey(); err != nil {
+				return err
+			}
+			s.keys[sk.Kid] = key
+		}
+		shared[sk.Kid] = true
+		if i+1 < len(stored) {
+			key.expiresAt = stored[i+1].ActiveAt.Add(grace)
+		}
+		if !sk.ActiveAt.After(now) {
+			current = key
+		}
+	}
+	if current != nil && current != s.current {
+		// 本地配置中未共享的签名密钥同样在宽限期内继续用于验证
+		if !shared[s.current.Kid] {
+			s.current.expiresAt = now.Add(grace)
+		}
+		s.current = current
+	}
+	for kid, key := range s.keys {
+		if !key.expiresAt.IsZero() && now.After(key.expiresAt) {
+			delete(s.keys, kid)
+		}
+	}
+	return nil
+}
+
+// Stop 停止自动轮换
+func (s *KeySet) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// JWK JSON Web Key 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 导出所有有效的非对称公钥，对称密钥不会导出
+func (s *KeySet) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	set := JWKSet{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		if !key.expiresAt.IsZero() && now.After(key.expiresAt) {
+			continue
+		}
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// jwk 将公钥编码为 JWK
+func (k *SigningKey) jwk() (JWK, bool) {
+	enc := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Kid: k.Kid, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc(pub.N.Bytes())
+		jwk.E = enc(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// 未压缩格式为 0x04 || X || Y
+		point, err := pub.Bytes()
+		if err != nil {
+			return JWK{}, false
+		}
+		size := (len(point) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc(point[1 : 1+size])
+		jwk.Y = enc(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/security"
+)
+
+// newPEMSigningKey 生成私钥并经 PEM 编码、解析后创建签名密钥
+func newPEMSigningKey(t *testing.T, kid, alg string) *SigningKey {
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch alg {
+	case AlgRS256:
+		signer, _, err = security.GenerateRSAKeyPair(2048)
+	case AlgES256:
+		signer, err = security.GenerateECDSAKey()
+	case AlgEdDSA:
+		signer, err = security.GenerateEd25519Key()
+	}
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	data, err := security.EncodePKCS8PrivateKeyToPEM(signer)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	parsed, err := security.ParseSignerFromPEM(data)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	key, err := NewSigningKey(kid, alg, parsed)
+	if err != nil {
+		t.Fatalf("NewSigningKey() error = %v", err)
+	}
+	return key
+}
+
+// newKeySetManager 创建使用指定密钥集合的JWT管理器
+func newKeySetManager(keys *KeySet) *JWTManager {
+	return NewJWTManager(&config.JWTConfig{AccessTokenExpiry: time.Minute, RefreshTokenExpiry: time.Hour}, WithKeySet(keys))
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	ctx := context.Background()
+	for _, alg := range []string{AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			key := newPEMSigningKey(t, "k-"+alg, alg)
+			m := newKeySetManager(NewKeySet(key))
+
+			pair, err := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+			if err != nil {
+				t.Fatalf("GenerateTokenPair() error = %v", err)
+			}
+			if _, err := m.VerifyToken(ctx, pair.AccessToken, AccessToken); err != nil {
+				t.Errorf("VerifyToken() error = %v", err)
+			}
+
+			jwks := m.Keys().JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != key.Kid || jwks.Keys[0].Alg != alg {
+				t.Errorf("JWKS() = %+v", jwks)
+			}
+
+			// 其他密钥集合无法验证
+			other := newKeySetManager(NewKeySet(newPEMSigningKey(t, "k-"+alg, alg)))
+			if _, err := other.VerifyToken(ctx, pair.AccessToken, AccessToken); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("其他密钥 VerifyToken() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+
+	if _, err := NewSigningKey("bad", AlgES256, newPEMSigningKey(t, "rsa", AlgRS256).signKey.(crypto.Signer)); err == nil {
+		t.Error("NewSigningKey() 密钥类型与算法不匹配时应返回错误")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := newPEMSigningKey(t, "old", AlgES256)
+	keys := NewKeySet(oldKey)
+	m := newKeySetManager(keys)
+
+	oldPair, _ := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+
+	// 宽限期内旧令牌仍可验证，新令牌使用新密钥签名
+	keys.Rotate(newPEMSigningKey(t, "new", AlgEdDSA), time.Hour)
+	if _, err := m.VerifyToken(ctx, oldPair.AccessToken, AccessToken); err != nil {
+		t.Errorf("宽限期内 VerifyToken() error = %v", err)
+	}
+	newPair, _ := m.GenerateTokenPair(ctx, 1, "alice", "admin")
+	if _, err := m.VerifyToken(ctx, newPair.AccessToken, AccessToken); err != nil {
+		t.Errorf("新密钥 VerifyToken() error = %v", err)
+	}
+	if got := len(keys.JWKS().Keys); got != 2 {
+		t.Errorf("宽限期内 JWKS() 密钥数 = %d, want 2", got)
+	}
+
+	// 宽限期结束后旧密钥失效
+	keys.Rotate(newPEMSigningKey(t, "newer", AlgES256), -time.Second)
+	oldKey.expiresAt = time.Now().Add(-time.Second)
+	if _, err := m.VerifyToken(ctx, oldPair.AccessToken, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("宽限期后 VerifyToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := m.VerifyToken(ctx, newPair.AccessToken, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("宽限期为负时 VerifyToken() error = %v, want %v", err, ErrInvalidToken)
+	}
+	if jwks := keys.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "newer" {
+		t.Errorf("宽限期后 JWKS() = %+v", jwks)
+	}
+}
+
+// memoryKeyStore 测试用内存签名密钥存储
+type memoryKeyStore struct {
+	keys []StoredKey
+}
+
+func (s *memoryKeyStore) Load(context.Context) ([]StoredKey, error) {
+	return slices.Clone(s.keys), nil
+}
+
+func (s *memoryKeyStore) Save(_ context.Context, last string, keys []StoredKey) (bool, error) {
+	current := ""
+	if len(s.keys) > 0 {
+		current = s.keys[len(s.keys)-1].Kid
+	}
+	if current != last {
+		return false, nil
+	}
+	s.keys = slices.Clone(keys)
+	return true, nil
+}
+
+func TestSharedKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryKeyStore{}
+	a := NewKeySet(newPEMSigningKey(t, "a", AlgES256))
+	b := NewKeySet(newPEMSigningKey(t, "b", AlgES256))
+	ma, mb := newKeySetManager(a), newKeySetManager(b)
+
+	// verify 检查 from 签发的令牌能否被 to 验证
+	verify := func(name string, from, to *JWTManager, want bool) {
+		t.Helper()
+		pair, err := from.GenerateTokenPair(ctx, 1, "alice", "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := to.VerifyToken(ctx, pair.AccessToken, AccessToken); (err == nil) != want {
+			t.Errorf("%s: VerifyToken() error = %v, want ok = %v", name, err, want)
+		}
+	}
+
+	// 首个实例写入自己的密钥，其余实例改用共享密钥
+	if err := a.syncKeys(ctx, store, time.Hour, time.Hour, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	bToken, _ := mb.GenerateTokenPair(ctx, 1, "alice", "admin")
+	if err := b.syncKeys(ctx, store, time.Hour, time.Hour, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if a.Current().Kid != "a" || b.Current().Kid != "a" {
+		t.Fatalf("同步后签名密钥 = %s, %s, want a", a.Current().Kid, b.Current().Kid)
+	}
+	verify("共享密钥", mb, ma, true)
+	if _, err := mb.VerifyToken(ctx, bToken.AccessToken, AccessToken); err != nil {
+		t.Errorf("本地旧密钥宽限期内 VerifyToken() error = %v", err)
+	}
+
+	// 轮换生成的新密钥延迟启用，其他实例同步后即可验证
+	if err := a.syncKeys(ctx, store, time.Nanosecond, time.Hour, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.keys) != 2 || a.Current().Kid != "a" {
+		t.Fatalf("延迟启用前 store = %d 个密钥, 签名密钥 = %s", len(store.keys), a.Current().Kid)
+	}
+	next := store.keys[1].Kid
+	if err := b.syncKeys(ctx, store, time.Hour, time.Hour, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Lookup(next); !ok {
+		t.Error("同步后应能验证尚未启用的新密钥")
+	}
+
+	// 启用后两个实例使用同一个新密钥签名，旧密钥在宽限期内仍可验证
+	store.keys[1].ActiveAt = time.Now()
+	for _, s := range []*KeySet{a, b} {
+		if err := s.syncKeys(ctx, store, time.Hour, time.Hour, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if s.Current().Kid != next {
+			t.Errorf("启用后签名密钥 = %s, want %s", s.Current().Kid, next)
+		}
+	}
+	verify("轮换后", ma, mb, true)
+	verify("轮换后", mb, ma, true)
+	if _, ok := a.Lookup("a"); !ok {
+		t.Error("宽限期内旧密钥应继续用于验证")
+	}
+
+	// 同时轮换时只有一个实例写入成功
+	store.keys[1].ActiveAt = time.Now().Add(-2 * time.Hour)
+	stale := slices.Clone(store.keys)
+	if err := a.syncKeys(ctx, store, time.Hour, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Save(ctx, stale[len(stale)-1].Kid, stale); ok {
+		t.Error("最新密钥已变化时 Save() 应返回 false")
+	}
+	if err := b.syncKeys(ctx, store, time.Hour, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	if a.Current().Kid != b.Current().Kid || a.Current().Kid == next {
+		t.Errorf("并发轮换后签名密钥 = %s, %s", a.Current().Kid, b.Current().Kid)
+	}
+	// 旧密钥 a 已被替换超过宽限期，从存储中清理
+	if len(store.keys) != 2 || store.keys[0].Kid != next {
+		t.Errorf("清理后 store = %+v", store.keys)
+	}
+}
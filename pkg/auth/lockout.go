@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts 默认触发锁定的连续失败次数
+	DefaultMaxAttempts = 5
+	// DefaultFailureWindow 默认失败次数统计窗口
+	DefaultFailureWindow = 15 * time.Minute
+	// DefaultBaseLockout 默认首次锁定时长
+	DefaultBaseLockout = time.Minute
+	// DefaultMaxLockout 默认最长锁定时长
+	DefaultMaxLockout = time.Hour
+)
+
+// LockoutCache 登录锁定使用的缓存接口，*redis.Client 已实现
+type LockoutCache interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Incr(ctx context.Context, key string) (int64, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// LockoutOptions 登录锁定配置
+type LockoutOptions struct {
+	MaxAttempts int           // 触发锁定的连续失败次数，默认 DefaultMaxAttempts
+	Window      time.Duration // 失败次数统计窗口，默认 DefaultFailureWindow
+	BaseLockout time.Duration // 首次锁定时长，此后每次锁定翻倍，默认 DefaultBaseLockout
+	MaxLockout  time.Duration // 最长锁定时长，默认 DefaultMaxLockout
+}
+
+// LoginLimiter 登录失败计数与渐进式锁定
+// 窗口内连续失败 MaxAttempts 次后锁定，锁定时长随锁定次数翻倍；登录成功后清除记录。
+// 键格式：login:fail:{subject} 失败次数，login:lock:{subject} 锁定标记，login:locks:{subject} 锁定次数。
+type LoginLimiter struct {
+	cache LockoutCache
+	opts  LockoutOptions
+}
+
+// NewLoginLimiter 创建登录锁定器
+func NewLoginLimiter(cache LockoutCache, opts LockoutOptions) *LoginLimiter {
+	if cache == nil {
+		panic("cache cannot be nil")
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultFailureWindow
+	}
+	if opts.BaseLockout <= 0 {
+		opts.BaseLockout = DefaultBaseLockout
+	}
+	if opts.MaxLockout < opts.BaseLockout {
+		opts.MaxLockout = max(DefaultMaxLockout, opts.BaseLockout)
+	}
+	return &LoginLimiter{cache: cache, opts: opts}
+}
+
+// Locked 获取剩余锁定时间，未锁定时返回 0
+func (l *LoginLimiter) Locked(ctx context.Context, subject string) (time.Duration, error) {
+	ttl, err := l.cache.TTL(ctx, "login:lock:"+subject)
+	if err != nil {
+		return 0, err
+	}
+	// 键不存在时 TTL 为负数
+	return max(ttl, 0), nil
+}
+
+// Fail 记录一次失败，达到阈值时锁定并返回锁定时长
+func (l *LoginLimiter) Fail(ctx context.Context, subject string) (time.Duration, error) {
+	failKey := "login:fail:" + subject
+	failures, err := l.cache.Incr(ctx, failKey)
+	if err != nil {
+		return 0, err
+	}
+	if failures == 1 {
+		if err := l.cache.Expire(ctx, failKey, l.opts.Window); err != nil {
+			return 0, err
+		}
+	}
+	if failures < int64(l.opts.MaxAttempts) {
+		return 0, nil
+	}
+
+	// 锁定次数保留到最长锁定时长之后，期间再次锁定时长翻倍
+	locksKey := "login:locks:" + subject
+	locks, err := l.cache.Incr(ctx, locksKey)
+	if err != nil {
+		return 0, err
+	}
+	lockout := l.opts.BaseLockout
+	for i := int64(1); i < locks && lockout < l.opts.MaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, l.opts.MaxLockout)
+	if err := l.cache.Expire(ctx, locksKey, l.opts.MaxLockout+l.opts.Window); err != nil {
+		return 0, err
+	}
+	if err := l.cache.Set(ctx, "login:lock:"+subject, locks, lockout); err != nil {
+		return 0, err
+	}
+	return lockout, l.cache.Delete(ctx, failKey)
+}
+
+// Reset 登录成功后清除失败与锁定记录
+func (l *LoginLimiter) Reset(ctx context.Context, subject string) error {
+	return l.cache.Delete(ctx, "login:fail:"+subject, "login:locks:"+subject)
+}
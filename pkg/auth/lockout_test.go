@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLockoutCache 测试用内存缓存，只记录过期时长而不真正过期
+type memoryLockoutCache struct {
+	mu      sync.Mutex
+	values  map[string]int64
+	expires map[string]time.Duration
+}
+
+func newMemoryLockoutCache() *memoryLockoutCache {
+	return &memoryLockoutCache{
+		values:  make(map[string]int64),
+		expires: make(map[string]time.Duration),
+	}
+}
+
+func (c *memoryLockoutCache) Set(_ context.Context, key string, value interface{}, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := value.(int64)
+	c.values[key] = n
+	c.expires[key] = expiration
+	return nil
+}
+
+func (c *memoryLockoutCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.values, key)
+		delete(c.expires, key)
+	}
+	return nil
+}
+
+func (c *memoryLockoutCache) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key]++
+	return c.values[key], nil
+}
+
+func (c *memoryLockoutCache) Expire(_ context.Context, key string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires[key] = expiration
+	return nil
+}
+
+func (c *memoryLockoutCache) TTL(_ context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.values[key]; !ok {
+		return -2, nil
+	}
+	return c.expires[key], nil
+}
+
+// unlock 模拟锁定到期
+func (c *memoryLockoutCache) unlock(subject string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, "login:lock:"+subject)
+}
+
+func TestLoginLimiter(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryLockoutCache()
+	limiter := NewLoginLimiter(cache, LockoutOptions{
+		MaxAttempts: 3,
+		BaseLockout: time.Minute,
+		MaxLockout:  3 * time.Minute,
+	})
+
+	// failUntilLocked 连续失败直到触发锁定，返回锁定时长
+	failUntilLocked := func() time.Duration {
+		t.Helper()
+		for i := 1; i <= 3; i++ {
+			lockout, err := limiter.Fail(ctx, "alice")
+			if err != nil {
+				t.Fatalf("Fail() error = %v", err)
+			}
+			if i < 3 && lockout != 0 {
+				t.Fatalf("第 %d 次失败 Fail() = %v, want 0", i, lockout)
+			}
+			if i == 3 {
+				return lockout
+			}
+		}
+		return 0
+	}
+
+	if remaining, err := limiter.Locked(ctx, "alice"); err != nil || remaining != 0 {
+		t.Fatalf("Locked() = %v, %v, want 0", remaining, err)
+	}
+
+	// 锁定时长逐次翻倍，不超过最长锁定时长
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		if lockout := failUntilLocked(); lockout != want {
+			t.Errorf("第 %d 次锁定时长 = %v, want %v", i+1, lockout, want)
+		}
+		if remaining, _ := limiter.Locked(ctx, "alice"); remaining != want {
+			t.Errorf("第 %d 次锁定 Locked() = %v, want %v", i+1, remaining, want)
+		}
+		cache.unlock("alice")
+	}
+
+	// 其他账号不受影响
+	if remaining, _ := limiter.Locked(ctx, "bob"); remaining != 0 {
+		t.Errorf("其他账号 Locked() = %v, want 0", remaining)
+	}
+
+	// 登录成功后重新从首次锁定时长开始
+	if err := limiter.Reset(ctx, "alice"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if lockout := failUntilLocked(); lockout != time.Minute {
+		t.Errorf("Reset() 后锁定时长 = %v, want %v", lockout, time.Minute)
+	}
+}
@@ -0,0 +1,446 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcStateTTL 授权请求的有效期，超时后回调失败
+	oidcStateTTL = 10 * time.Minute
+	// oidcJWKSMinRefresh 遇到未知 kid 时重新获取 JWKS 的最短间隔，防止被伪造的 kid 放大请求
+	oidcJWKSMinRefresh = time.Minute
+)
+
+var (
+	ErrInvalidOIDCState = errors.New("无效或已过期的登录状态")
+	ErrInvalidIDToken   = errors.New("无效的ID令牌")
+	ErrOIDCExchange     = errors.New("授权码换取令牌失败")
+)
+
+// OIDCState 授权请求的一次性状态，回调时取出校验
+type OIDCState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	Tenant       string `json:"tenant,omitempty"` // 发起登录时选择的租户
+}
+
+// OIDCStateStore 授权状态存储
+type OIDCStateStore interface {
+	// Save 保存状态
+	Save(ctx context.Context, state string, data *OIDCState, ttl time.Duration) error
+	// Take 取出并删除状态，不存在时返回 ErrInvalidOIDCState
+	Take(ctx context.Context, state string) (*OIDCState, error)
+}
+
+// OIDCDiscovery OpenID Provider 元数据
+type OIDCDiscovery struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	UserinfoEndpoint              string   `json:"userinfo_endpoint,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// OIDCIdentity 从 ID 令牌中获取的身份信息
+type OIDCIdentity struct {
+	Subject           string        // 身份提供方的用户标识 sub
+	Email             string        // 邮箱
+	EmailVerified     bool          // 邮箱是否已验证
+	Name              string        // 姓名
+	PreferredUsername string        // 用户名
+	Groups            []string      // RoleClaim 声明中的组或角色
+	Claims            jwt.MapClaims // 全部声明
+	Tenant            string        // 发起登录时选择的租户，来自授权状态而非 ID 令牌
+}
+
+// OIDCOption OIDC 客户端配置项
+type OIDCOption func(*OIDCProvider)
+
+// WithOIDCHTTPClient 设置访问身份提供方使用的 HTTP 客户端
+func WithOIDCHTTPClient(client *http.Client) OIDCOption {
+	return func(p *OIDCProvider) {
+		p.httpClient = client
+	}
+}
+
+// OIDCProvider OIDC 依赖方，实现授权码 + PKCE 流程
+type OIDCProvider struct {
+	cfg        *config.OIDCConfig
+	states     OIDCStateStore
+	httpClient *http.Client
+	discovery  *OIDCDiscovery
+
+	mu          sync.RWMutex
+	keys        map[string]JWK // 按 kid 缓存的签名公钥
+	keysFetched time.Time
+}
+
+// NewOIDCProvider 创建 OIDC 依赖方并获取身份提供方的元数据与公钥
+func NewOIDCProvider(ctx context.Context, cfg *config.OIDCConfig, states OIDCStateStore, opts ...OIDCOption) (*OIDCProvider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC 配置缺少 issuer、client_id 或 redirect_url")
+	}
+	p := &OIDCProvider{
+		cfg:        cfg,
+		states:     states,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var discovery OIDCDiscovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(cfg.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("获取 OIDC 元数据失败: %w", err)
+	}
+	// 元数据中的 issuer 必须与配置一致，防止被替换为其他身份提供方
+	if discovery.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("OIDC 元数据 issuer %q 与配置 %q 不一致", discovery.Issuer, cfg.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("OIDC 元数据缺少必要的端点")
+	}
+	if len(discovery.CodeChallengeMethodsSupported) > 0 && !slices.Contains(discovery.CodeChallengeMethodsSupported, "S256") {
+		return nil, errors.New("身份提供方不支持 PKCE S256")
+	}
+	p.discovery = &discovery
+
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Discovery 获取身份提供方元数据
+func (p *OIDCProvider) Discovery() *OIDCDiscovery {
+	return p.discovery
+}
+
+// AuthCodeURL 生成跳转到身份提供方的授权地址，state、nonce 与 PKCE 校验码保存在状态存储中
+// tenant 为登录后选择的租户，可以为空，回调时由 Exchange 通过 OIDCIdentity.Tenant 返回。
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, tenant string) (string, error) {
+	state, err := randomURLString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomURLString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomURLString(64)
+	if err != nil {
+		return "", err
+	}
+	if err := p.states.Save(ctx, state, &OIDCState{Nonce: nonce, CodeVerifier: verifier, Tenant: tenant}, oidcStateTTL); err != nil {
+		return "", fmt.Errorf("保存登录状态失败: %w", err)
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.discovery.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange 校验回调的 state，使用授权码换取并验证 ID 令牌
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code string) (*OIDCIdentity, error) {
+	saved, err := p.states.Take(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {saved.CodeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &token); err != nil {
+		if token.Error != "" {
+			return nil, fmt.Errorf("%w: %s %s", ErrOIDCExchange, token.Error, token.ErrorDescription)
+		}
+		return nil, fmt.Errorf("授权码换取令牌失败: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: 响应中缺少 id_token", ErrInvalidIDToken)
+	}
+	identity, err := p.VerifyIDToken(ctx, token.IDToken, saved.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	identity.Tenant = saved.Tenant
+	return identity, nil
+}
+
+// VerifyIDToken 验证 ID 令牌的签名、签发者、受众、有效期与 nonce
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, idToken, nonce string) (*OIDCIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid, token.Method.Alg())
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce 不匹配", ErrInvalidIDToken)
+	}
+	// 多个受众时 azp 必须为本客户端
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: azp 不匹配", ErrInvalidIDToken)
+		}
+	}
+
+	identity := &OIDCIdentity{Claims: claims}
+	identity.Subject, _ = claims.GetSubject()
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%w: 缺少 sub", ErrInvalidIDToken)
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Name, _ = claims["name"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	roleClaim := p.cfg.RoleClaim
+	if roleClaim == "" {
+		roleClaim = "groups"
+	}
+	switch groups := claims[roleClaim].(type) {
+	case string:
+		identity.Groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			if s, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, s)
+			}
+		}
+	}
+	return identity, nil
+}
+
+// MapRoles 按配置将身份提供方的组映射为本地角色，组名不区分大小写，结果去重
+func (p *OIDCProvider) MapRoles(groups []string) []string {
+	mapping := make(map[string][]string, len(p.cfg.RoleMapping))
+	for group, roles := range p.cfg.RoleMapping {
+		mapping[strings.ToLower(group)] = roles
+	}
+	var roles []string
+	for _, group := range groups {
+		for _, role := range mapping[strings.ToLower(group)] {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// publicKey 获取验证 ID 令牌的公钥，kid 未知时重新获取 JWKS 以支持身份提供方轮换密钥
+func (p *OIDCProvider) publicKey(ctx context.Context, kid, alg string) (interface{}, error) {
+	jwk, ok := p.lookupKey(kid)
+	if !ok {
+		p.mu.RLock()
+		stale := time.Since(p.keysFetched) >= oidcJWKSMinRefresh
+		p.mu.RUnlock()
+		if stale {
+			if err := p.refreshKeys(ctx); err != nil {
+				return nil, err
+			}
+			jwk, ok = p.lookupKey(kid)
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	if jwk.Alg != "" && jwk.Alg != alg {
+		return nil, fmt.Errorf("密钥 %s 的算法 %s 与令牌 %s 不一致", kid, jwk.Alg, alg)
+	}
+	return jwk.PublicKey()
+}
+
+// lookupKey 按 kid 查找公钥，令牌未指定 kid 且只有一个公钥时使用该公钥
+func (p *OIDCProvider) lookupKey(kid string) (JWK, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if kid == "" && len(p.keys) == 1 {
+		for _, jwk := range p.keys {
+			return jwk, true
+		}
+	}
+	jwk, ok := p.keys[kid]
+	return jwk, ok
+}
+
+// refreshKeys 重新获取 JWKS
+func (p *OIDCProvider) refreshKeys(ctx context.Context) error {
+	var set JWKSet
+	if err := p.getJSON(ctx, p.discovery.JWKSURI, &set); err != nil {
+		return fmt.Errorf("获取 OIDC 公钥失败: %w", err)
+	}
+	keys := make(map[string]JWK, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use == "" || jwk.Use == "sig" {
+			keys[jwk.Kid] = jwk
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysFetched = time.Now()
+	return nil
+}
+
+// getJSON 发起 GET 请求并解码 JSON 响应
+func (p *OIDCProvider) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.doJSON(req, v)
+}
+
+// doJSON 发送请求并解码 JSON 响应，非 2xx 状态码时仍尝试解码以便获取错误信息
+func (p *OIDCProvider) doJSON(req *http.Request, v any) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s 返回状态码 %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+	}
+	return decodeErr
+}
+
+// pkceChallenge 计算 PKCE S256 质询值
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomURLString 生成 n 字节熵的 URL 安全随机串
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机串失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// takeStateScript 原子地读取并删除状态，保证 state 只能使用一次；不存在时返回空串
+const takeStateScript = `
+local value = redis.call('GET', KEYS[1])
+if not value then
+	return ''
+end
+redis.call('DEL', KEYS[1])
+return value
+`
+
+// RedisOIDCStateStore 基于 Redis 的授权状态存储，键格式为 oidc:state:{state}
+type RedisOIDCStateStore struct {
+	client *redis.Client
+}
+
+// NewRedisOIDCStateStore 创建 Redis 授权状态存储
+func NewRedisOIDCStateStore(client *redis.Client) *RedisOIDCStateStore {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisOIDCStateStore{client: client}
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+// Save 实现 OIDCStateStore 接口
+func (s *RedisOIDCStateStore) Save(ctx context.Context, state string, data *OIDCState, ttl time.Duration) error {
+	return s.client.Set(ctx, oidcStateKey(state), data, ttl)
+}
+
+// Take 实现 OIDCStateStore 接口
+func (s *RedisOIDCStateStore) Take(ctx context.Context, state string) (*OIDCState, error) {
+	if state == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	value, err := s.client.Eval(ctx, takeStateScript, []string{oidcStateKey(state)}).Text()
+	if err != nil {
+		return nil, fmt.Errorf("读取登录状态失败: %w", err)
+	}
+	if value == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	var data OIDCState
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	return &data, nil
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// memoryOIDCStateStore 测试用内存授权状态存储
+type memoryOIDCStateStore struct {
+	mu     sync.Mutex
+	states map[string]OIDCState
+}
+
+func (s *memoryOIDCStateStore) Save(_ context.Context, state string, data *OIDCState, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state] = *data
+	return nil
+}
+
+func (s *memoryOIDCStateStore) Take(_ context.Context, state string) (*OIDCState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.states[state]
+	if !ok {
+		return nil, ErrInvalidOIDCState
+	}
+	delete(s.states, state)
+	return &data, nil
+}
+
+// fakeIdP 进程内的身份提供方，签发 ES256 ID 令牌
+type fakeIdP struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]fakeAuthRequest // 授权码 -> 授权请求
+	tamper func(claims jwt.MapClaims) // 修改签发的声明
+}
+
+type fakeAuthRequest struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key, codes: make(map[string]fakeAuthRequest)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OIDCDiscovery{
+			Issuer:                        idp.server.URL,
+			AuthorizationEndpoint:         idp.server.URL + "/authorize",
+			TokenEndpoint:                 idp.server.URL + "/token",
+			JWKSURI:                       idp.server.URL + "/jwks",
+			CodeChallengeMethodsSupported: []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub, _ := key.PublicKey.Bytes()
+		enc := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{{
+			Kty: "EC", Kid: "idp-1", Use: "sig", Alg: "ES256", Crv: "P-256",
+			X: enc(pub[1:33]), Y: enc(pub[33:]),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "fiber" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		idp.mu.Lock()
+		req, ok := idp.codes[r.PostFormValue("code")]
+		delete(idp.codes, r.PostFormValue("code"))
+		idp.mu.Unlock()
+		if !ok || pkceChallenge(r.PostFormValue("code_verifier")) != req.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		now := time.Now()
+		claims := jwt.MapClaims{
+			"iss":    idp.server.URL,
+			"sub":    "idp-user-1",
+			"aud":    "fiber",
+			"iat":    now.Unix(),
+			"exp":    now.Add(time.Minute).Unix(),
+			"nonce":  req.nonce,
+			"email":  "alice@example.com",
+			"name":   "Alice",
+			"groups": []string{"Ops", "dev"},
+		}
+		if idp.tamper != nil {
+			idp.tamper(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "idp-1"
+		signed, _ := token.SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize 模拟用户在身份提供方完成登录，返回回调的 state 与授权码
+func (idp *fakeIdP) authorize(t *testing.T, authURL string) (state, code string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "fiber" || q.Get("scope") != "openid email" {
+		t.Fatalf("授权地址参数错误: %s", authURL)
+	}
+	code = q.Get("state") + "-code"
+	idp.mu.Lock()
+	idp.codes[code] = fakeAuthRequest{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	idp.mu.Unlock()
+	return q.Get("state"), code
+}
+
+func TestOIDCProvider(t *testing.T) {
+	idp := newFakeIdP(t)
+	ctx := context.Background()
+	p, err := NewOIDCProvider(ctx, &config.OIDCConfig{
+		Issuer:       idp.server.URL,
+		ClientID:     "fiber",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://localhost/admin/oidc/callback",
+		Scopes:       []string{"openid", "email"},
+		RoleMapping:  map[string][]string{"ops": {"admin"}, "dev": {"developer", "admin"}},
+	}, &memoryOIDCStateStore{states: make(map[string]OIDCState)}, WithOIDCHTTPClient(idp.server.Client()))
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+
+	login := func() (string, string) {
+		authURL, err := p.AuthCodeURL(ctx, "acme")
+		if err != nil {
+			t.Fatalf("AuthCodeURL() error = %v", err)
+		}
+		return idp.authorize(t, authURL)
+	}
+
+	state, code := login()
+	identity, err := p.Exchange(ctx, state, code)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if identity.Subject != "idp-user-1" || identity.Email != "alice@example.com" || len(identity.Groups) != 2 || identity.Tenant != "acme" {
+		t.Errorf("Exchange() = %+v", identity)
+	}
+	if roles := p.MapRoles(identity.Groups); len(roles) != 2 || roles[0] != "admin" || roles[1] != "developer" {
+		t.Errorf("MapRoles() = %v, want [admin developer]", roles)
+	}
+
+	// state 只能使用一次
+	if _, err := p.Exchange(ctx, state, code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("重放 Exchange() error = %v, want %v", err, ErrInvalidOIDCState)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(jwt.MapClaims)
+	}{
+		{"nonce不匹配", func(c jwt.MapClaims) { c["nonce"] = "other" }},
+		{"受众错误", func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{"签发者错误", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"已过期", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp.tamper = tt.tamper
+			defer func() { idp.tamper = nil }()
+			state, code := login()
+			if _, err := p.Exchange(ctx, state, code); !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("Exchange() error = %v, want %v", err, ErrInvalidIDToken)
+			}
+		})
+	}
+
+	t.Run("PKCE校验码不匹配", func(t *testing.T) {
+		state, code := login()
+		// 篡改保存的校验码，模拟授权码被其他客户端截获使用
+		states := p.states.(*memoryOIDCStateStore)
+		states.mu.Lock()
+		saved := states.states[state]
+		saved.CodeVerifier = "intercepted"
+		states.states[state] = saved
+		states.mu.Unlock()
+		if _, err := p.Exchange(ctx, state, code); !errors.Is(err, ErrOIDCExchange) {
+			t.Errorf("Exchange() error = %v, want %v", err, ErrOIDCExchange)
+		}
+	})
+
+	t.Run("未知state", func(t *testing.T) {
+		if _, err := p.Exchange(ctx, "unknown", "code"); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Errorf("Exchange() error = %v, want %v", err, ErrInvalidOIDCState)
+		}
+	})
+}
+
+func TestNewOIDCProviderIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	_, err := NewOIDCProvider(context.Background(), &config.OIDCConfig{
+		Issuer:      idp.server.URL + "/",
+		ClientID:    "fiber",
+		RedirectURL: "http://localhost/callback",
+	}, &memoryOIDCStateStore{}, WithOIDCHTTPClient(idp.server.Client()))
+	if err == nil {
+		t.Error("NewOIDCProvider() 应拒绝 issuer 不一致的元数据")
+	}
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = errors.New("会话不存在")
+	ErrNoSessionStore  = errors.New("未配置会话存储")
+)
+
+// Session 登录会话，与令牌家族一一对应，ID 即家族 ID
+type Session struct {
+	ID         string    `json:"id"`
+	Subject    string    `json:"subject"` // 令牌主体 sub
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastActive time.Time `json:"last_active"` // 最近一次登录或刷新令牌的时间
+	ExpiresAt  time.Time `json:"expires_at"`  // 刷新令牌过期时间，之后会话自动清理
+}
+
+// SessionStore 会话存储，按令牌主体分组
+type SessionStore interface {
+	// Save 保存会话，过期后自动清理
+	Save(ctx context.Context, session *Session) error
+	// Get 获取会话，不存在或已过期时返回 ErrSessionNotFound
+	Get(ctx context.Context, subject, id string) (*Session, error)
+	// List 获取主体未过期的会话，同时清理已过期的会话
+	List(ctx context.Context, subject string) ([]*Session, error)
+	// Delete 删除会话
+	Delete(ctx context.Context, subject string, ids ...string) error
+	// DeleteAll 删除主体的全部会话
+	DeleteAll(ctx context.Context, subject string) error
+}
+
+// ClientInfo 发起登录或刷新的客户端信息
+type ClientInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo 将客户端信息写入 ctx，签发或刷新令牌时记录到会话
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFrom 获取 ctx 中的客户端信息
+func ClientInfoFrom(ctx context.Context) (ClientInfo, bool) {
+	info, ok := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info, ok
+}
+
+// saveSessionScript 写入会话并保证哈希的过期时间不短于该会话
+// 会话 JSON 由调用方编码后原样写入，可直接用 Client.HGet 解码
+const saveSessionScript = `
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`
+
+// RedisSessionStore 基于 Redis 的会话存储
+// 键格式：session:{subject} 为哈希，字段为会话 ID，值为会话 JSON；哈希随最晚过期的会话过期，已过期的字段在 List 时清理。
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+// NewRedisSessionStore 创建 Redis 会话存储
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisSessionStore{client: client}
+}
+
+func sessionKey(subject string) string {
+	return "session:" + subject
+}
+
+// Save 实现 SessionStore 接口
+func (s *RedisSessionStore) Save(ctx context.Context, session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	err = s.client.Eval(ctx, saveSessionScript, []string{sessionKey(session.Subject)},
+		session.ID, string(data), ttl.Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("保存会话失败: %w", err)
+	}
+	return nil
+}
+
+// Get 实现 SessionStore 接口
+func (s *RedisSessionStore) Get(ctx context.Context, subject, id string) (*Session, error) {
+	var session Session
+	if err := s.client.HGet(ctx, sessionKey(subject), id, &session); err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// List 实现 SessionStore 接口
+func (s *RedisSessionStore) List(ctx context.Context, subject string) ([]*Session, error) {
+	values, err := s.client.HGetAll(ctx, sessionKey(subject))
+	if err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	now := time.Now()
+	sessions := make([]*Session, 0, len(values))
+	var expired []string
+	for id, value := range values {
+		var session Session
+		if err := json.Unmarshal([]byte(value), &session); err != nil || now.After(session.ExpiresAt) {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(expired) > 0 {
+		if err := s.Delete(ctx, subject, expired...); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// Delete 实现 SessionStore 接口
+func (s *RedisSessionStore) Delete(ctx context.Context, subject string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.client.HDel(ctx, sessionKey(subject), ids...); err != nil {
+		return fmt.Errorf("删除会话失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteAll 实现 SessionStore 接口
+func (s *RedisSessionStore) DeleteAll(ctx context.Context, subject string) error {
+	return s.client.Delete(ctx, sessionKey(subject))
+}
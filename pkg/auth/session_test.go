@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/config"
+)
+
+// memorySessionStore 测试用内存会话存储
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]Session
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[string]map[string]Session)}
+}
+
+func (s *memorySessionStore) Save(_ context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[session.Subject] == nil {
+		s.sessions[session.Subject] = make(map[string]Session)
+	}
+	s.sessions[session.Subject][session.ID] = *session
+	return nil
+}
+
+func (s *memorySessionStore) Get(_ context.Context, subject, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[subject][id]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *memorySessionStore) List(_ context.Context, subject string) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*Session
+	for id, session := range s.sessions[subject] {
+		if time.Now().After(session.ExpiresAt) {
+			delete(s.sessions[subject], id)
+			continue
+		}
+		result = append(result, &session)
+	}
+	return result, nil
+}
+
+func (s *memorySessionStore) Delete(_ context.Context, subject string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.sessions[subject], id)
+	}
+	return nil
+}
+
+func (s *memorySessionStore) DeleteAll(_ context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, subject)
+	return nil
+}
+
+func TestSessions(t *testing.T) {
+	m := NewJWTManager(&config.JWTConfig{
+		SecretKey:          "test-secret",
+		AccessTokenExpiry:  time.Minute,
+		RefreshTokenExpiry: time.Hour,
+		MaxSessions:        2,
+	}, WithTokenStore(newMemoryTokenStore()), WithSessionStore(newMemorySessionStore()))
+	ctx := context.Background()
+	alice := Identity{UserID: 1, Subject: "admin:1", Username: "alice"}
+
+	login := func(device string) *TokenPair {
+		t.Helper()
+		pair, err := m.IssueTokenPair(WithClientInfo(ctx, ClientInfo{Device: device, IP: "10.0.0.1", UserAgent: "test"}), alice)
+		if err != nil {
+			t.Fatalf("IssueTokenPair() error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+		return pair
+	}
+
+	laptop := login("laptop")
+	phone := login("phone")
+
+	// 刷新后 laptop 成为最近活跃的会话
+	laptop, err := m.RefreshToken(ctx, laptop.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	sessions, err := m.Sessions(ctx, "admin:1")
+	if err != nil {
+		t.Fatalf("Sessions() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Device != "laptop" || sessions[1].Device != "phone" || sessions[0].IP != "10.0.0.1" {
+		t.Fatalf("Sessions() = %+v", sessions)
+	}
+
+	// 超出并发会话数时吊销最久未活跃的 phone
+	tablet := login("tablet")
+	if _, err := m.VerifyToken(ctx, phone.AccessToken, AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("被挤下线的会话 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := m.VerifyToken(ctx, laptop.AccessToken, AccessToken); err != nil {
+		t.Errorf("保留的会话 VerifyToken() error = %v", err)
+	}
+
+	// 令牌主体区分ID相同的不同类账号
+	if err := m.RevokeSession(ctx, "1", sessions[0].ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("其他主体 RevokeSession() error = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	claims, _ := m.VerifyToken(ctx, tablet.AccessToken, AccessToken)
+	if err := m.RevokeSession(ctx, "admin:1", claims.Family); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, err := m.RefreshToken(ctx, tablet.RefreshToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("RevokeSession() 后 RefreshToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+
+	// 登出删除当前会话
+	if err := m.Revoke(ctx, laptop.AccessToken); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if sessions, _ := m.Sessions(ctx, "admin:1"); len(sessions) != 0 {
+		t.Errorf("登出后 Sessions() = %+v, want 空", sessions)
+	}
+
+	// 刷新令牌重放时吊销家族并删除会话
+	watch := login("watch")
+	if _, err := m.RefreshToken(ctx, watch.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if _, err := m.RefreshToken(ctx, watch.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("重放 RefreshToken() error = %v, want %v", err, ErrTokenReused)
+	}
+	if sessions, _ := m.Sessions(ctx, "admin:1"); len(sessions) != 0 {
+		t.Errorf("重放后 Sessions() = %+v, want 空", sessions)
+	}
+
+	// 按令牌主体吊销全部令牌并清除会话
+	desktop := login("desktop")
+	if err := m.RevokeAllForUser(ctx, "admin:1"); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+	if _, err := m.VerifyToken(ctx, desktop.AccessToken, AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("RevokeAllForUser() 后 VerifyToken() error = %v, want %v", err, ErrRevokedToken)
+	}
+	if sessions, _ := m.Sessions(ctx, "admin:1"); len(sessions) != 0 {
+		t.Errorf("RevokeAllForUser() 后 Sessions() = %+v, want 空", sessions)
+	}
+}
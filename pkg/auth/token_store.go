@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"time"
+)
+
+// TokenStore 令牌状态存储，用于刷新令牌轮换与吊销
+// 同一次登录签发的令牌属于同一个家族（family），每个家族只有一个有效的刷新令牌；家族被吊销后其下所有令牌失效。
+type TokenStore interface {
+	// CreateFamily 创建令牌主体 subject 的令牌家族并记录当前有效的刷新令牌 jti
+	CreateFamily(ctx context.Context, subject, family, jti string, ttl time.Duration) error
+	// Rotate 使用刷新令牌 jti 并将家族的有效刷新令牌替换为 next
+	// 家族不存在时返回 ErrRevokedToken；jti 不是当前有效的刷新令牌时吊销整个家族并返回 ErrTokenReused。
+	Rotate(ctx context.Context, family, jti, next string, ttl time.Duration) error
+	// IsRevoked 判断令牌是否已被吊销
+	IsRevoked(ctx context.Context, family, jti string) (bool, error)
+	// Revoke 将 jti 加入黑名单，ttl 为令牌剩余有效期
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	// RevokeFamily 吊销令牌家族
+	RevokeFamily(ctx context.Context, family string) error
+	// RevokeSubject 吊销令牌主体的所有令牌家族
+	RevokeSubject(ctx context.Context, subject string) error
+}
+
+// createFamilyScript 记录家族的刷新令牌并加入主体的家族集合，集合过期时间不短于家族
+// Client.Set/SAdd 会以 JSON 编码值，脚本直接写入原始字符串，便于比较
+const createFamilyScript = `
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+if redis.call('PTTL', KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+end
+return 1
+`
+
+// rotateScript 原子地校验并轮换刷新令牌：1 成功，0 家族不存在，-1 令牌重放（已吊销家族）
+const rotateScript = `
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`
+
+// revokedScript 判断令牌是否在黑名单中或所属家族已失效
+const revokedScript = `
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 1
+end
+if KEYS[2] ~= '' and redis.call('EXISTS', KEYS[2]) == 0 then
+	return 1
+end
+return 0
+`
+
+// RedisTokenStore 基于 Redis 的令牌状态存储
+// 键格式：jwt:family:{family} 保存家族当前有效的刷新令牌 jti，jwt:subject:{sub} 保存令牌主体的家族集合，jwt:deny:{jti} 为黑名单。
+type RedisTokenStore struct {
+	client *redis.Client
+}
+
+// NewRedisTokenStore 创建 Redis 令牌状态存储
+func NewRedisTokenStore(client *redis.Client) *RedisTokenStore {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisTokenStore{client: client}
+}
+
+func familyKey(family string) string {
+	return "jwt:family:" + family
+}
+
+// subjectFamiliesKey 按令牌主体区分，ID 相同的管理员与用户使用不同的集合
+func subjectFamiliesKey(subject string) string {
+	return "jwt:subject:" + subject
+}
+
+func denyKey(jti string) string {
+	return "jwt:deny:" + jti
+}
+
+// CreateFamily 实现 TokenStore 接口
+func (s *RedisTokenStore) CreateFamily(ctx context.Context, subject, family, jti string, ttl time.Duration) error {
+	err := s.client.Eval(ctx, createFamilyScript, []string{familyKey(family), subjectFamiliesKey(subject)},
+		jti, ttl.Milliseconds(), family).Err()
+	if err != nil {
+		return fmt.Errorf("创建令牌家族失败: %w", err)
+	}
+	return nil
+}
+
+// Rotate 实现 TokenStore 接口
+func (s *RedisTokenStore) Rotate(ctx context.Context, family, jti, next string, ttl time.Duration) error {
+	result, err := s.client.Eval(ctx, rotateScript, []string{familyKey(family)}, jti, next, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("轮换刷新令牌失败: %w", err)
+	}
+	switch result {
+	case 1:
+		return nil
+	case -1:
+		return ErrTokenReused
+	default:
+		return ErrRevokedToken
+	}
+}
+
+// IsRevoked 实现 TokenStore 接口
+func (s *RedisTokenStore) IsRevoked(ctx context.Context, family, jti string) (bool, error) {
+	fKey := ""
+	if family != "" {
+		fKey = familyKey(family)
+	}
+	result, err := s.client.Eval(ctx, revokedScript, []string{denyKey(jti), fKey}).Int()
+	if err != nil {
+		return false, fmt.Errorf("查询令牌状态失败: %w", err)
+	}
+	return result == 1, nil
+}
+
+// Revoke 实现 TokenStore 接口
+func (s *RedisTokenStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, denyKey(jti), 1, ttl)
+}
+
+// RevokeFamily 实现 TokenStore 接口
+func (s *RedisTokenStore) RevokeFamily(ctx context.Context, family string) error {
+	return s.client.Delete(ctx, familyKey(family))
+}
+
+// RevokeSubject 实现 TokenStore 接口
+func (s *RedisTokenStore) RevokeSubject(ctx context.Context, subject string) error {
+	key := subjectFamiliesKey(subject)
+	families, err := s.client.SMembers(ctx, key)
+	if err != nil {
+		return fmt.Errorf("查询主体令牌家族失败: %w", err)
+	}
+	keys := make([]string, 0, len(families)+1)
+	for _, family := range families {
+		keys = append(keys, familyKey(family))
+	}
+	keys = append(keys, key)
+	return s.client.Delete(ctx, keys...)
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/spf13/viper"
@@ -20,6 +21,8 @@ type Config struct {
 	NSQ      NSQConfig      `mapstructure:"nsq"`
 	App      AppConfig      `mapstructure:"app"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	OIDC     OIDCConfig     `mapstructure:"oidc"`
+	Rbac     RbacConfig     `mapstructure:"rbac"`
 	Log      LogConfig      `mapstructure:"log"`
 }
 
@@ -43,6 +46,21 @@ type DBConfig struct {
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+
+	// 读写分离：读操作路由到只读副本，写操作和事务使用主库
+	Replicas            []ReplicaConfig `mapstructure:"replicas"`              // 只读副本，为空时不启用读写分离
+	Policy              string          `mapstructure:"policy"`                // 副本选择策略：round_robin（默认）或 weighted
+	HealthCheckInterval time.Duration   `mapstructure:"health_check_interval"` // 副本健康检查间隔，默认 10s
+}
+
+// ReplicaConfig 只读副本配置，未设置的用户名、密码和库名沿用主库配置，连接池参数与主库一致
+type ReplicaConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	DBName   string `mapstructure:"dbname"`
+	Weight   int    `mapstructure:"weight"` // 权重，仅 weighted 策略生效，默认 1
 }
 
 type RedisConfig struct {
@@ -76,12 +94,56 @@ type AppConfig struct {
 	Env      string `yaml:"env"`
 	Name     string `yaml:"name"`
 	Language string `yaml:"language"`
+	// CursorSecret 游标分页签名密钥，多实例间需保持一致；为空时使用进程内随机密钥，重启后旧游标失效
+	CursorSecret string `mapstructure:"cursor_secret"`
 }
 
 type JWTConfig struct {
-	SecretKey          string        `mapstructure:"secret_key"`
-	AccessTokenExpiry  time.Duration `mapstructure:"access_token_expiry"`
-	RefreshTokenExpiry time.Duration `mapstructure:"refresh_token_expiry"`
+	SecretKey          string         `mapstructure:"secret_key"`
+	AccessTokenExpiry  time.Duration  `mapstructure:"access_token_expiry"`
+	RefreshTokenExpiry time.Duration  `mapstructure:"refresh_token_expiry"`
+	Issuer             string         `mapstructure:"issuer"`            // 签发者 iss，非空时签发并校验
+	Audience           []string       `mapstructure:"audience"`          // 受众 aud，非空时签发并要求令牌至少包含其中之一
+	Leeway             time.Duration  `mapstructure:"leeway"`            // 校验 exp/nbf/iat 时允许的时钟偏差
+	Keys               []JWTKeyConfig `mapstructure:"keys"`              // 非对称签名密钥，第一个用于签名，其余仅用于验证；为空时使用 secret_key 进行 HS256 签名
+	RotationInterval   time.Duration  `mapstructure:"rotation_interval"` // 自动生成新密钥的间隔，0 表示不自动轮换
+	RotationGrace      time.Duration  `mapstructure:"rotation_grace"`    // 轮换后旧密钥继续用于验证的时间，默认为刷新令牌有效期
+	MaxSessions        int            `mapstructure:"max_sessions"`      // 每个用户的最大并发会话数，超出时吊销最久未活跃的会话，0 表示不限制
+}
+
+// RbacConfig Casbin 权限配置
+type RbacConfig struct {
+	Domains       bool   `mapstructure:"domains"`        // 是否启用多租户，启用后策略与角色分配按域隔离
+	DefaultDomain string `mapstructure:"default_domain"` // 令牌未携带租户时使用的域，也是单租户策略迁移的目标域，默认 default
+
+	Watcher        bool          `mapstructure:"watcher"`         // 是否通过 Redis 发布订阅在多个实例间同步策略变更
+	Channel        string        `mapstructure:"channel"`         // 策略变更通知频道，默认 casbin:policy
+	ReloadInterval time.Duration `mapstructure:"reload_interval"` // 定期从数据库全量加载策略的间隔，0 表示不定期加载
+
+	DecisionLog        string `mapstructure:"decision_log"`        // 权限判定日志：空表示不记录，deny 只记录拒绝，all 记录全部
+	DecisionCollection string `mapstructure:"decision_collection"` // 判定日志的 MongoDB 集合，默认 rbac_decisions
+}
+
+// OIDCConfig OIDC 身份提供方配置
+type OIDCConfig struct {
+	Enabled       bool                `mapstructure:"enabled"`
+	Issuer        string              `mapstructure:"issuer"`          // 身份提供方地址，据此获取 /.well-known/openid-configuration
+	ClientID      string              `mapstructure:"client_id"`       // 客户端 ID
+	ClientSecret  string              `mapstructure:"client_secret"`   // 客户端密钥，公开客户端可为空
+	RedirectURL   string              `mapstructure:"redirect_url"`    // 回调地址，需在身份提供方登记
+	Scopes        []string            `mapstructure:"scopes"`          // 额外申请的 scope，openid 总会包含
+	RoleClaim     string              `mapstructure:"role_claim"`      // ID 令牌中的组或角色声明，默认 groups
+	RoleMapping   map[string][]string `mapstructure:"role_mapping"`    // 身份提供方的组到本地 Casbin 角色的映射，组名不区分大小写
+	AutoCreate    bool                `mapstructure:"auto_create"`     // 首次登录时自动创建管理员
+	SkipTwoFactor bool                `mapstructure:"skip_two_factor"` // 身份提供方已强制多因素认证时跳过本地两步验证
+}
+
+// JWTKeyConfig JWT 签名密钥配置
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`              // 密钥 ID，写入令牌头部的 kid
+	Algorithm      string `mapstructure:"algorithm"`        // RS256、ES256 或 EdDSA
+	PrivateKey     string `mapstructure:"private_key"`      // PEM 格式私钥内容，优先于 private_key_file
+	PrivateKeyFile string `mapstructure:"private_key_file"` // PEM 格式私钥文件路径
 }
 
 type LogConfig struct {
@@ -197,7 +259,27 @@ func Load() error {
 	if err := viper.Unmarshal(Data); err != nil {
 		return err
 	}
-	log.Printf("具体配置信息:%+v", *Data)
+	log.Printf("具体配置信息:%+v", Data.redacted())
 
 	return nil
 }
+
+// redacted 返回隐藏签名密钥、私钥等敏感字段后的配置副本，用于打印日志
+func (c Config) redacted() Config {
+	c.App.CursorSecret = mask(c.App.CursorSecret)
+	c.JWT.SecretKey = mask(c.JWT.SecretKey)
+	c.JWT.Keys = slices.Clone(c.JWT.Keys)
+	for i := range c.JWT.Keys {
+		c.JWT.Keys[i].PrivateKey = mask(c.JWT.Keys[i].PrivateKey)
+	}
+	c.OIDC.ClientSecret = mask(c.OIDC.ClientSecret)
+	return c
+}
+
+// mask 隐藏非空的敏感值
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "******"
+}
@@ -3,6 +3,7 @@ package cron
 import (
 	"context"
 	"errors"
+	"fiber_web/pkg/lock"
 	"fiber_web/pkg/logger"
 	"fmt"
 	"sync"
@@ -20,6 +21,13 @@ const (
 	TaskStatusStopped                   // 已停止
 )
 
+const (
+	// singletonKeyPrefix 集群单例任务锁的键前缀
+	singletonKeyPrefix = "cron:singleton:"
+	// defaultLeaseTTL 单例任务锁的默认租约时长
+	defaultLeaseTTL = 30 * time.Second
+)
+
 // TaskFunc 定时任务函数类型
 type TaskFunc func(ctx context.Context) error
 
@@ -64,16 +72,17 @@ func (i IntervalSchedule) ToCron() string {
 
 // Task 定时任务结构
 type Task struct {
-	Name     string        // 任务名称
-	Spec     string        // cron表达式
-	Func     TaskFunc      // 执行的函数
-	Timeout  time.Duration // 超时时间
-	Status   TaskStatus    // 任务状态
-	EntryID  cron.EntryID  // cron任务ID
-	LastTime time.Time     // 上次执行时间
-	mu       sync.RWMutex  // 读写锁，优化并发访问
-	ctx      context.Context
-	cancel   context.CancelFunc
+	Name      string        // 任务名称
+	Spec      string        // cron表达式
+	Func      TaskFunc      // 执行的函数
+	Timeout   time.Duration // 超时时间
+	Status    TaskStatus    // 任务状态
+	EntryID   cron.EntryID  // cron任务ID
+	LastTime  time.Time     // 上次执行时间
+	Singleton bool          // 集群单例，同一时刻只有一个实例执行
+	mu        sync.RWMutex  // 读写锁，优化并发访问
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 // Scheduler 调度器
@@ -82,32 +91,75 @@ type Scheduler struct {
 	tasks map[string]*Task
 	log   *logger.Logger
 	mu    sync.RWMutex
+
+	locker   lock.Lock     // 集群单例任务使用的分布式锁
+	leaseTTL time.Duration // 单例任务锁的租约时长，执行期间自动续期
+}
+
+// SchedulerOption 调度器配置项
+type SchedulerOption func(*Scheduler)
+
+// WithLocker 设置分布式锁，启用集群单例任务
+// 租约时长只需覆盖实例宕机后锁的释放时间，长任务由锁守护自动续期。
+func WithLocker(locker lock.Lock, leaseTTL time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.locker = locker
+		if leaseTTL > 0 {
+			s.leaseTTL = leaseTTL
+		}
+	}
 }
 
 // NewScheduler 创建一个新的调度器
-func NewScheduler(logger *logger.Logger) *Scheduler {
-	return &Scheduler{
-		cron:  cron.New(cron.WithSeconds()),
-		tasks: make(map[string]*Task),
-		log:   logger,
+func NewScheduler(logger *logger.Logger, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		cron:     cron.New(cron.WithSeconds()),
+		tasks:    make(map[string]*Task),
+		log:      logger,
+		leaseTTL: defaultLeaseTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // AddTask 添加定时任务
 func (s *Scheduler) AddTask(name, spec string, f TaskFunc, timeout time.Duration) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.tasks[name]; exists {
-		return ErrTaskAlreadyExists
-	}
-
-	task := &Task{
+	return s.addTask(&Task{
 		Name:    name,
 		Spec:    spec,
 		Func:    f,
 		Timeout: timeout,
 		Status:  TaskStatusReady,
+	})
+}
+
+// AddSingletonTask 添加集群单例任务
+// 每次触发时先获取以任务名命名的分布式锁，其他实例正在执行时本次跳过；
+// 执行期间锁自动续期，锁丢失时取消任务的上下文。
+func (s *Scheduler) AddSingletonTask(name, spec string, f TaskFunc, timeout time.Duration) error {
+	if s.locker == nil {
+		return ErrLockerNotSet
+	}
+	return s.addTask(&Task{
+		Name:      name,
+		Spec:      spec,
+		Func:      s.singleton(name, f),
+		Timeout:   timeout,
+		Status:    TaskStatusReady,
+		Singleton: true,
+	})
+}
+
+// addTask 注册任务到cron
+func (s *Scheduler) addTask(task *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, spec := task.Name, task.Spec
+	if _, exists := s.tasks[name]; exists {
+		return ErrTaskAlreadyExists
 	}
 
 	wrappedFunc := func() {
@@ -127,6 +179,43 @@ func (s *Scheduler) AddTask(name, spec string, f TaskFunc, timeout time.Duration
 	return nil
 }
 
+// singletonKey 单例任务锁的键，任务名作为 hash tag，保证 Redis Cluster 下锁与栅栏令牌计数器位于同一槽
+func singletonKey(name string) string {
+	return singletonKeyPrefix + "{" + name + "}"
+}
+
+// singleton 包装任务函数，持有分布式锁时才执行
+func (s *Scheduler) singleton(name string, f TaskFunc) TaskFunc {
+	key := singletonKey(name)
+	return func(ctx context.Context) error {
+		// 每次执行使用不同的持有者，避免超时后仍在运行的上次执行与本次重入同一把锁
+		owner, err := lock.NewOwner()
+		if err != nil {
+			return err
+		}
+		guard, err := s.locker.Lock(ctx, key, owner, s.leaseTTL)
+		if errors.Is(err, lock.ErrLockFailed) {
+			s.log.Debug("singleton task skipped, running on another instance", logger.String("task", name))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// 任务上下文可能已取消，使用独立的上下文解锁
+			if err := guard.Release(context.Background()); err != nil && !errors.Is(err, lock.ErrNotOwner) {
+				s.log.Warn("failed to release singleton task lock", logger.String("task", name), logger.ErrorField(err))
+			}
+		}()
+
+		err = f(guard.Context())
+		if lost := guard.Err(); lost != nil {
+			return errors.Join(lost, err)
+		}
+		return err
+	}
+}
+
 // runTask 运行任务
 func (s *Scheduler) runTask(task *Task) error {
 	task.mu.Lock()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fiber_web/pkg/config"
+	"fiber_web/pkg/lock"
 	"fiber_web/pkg/logger"
 	"sync"
 	"testing"
@@ -389,3 +390,134 @@ func TestTaskLastTime(t *testing.T) {
 		t.Error("任务最后执行时间未更新")
 	}
 }
+
+// memoryLocker 测试用进程内锁，不处理过期
+type memoryLocker struct {
+	mu     sync.Mutex
+	owners map[string]any
+}
+
+func (l *memoryLocker) Lock(ctx context.Context, key string, value any, ttl time.Duration) (*lock.LockGuard, error) {
+	token, ok, err := l.TryLock(ctx, key, value, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, lock.ErrLockFailed
+	}
+	return lock.NewLockGuard(ctx, l, key, value, ttl, token), nil
+}
+
+func (l *memoryLocker) TryLock(_ context.Context, key string, value any, _ time.Duration) (int64, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if owner, ok := l.owners[key]; ok && owner != value {
+		return 0, false, nil
+	}
+	l.owners[key] = value
+	return 1, true, nil
+}
+
+func (l *memoryLocker) LockWithTimeout(ctx context.Context, key string, value any, ttl, _ time.Duration) (*lock.LockGuard, error) {
+	return l.Lock(ctx, key, value, ttl)
+}
+
+func (l *memoryLocker) Unlock(_ context.Context, key string, value any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.owners[key] != value {
+		return lock.ErrNotOwner
+	}
+	delete(l.owners, key)
+	return nil
+}
+
+func (l *memoryLocker) Refresh(_ context.Context, key string, value any, _ time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.owners[key] != value {
+		return lock.ErrNotOwner
+	}
+	return nil
+}
+
+func (l *memoryLocker) GetLockTTL(context.Context, string) (time.Duration, error) {
+	return 0, nil
+}
+
+func (l *memoryLocker) set(key string, value any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if value == nil {
+		delete(l.owners, key)
+		return
+	}
+	l.owners[key] = value
+}
+
+func TestSingletonTask(t *testing.T) {
+	if err := setupTestScheduler(t).AddSingletonTask("job", "@every 1m", func(ctx context.Context) error { return nil }, time.Second); !errors.Is(err, ErrLockerNotSet) {
+		t.Fatalf("未设置锁 AddSingletonTask() error = %v, want %v", err, ErrLockerNotSet)
+	}
+
+	// 两个实例共享同一把锁
+	locker := &memoryLocker{owners: make(map[string]any)}
+	a := NewScheduler(getTestLogger(t), WithLocker(locker, 30*time.Millisecond))
+	b := NewScheduler(getTestLogger(t), WithLocker(locker, 30*time.Millisecond))
+
+	started := make(chan struct{})
+	if err := a.AddSingletonTask("job", "@every 1m", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, 5*time.Second); err != nil {
+		t.Fatalf("添加任务失败: %v", err)
+	}
+	var runs int
+	if err := b.AddSingletonTask("job", "@every 1m", func(ctx context.Context) error {
+		runs++
+		return nil
+	}, 5*time.Second); err != nil {
+		t.Fatalf("添加任务失败: %v", err)
+	}
+	taskA, _ := a.GetTask("job")
+	taskB, _ := b.GetTask("job")
+	if !taskA.Singleton {
+		t.Error("单例任务的 Singleton 应为 true")
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- a.runTask(taskA) }()
+	<-started
+
+	if err := b.runTask(taskB); err != nil || runs != 0 {
+		t.Errorf("其他实例执行中时 runTask() error = %v, runs = %d, want 跳过", err, runs)
+	}
+
+	// 锁被抢占后取消执行中的任务
+	locker.set(singletonKey("job"), "other")
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, lock.ErrLockLost) {
+			t.Errorf("锁丢失后 runTask() error = %v, want %v", err, lock.ErrLockLost)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("锁丢失后任务应被取消")
+	}
+
+	locker.set(singletonKey("job"), nil)
+	if err := b.runTask(taskB); err != nil || runs != 1 {
+		t.Errorf("锁空闲时 runTask() error = %v, runs = %d, want 执行一次", err, runs)
+	}
+	if _, ok, _ := locker.TryLock(context.Background(), singletonKey("job"), "other", time.Second); !ok {
+		t.Error("任务结束后应释放锁")
+	}
+}
+
+func TestSingletonKey(t *testing.T) {
+	// 任务名作为 hash tag，锁与栅栏令牌计数器 {key}:fence 位于同一槽
+	key := singletonKey("daily-report")
+	if key != "cron:singleton:{daily-report}" {
+		t.Errorf("singletonKey() = %q, want cron:singleton:{daily-report}", key)
+	}
+}
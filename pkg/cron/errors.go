@@ -8,4 +8,5 @@ var (
 	ErrTaskIsRunning     = errors.New("task is already running")
 	ErrTaskTimeout       = errors.New("task execution timeout")
 	ErrTaskStopped       = errors.New("task stopped")
+	ErrLockerNotSet      = errors.New("locker not set")
 )
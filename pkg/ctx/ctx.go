@@ -14,3 +14,12 @@ func GetPagination(c *fiber.Ctx) *query.Pagination {
 	}
 	return p
 }
+
+// GetQuery 从上下文获取查询参数，未经过 Filter 中间件时返回空查询
+func GetQuery(c *fiber.Ctx) *query.Query {
+	q, ok := c.Locals("query").(*query.Query)
+	if !ok {
+		return query.NewQuery()
+	}
+	return q
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fiber_web/pkg/config"
 	"fmt"
 
@@ -16,7 +18,8 @@ type DBManager struct {
 
 // Database 包装单个数据库连接
 type Database struct {
-	db *gorm.DB
+	db       *gorm.DB
+	resolver *Resolver // 读写分离路由，未配置副本时为 nil
 }
 
 // NewDBManager 创建数据库管理器
@@ -46,15 +49,9 @@ func NewDBManager(cfg *config.DatabaseConfig) (*DBManager, error) {
 	return manager, nil
 }
 
-// newDatabase 创建单个数据库连接
+// newDatabase 创建单个数据库连接，配置了只读副本时启用读写分离
 func newDatabase(cfg *config.DBConfig) (*Database, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-	)
+	dsn := buildDSN(cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
 
 	gormConfig := &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
@@ -74,16 +71,87 @@ func newDatabase(cfg *config.DBConfig) (*Database, error) {
 	}
 
 	// 设置连接池
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	setPool(sqlDB, cfg)
 
 	// 测试连接
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Database{db: db}, nil
+	database := &Database{db: db}
+	if len(cfg.Replicas) == 0 {
+		return database, nil
+	}
+
+	resolver, err := newResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(resolver); err != nil {
+		_ = resolver.Close()
+		return nil, fmt.Errorf("failed to register resolver: %w", err)
+	}
+	resolver.StartHealthCheck(cfg.HealthCheckInterval)
+	database.resolver = resolver
+
+	return database, nil
+}
+
+// newResolver 连接只读副本并创建读写分离路由
+// 启动时不可用的副本不会导致失败，而是标记为不健康，由健康检查恢复
+func newResolver(cfg *config.DBConfig) (*Resolver, error) {
+	switch cfg.Policy {
+	case "", PolicyRoundRobin, PolicyWeighted:
+	default:
+		return nil, fmt.Errorf("unsupported replica policy: %s", cfg.Policy)
+	}
+
+	replicas := make([]*Replica, 0, len(cfg.Replicas))
+	for _, rc := range cfg.Replicas {
+		user, password, dbName := rc.User, rc.Password, rc.DBName
+		if user == "" {
+			user, password = cfg.User, cfg.Password
+		}
+		if dbName == "" {
+			dbName = cfg.DBName
+		}
+
+		sqlDB, err := sql.Open("mysql", buildDSN(user, password, rc.Host, rc.Port, dbName))
+		if err != nil {
+			for _, r := range replicas {
+				_ = r.DB.Close()
+			}
+			return nil, fmt.Errorf("failed to open replica %s:%d: %w", rc.Host, rc.Port, err)
+		}
+		setPool(sqlDB, cfg)
+		replicas = append(replicas, &Replica{
+			Name:   fmt.Sprintf("%s:%d", rc.Host, rc.Port),
+			DB:     sqlDB,
+			Weight: rc.Weight,
+		})
+	}
+
+	resolver := NewResolver(cfg.Policy, replicas...)
+	resolver.CheckHealth(context.Background())
+	return resolver, nil
+}
+
+// buildDSN 构建 MySQL 连接串
+func buildDSN(user, password, host string, port int, dbName string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		user,
+		password,
+		host,
+		port,
+		dbName,
+	)
+}
+
+// setPool 设置连接池参数
+func setPool(sqlDB *sql.DB, cfg *config.DBConfig) {
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 }
 
 // GetDB 获取指定名称的数据库连接
@@ -130,7 +198,17 @@ func (d *Database) Rollback() *gorm.DB {
 	return d.db.Rollback()
 }
 
+// Resolver 获取读写分离路由，未配置副本时返回 nil
+func (d *Database) Resolver() *Resolver {
+	return d.resolver
+}
+
 func (d *Database) Close() error {
+	if d.resolver != nil {
+		if err := d.resolver.Close(); err != nil {
+			return err
+		}
+	}
 	sqlDB, err := d.db.DB()
 	if err != nil {
 		return err
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PolicyRoundRobin 轮询选择副本
+	PolicyRoundRobin = "round_robin"
+	// PolicyWeighted 按权重选择副本（平滑加权轮询）
+	PolicyWeighted = "weighted"
+
+	// DefaultHealthCheckInterval 默认副本健康检查间隔
+	DefaultHealthCheckInterval = 10 * time.Second
+
+	resolverName = "fiber_web:resolver"
+)
+
+// primaryKey 强制主库读的上下文键
+type primaryKey struct{}
+
+// WithPrimary 标记上下文中的读操作强制使用主库，用于写后立即读等不能容忍复制延迟的场景
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsePrimary 判断上下文是否要求使用主库
+func UsePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// Replica 只读副本
+type Replica struct {
+	Name   string  // 名称，用于健康状态查询
+	DB     *sql.DB // 副本连接
+	Weight int     // 权重，仅 weighted 策略生效，小于等于 0 时为 1
+
+	healthy atomic.Bool
+	current int // 平滑加权轮询的当前权重
+}
+
+// Resolver 读写分离路由，以 GORM 插件的形式注册
+// 查询（Find/First/Scan/Count 等）路由到健康的副本，写操作、事务、加锁读和 WithPrimary 标记的读操作使用主库；
+// 没有健康的副本时回退到主库。
+type Resolver struct {
+	primary  gorm.ConnPool
+	replicas []*Replica
+	policy   string
+	next     atomic.Uint64
+	mu       sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewResolver 创建读写分离路由，policy 为空时使用轮询
+func NewResolver(policy string, replicas ...*Replica) *Resolver {
+	if policy == "" {
+		policy = PolicyRoundRobin
+	}
+	for _, r := range replicas {
+		if r.Weight <= 0 {
+			r.Weight = 1
+		}
+		r.healthy.Store(true)
+	}
+	return &Resolver{
+		replicas: replicas,
+		policy:   policy,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Name 实现 gorm.Plugin 接口
+func (r *Resolver) Name() string {
+	return resolverName
+}
+
+// Initialize 实现 gorm.Plugin 接口，注册连接路由回调
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+
+	if err := db.Callback().Query().Before("gorm:query").Register(resolverName, r.routeRead); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(resolverName, r.routeRead); err != nil {
+		return err
+	}
+	// 同一个 *gorm.DB 实例先读后写时，写操作需要切回主库；须在默认事务开启之前切换
+	if err := db.Callback().Create().Before("gorm:begin_transaction").Register(resolverName, r.routeWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:begin_transaction").Register(resolverName, r.routeWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:begin_transaction").Register(resolverName, r.routeWrite); err != nil {
+		return err
+	}
+	return db.Callback().Raw().Before("gorm:raw").Register(resolverName, r.routeWrite)
+}
+
+// routeRead 为读操作选择连接
+func (r *Resolver) routeRead(db *gorm.DB) {
+	if inTransaction(db) {
+		return
+	}
+	// SELECT ... FOR UPDATE/SHARE 必须在主库执行
+	if _, locking := db.Statement.Clauses["FOR"]; locking || UsePrimary(db.Statement.Context) {
+		r.usePrimary(db)
+		return
+	}
+	if replica := r.pick(); replica != nil {
+		db.Statement.ConnPool = replica.DB
+		return
+	}
+	r.usePrimary(db)
+}
+
+// routeWrite 写操作使用主库
+func (r *Resolver) routeWrite(db *gorm.DB) {
+	if !inTransaction(db) {
+		r.usePrimary(db)
+	}
+}
+
+// usePrimary 切换到主库连接
+func (r *Resolver) usePrimary(db *gorm.DB) {
+	if r.primary != nil {
+		db.Statement.ConnPool = r.primary
+	}
+}
+
+// inTransaction 判断是否在事务中，事务内的语句始终使用事务连接
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// pick 按策略从健康的副本中选择一个，没有健康的副本时返回 nil
+func (r *Resolver) pick() *Replica {
+	healthy := make([]*Replica, 0, len(r.replicas))
+	for _, replica := range r.replicas {
+		if replica.healthy.Load() {
+			healthy = append(healthy, replica)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if r.policy != PolicyWeighted {
+		n := r.next.Add(1)
+		return healthy[(n-1)%uint64(len(healthy))]
+	}
+
+	// 平滑加权轮询：每轮所有副本加上自身权重，选出当前权重最大者并减去总权重
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var best *Replica
+	total := 0
+	for _, replica := range healthy {
+		replica.current += replica.Weight
+		total += replica.Weight
+		if best == nil || replica.current > best.current {
+			best = replica
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// CheckHealth 检查所有副本的连接状态并更新健康标记
+func (r *Resolver) CheckHealth(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, time.Second)
+		replica.healthy.Store(replica.DB.PingContext(pingCtx) == nil)
+		cancel()
+	}
+}
+
+// StartHealthCheck 启动后台健康检查，interval 小于等于 0 时使用 DefaultHealthCheckInterval
+func (r *Resolver) StartHealthCheck(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.CheckHealth(context.Background())
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Healthy 获取各副本的健康状态，键为副本名称
+func (r *Resolver) Healthy() map[string]bool {
+	status := make(map[string]bool, len(r.replicas))
+	for _, replica := range r.replicas {
+		status[replica.Name] = replica.healthy.Load()
+	}
+	return status
+}
+
+// Close 停止健康检查并关闭副本连接
+func (r *Resolver) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	var errs []error
+	for _, replica := range r.replicas {
+		if err := replica.DB.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// resolverTestItem 测试模型，主库与副本写入不同的数据以区分路由
+type resolverTestItem struct {
+	ID   uint
+	Name string
+}
+
+// openTestDB 创建内存 SQLite 数据库并写入一条记录
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&resolverTestItem{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if err := db.Create(&resolverTestItem{ID: 1, Name: name}).Error; err != nil {
+		t.Fatalf("写入数据失败: %v", err)
+	}
+	return db
+}
+
+// setupResolver 创建一主一从的测试数据库
+func setupResolver(t *testing.T) (*gorm.DB, *Resolver) {
+	primary := openTestDB(t, "primary")
+	replicaDB, err := openTestDB(t, "replica").DB()
+	if err != nil {
+		t.Fatalf("获取副本实例失败: %v", err)
+	}
+	resolver := NewResolver(PolicyRoundRobin, &Replica{Name: "replica", DB: replicaDB})
+	if err := primary.Use(resolver); err != nil {
+		t.Fatalf("注册路由失败: %v", err)
+	}
+	t.Cleanup(func() { _ = resolver.Close() })
+	return primary, resolver
+}
+
+// readName 读取 ID 为 1 的记录名称，用于判断读操作落在哪个库
+func readName(t *testing.T, db *gorm.DB) string {
+	var item resolverTestItem
+	if err := db.First(&item, 1).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	return item.Name
+}
+
+func TestResolverRouting(t *testing.T) {
+	db, resolver := setupResolver(t)
+	ctx := context.Background()
+
+	if got := readName(t, db.WithContext(ctx)); got != "replica" {
+		t.Errorf("读操作 = %s, want replica", got)
+	}
+	if got := readName(t, db.WithContext(WithPrimary(ctx))); got != "primary" {
+		t.Errorf("WithPrimary 读操作 = %s, want primary", got)
+	}
+	if got := readName(t, db.Clauses(clause.Locking{Strength: "UPDATE"})); got != "primary" {
+		t.Errorf("加锁读 = %s, want primary", got)
+	}
+
+	// 写操作走主库，写入后副本中不存在
+	if err := db.Create(&resolverTestItem{ID: 2, Name: "new"}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	var count int64
+	db.Model(&resolverTestItem{}).Where("id = ?", 2).Count(&count)
+	if count != 0 {
+		t.Errorf("副本 Count() = %d, want 0", count)
+	}
+	db.WithContext(WithPrimary(ctx)).Model(&resolverTestItem{}).Where("id = ?", 2).Count(&count)
+	if count != 1 {
+		t.Errorf("主库 Count() = %d, want 1", count)
+	}
+
+	// 事务内读写都使用事务连接
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if got := readName(t, tx); got != "primary" {
+			t.Errorf("事务内读操作 = %s, want primary", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+
+	// 语句连接已指向副本时，写操作和强制主库读需切回主库
+	leaked := db.Session(&gorm.Session{NewDB: true})
+	leaked.Statement.ConnPool = resolver.replicas[0].DB
+	if err := leaked.Model(&resolverTestItem{ID: 1}).Update("name", "updated").Error; err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := readName(t, leaked.WithContext(WithPrimary(ctx))); got != "updated" {
+		t.Errorf("主库更新后 = %s, want updated", got)
+	}
+}
+
+func TestResolverFallback(t *testing.T) {
+	db, resolver := setupResolver(t)
+
+	// 副本不可用时健康检查将其摘除，读操作回退主库
+	_ = resolver.replicas[0].DB.Close()
+	resolver.CheckHealth(context.Background())
+	if resolver.Healthy()["replica"] {
+		t.Fatal("关闭的副本应标记为不健康")
+	}
+	if got := readName(t, db); got != "primary" {
+		t.Errorf("无健康副本时读操作 = %s, want primary", got)
+	}
+}
+
+func TestResolverWeighted(t *testing.T) {
+	a, b := &Replica{Name: "a", Weight: 3}, &Replica{Name: "b"}
+	resolver := NewResolver(PolicyWeighted, a, b)
+
+	picks := map[string]int{}
+	for i := 0; i < 8; i++ {
+		picks[resolver.pick().Name]++
+	}
+	if picks["a"] != 6 || picks["b"] != 2 {
+		t.Errorf("加权选择 = %v, want a:6 b:2", picks)
+	}
+
+	b.healthy.Store(false)
+	for i := 0; i < 4; i++ {
+		if got := resolver.pick().Name; got != "a" {
+			t.Errorf("不健康副本不应被选择, got %s", got)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTxRetries 死锁时默认重试次数
+	DefaultTxRetries = 3
+	// DefaultTxBackoff 默认重试基础间隔，第 n 次重试等待 n 倍间隔
+	DefaultTxBackoff = 20 * time.Millisecond
+
+	// mysqlErrDeadlock MySQL 死锁错误码
+	mysqlErrDeadlock = 1213
+	// mysqlErrLockWaitTimeout MySQL 锁等待超时错误码
+	mysqlErrLockWaitTimeout = 1205
+)
+
+// TxManager 事务管理器
+type TxManager interface {
+	// Transaction 在事务中执行 fn，fn 返回错误或 panic 时回滚
+	// 事务通过 fn 的 ctx 传递，使用该 ctx 的仓储和查询器自动加入事务；ctx 中已有事务时嵌套执行。
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txKey 事务上下文键
+type txKey struct{}
+
+// txState 上下文中的事务状态
+type txState struct {
+	tx     *gorm.DB  // GORM 事务连接，MongoDB 事务为 nil
+	hooks  *[]func() // 提交后执行的回调，保存点共享所在事务的回调列表
+	parent *txState  // 外层的其他事务，如 MySQL 事务中开启的 MongoDB 事务
+}
+
+// txFor 由内向外查找 db 所在数据库的事务连接
+func (s *txState) txFor(db *gorm.DB) *gorm.DB {
+	for ; s != nil; s = s.parent {
+		// 同一个 gorm.Open 创建的会话共享连接池，据此避免加入其他数据库的事务
+		if s.tx != nil && s.tx.ConnPool == db.ConnPool {
+			return s.tx
+		}
+	}
+	return nil
+}
+
+// commit 事务提交后执行回调；处于外层事务中时转交外层，待最外层提交后执行
+func (s *txState) commit(hooks []func()) {
+	if s != nil {
+		*s.hooks = append(*s.hooks, hooks...)
+		return
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// stateFrom 获取上下文中的事务状态
+func stateFrom(ctx context.Context) *txState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(txKey{}).(*txState)
+	return state
+}
+
+// InTransaction 判断上下文是否处于事务中
+func InTransaction(ctx context.Context) bool {
+	return stateFrom(ctx) != nil
+}
+
+// TxFromContext 获取上下文中最内层的 GORM 事务连接
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	for state := stateFrom(ctx); state != nil; state = state.parent {
+		if state.tx != nil {
+			return state.tx, true
+		}
+	}
+	return nil, false
+}
+
+// Conn 获取绑定 ctx 的连接：ctx 中有同一数据库的事务时返回事务连接，否则返回 db
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil {
+		return db
+	}
+	if tx := stateFrom(ctx).txFor(db); tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// AfterCommit 注册最外层事务提交后执行的回调，回滚时丢弃；不在事务中时立即执行
+// 适用于缓存失效、内存状态刷新等不能在提交前执行的副作用。
+func AfterCommit(ctx context.Context, fn func()) {
+	if state := stateFrom(ctx); state != nil {
+		*state.hooks = append(*state.hooks, fn)
+		return
+	}
+	fn()
+}
+
+// IsDeadlock 判断是否为可重试的 MySQL 死锁或锁等待超时错误
+func IsDeadlock(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+	return false
+}
+
+// TxOptions 事务管理器配置
+type TxOptions struct {
+	MaxRetries int              // 最外层事务失败时的最大重试次数，默认 DefaultTxRetries，小于 0 表示不重试
+	Backoff    time.Duration    // 重试基础间隔，默认 DefaultTxBackoff
+	RetryIf    func(error) bool // 判断错误是否可重试，默认 IsDeadlock
+}
+
+// GormTxManager 基于 GORM 的事务管理器，嵌套事务使用保存点
+type GormTxManager struct {
+	db   *gorm.DB
+	opts TxOptions
+}
+
+// NewTxManager 创建 GORM 事务管理器
+func NewTxManager(db *gorm.DB, opts TxOptions) *GormTxManager {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultTxRetries
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultTxBackoff
+	}
+	if opts.RetryIf == nil {
+		opts.RetryIf = IsDeadlock
+	}
+	return &GormTxManager{db: db, opts: opts}
+}
+
+// Transaction 实现 TxManager 接口
+// 最外层事务遇到可重试错误时整体重试 fn，因此 fn 应当可以重复执行；嵌套事务失败时只回滚到保存点，不重试。
+// 在其他数据库的事务中开启时，提交后的回调待外层事务提交后执行。
+func (m *GormTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent := stateFrom(ctx)
+	if outer := parent.txFor(m.db); outer != nil {
+		mark := len(*parent.hooks)
+		err := outer.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, &txState{tx: tx, hooks: parent.hooks, parent: parent}))
+		})
+		if err != nil {
+			// 回滚到保存点后丢弃该层注册的回调
+			*parent.hooks = (*parent.hooks)[:mark]
+		}
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		var hooks []func()
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, &txState{tx: tx, hooks: &hooks, parent: parent}))
+		})
+		if err == nil {
+			parent.commit(hooks)
+			return nil
+		}
+		if attempt >= m.opts.MaxRetries || !m.opts.RetryIf(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(m.opts.Backoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// MongoTxManager 基于 MongoDB 会话的事务管理器
+// MongoDB 不支持保存点，嵌套调用直接加入外层事务；瞬时错误由驱动按 TransientTransactionError 标签重试。
+type MongoTxManager struct {
+	client *mongo.Client
+}
+
+// NewMongoTxManager 创建 MongoDB 事务管理器
+func NewMongoTxManager(client *mongo.Client) *MongoTxManager {
+	if client == nil {
+		panic("client cannot be nil")
+	}
+	return &MongoTxManager{client: client}
+}
+
+// Transaction 实现 TxManager 接口
+// 在 MySQL 事务中开启时，fn 中的 MySQL 操作仍加入外层事务，提交后的回调待外层事务提交后执行。
+func (m *MongoTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil && InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	parent := stateFrom(ctx)
+	var hooks []func()
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// 驱动重试时丢弃上一次注册的回调
+		hooks = hooks[:0]
+		return nil, fn(context.WithValue(sc, txKey{}, &txState{hooks: &hooks, parent: parent}))
+	})
+	if err != nil {
+		return err
+	}
+	parent.commit(hooks)
+	return nil
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// countItems 统计测试表记录数
+func countItems(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	if err := db.Model(&resolverTestItem{}).Count(&count).Error; err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	return count
+}
+
+func TestTxManagerCommitAndRollback(t *testing.T) {
+	db := openTestDB(t, "primary")
+	tm := NewTxManager(db, TxOptions{})
+	ctx := context.Background()
+
+	err := tm.Transaction(ctx, func(ctx context.Context) error {
+		if !InTransaction(ctx) {
+			t.Error("事务内 InTransaction() 应为 true")
+		}
+		return Conn(ctx, db).Create(&resolverTestItem{Name: "a"}).Error
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if got := countItems(t, db); got != 2 {
+		t.Errorf("提交后记录数 = %d, want 2", got)
+	}
+
+	errFail := errors.New("fail")
+	err = tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := Conn(ctx, db).Create(&resolverTestItem{Name: "b"}).Error; err != nil {
+			return err
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Transaction() error = %v, want %v", err, errFail)
+	}
+	if got := countItems(t, db); got != 2 {
+		t.Errorf("回滚后记录数 = %d, want 2", got)
+	}
+}
+
+func TestTxManagerNested(t *testing.T) {
+	db := openTestDB(t, "primary")
+	tm := NewTxManager(db, TxOptions{})
+	ctx := context.Background()
+
+	var hooks []string
+	err := tm.Transaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { hooks = append(hooks, "outer") })
+		if err := Conn(ctx, db).Create(&resolverTestItem{Name: "outer"}).Error; err != nil {
+			return err
+		}
+
+		// 内层失败只回滚到保存点，注册的回调被丢弃
+		inner := tm.Transaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { hooks = append(hooks, "inner") })
+			if err := Conn(ctx, db).Create(&resolverTestItem{Name: "inner"}).Error; err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		if inner == nil {
+			t.Error("内层事务应返回错误")
+		}
+		if len(hooks) != 0 {
+			t.Error("提交前不应执行回调")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+
+	var names []string
+	db.Model(&resolverTestItem{}).Order("id").Pluck("name", &names)
+	if len(names) != 2 || names[1] != "outer" {
+		t.Errorf("嵌套事务后记录 = %v, want [primary outer]", names)
+	}
+	if len(hooks) != 1 || hooks[0] != "outer" {
+		t.Errorf("提交后回调 = %v, want [outer]", hooks)
+	}
+
+	// 不在事务中时立即执行
+	ran := false
+	AfterCommit(ctx, func() { ran = true })
+	if !ran {
+		t.Error("事务外 AfterCommit() 应立即执行")
+	}
+}
+
+func TestTxManagerRetry(t *testing.T) {
+	db := openTestDB(t, "primary")
+	ctx := context.Background()
+	deadlock := &mysqldriver.MySQLError{Number: mysqlErrDeadlock, Message: "Deadlock found"}
+
+	tests := []struct {
+		name         string
+		failures     int
+		maxRetries   int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"重试后成功", 2, 3, 3, false},
+		{"超过重试次数", 5, 2, 3, true},
+		{"不重试", 1, -1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := NewTxManager(db, TxOptions{MaxRetries: tt.maxRetries, Backoff: 1})
+			attempts := 0
+			err := tm.Transaction(ctx, func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return deadlock
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr || attempts != tt.wantAttempts {
+				t.Errorf("Transaction() error = %v, attempts = %d, want err %v, attempts %d", err, attempts, tt.wantErr, tt.wantAttempts)
+			}
+		})
+	}
+
+	if IsDeadlock(errors.New("other")) || !IsDeadlock(deadlock) {
+		t.Error("IsDeadlock() 判断错误")
+	}
+}
+
+func TestTxManagerAcrossDatabases(t *testing.T) {
+	db, other := openTestDB(t, "primary"), openTestDB(t, "other")
+	tm, otherTM := NewTxManager(db, TxOptions{}), NewTxManager(other, TxOptions{})
+
+	var hooks []string
+	errFail := errors.New("outer failed")
+	err := tm.Transaction(context.Background(), func(ctx context.Context) error {
+		if err := otherTM.Transaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { hooks = append(hooks, "inner") })
+			// 内层事务中外层数据库的写入仍加入外层事务
+			if err := Conn(ctx, db).Create(&resolverTestItem{Name: "outer"}).Error; err != nil {
+				return err
+			}
+			return Conn(ctx, other).Create(&resolverTestItem{Name: "inner"}).Error
+		}); err != nil {
+			return err
+		}
+		if len(hooks) != 0 {
+			t.Error("外层事务提交前不应执行内层回调")
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Transaction() error = %v, want %v", err, errFail)
+	}
+	if got := countItems(t, db); got != 1 {
+		t.Errorf("外层回滚后记录数 = %d, want 1", got)
+	}
+	if got := countItems(t, other); got != 2 {
+		t.Errorf("内层已提交的记录数 = %d, want 2", got)
+	}
+	if len(hooks) != 0 {
+		t.Errorf("外层回滚后回调 = %v, want 空", hooks)
+	}
+
+	// 外层提交后执行内层回调
+	err = tm.Transaction(context.Background(), func(ctx context.Context) error {
+		return otherTM.Transaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { hooks = append(hooks, "inner") })
+			return nil
+		})
+	})
+	if err != nil || len(hooks) != 1 {
+		t.Errorf("Transaction() error = %v, hooks = %v, want [inner]", err, hooks)
+	}
+}
+
+func TestConnOtherDatabase(t *testing.T) {
+	db, other := openTestDB(t, "primary"), openTestDB(t, "other")
+	tm := NewTxManager(db, TxOptions{})
+
+	// 其他数据库的连接不加入当前事务
+	err := tm.Transaction(context.Background(), func(ctx context.Context) error {
+		if tx, _ := TxFromContext(ctx); Conn(ctx, other).Statement.ConnPool == tx.Statement.ConnPool {
+			t.Error("其他数据库不应加入事务")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fiber_web/pkg/logger"
+	"sync"
+	"time"
+)
+
+// ErrLockLost 续期失败，锁已过期或被其他持有者获得
+var ErrLockLost = errors.New("lock lost")
+
+// LockGuard 已获得的锁，后台每隔 TTL/3 自动续期直到 Release
+// 续期发现锁已丢失时取消 Context，持锁执行的任务应使用 Context 并在其取消时停止写入。
+type LockGuard struct {
+	lock  Lock
+	key   string
+	value any
+	ttl   time.Duration
+	token int64
+
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	done        chan struct{}
+	releaseOnce sync.Once
+	releaseErr  error
+}
+
+// NewLockGuard 为已获得的锁创建守护并开始自动续期，ctx 取消时停止续期
+func NewLockGuard(ctx context.Context, l Lock, key string, value any, ttl time.Duration, token int64) *LockGuard {
+	gctx, cancel := context.WithCancelCause(ctx)
+	g := &LockGuard{
+		lock:   l,
+		key:    key,
+		value:  value,
+		ttl:    ttl,
+		token:  token,
+		ctx:    gctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go g.watch()
+	return g
+}
+
+// Token 栅栏令牌
+func (g *LockGuard) Token() int64 {
+	return g.token
+}
+
+// Context 持锁期间有效的上下文，锁丢失、Release 或父上下文取消时取消
+func (g *LockGuard) Context() context.Context {
+	return g.ctx
+}
+
+// Err 锁已丢失时返回 ErrLockLost
+func (g *LockGuard) Err() error {
+	if errors.Is(context.Cause(g.ctx), ErrLockLost) {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release 停止续期并解锁，可重复调用；ctx 不能使用 Context()，其在释放时已取消
+func (g *LockGuard) Release(ctx context.Context) error {
+	g.releaseOnce.Do(func() {
+		g.cancel(nil)
+		<-g.done
+		g.releaseErr = g.lock.Unlock(ctx, g.key, g.value)
+	})
+	return g.releaseErr
+}
+
+// watch 定期续期，续期被拒绝或超过 TTL 未能续期时判定锁已丢失
+func (g *LockGuard) watch() {
+	defer close(g.done)
+
+	interval := max(g.ttl/3, time.Millisecond)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(g.ctx, interval)
+			err := g.lock.Refresh(ctx, g.key, g.value, g.ttl)
+			cancel()
+
+			switch {
+			case err == nil:
+				renewed = time.Now()
+			case g.ctx.Err() != nil:
+				return
+			case errors.Is(err, ErrNotOwner) || time.Since(renewed) >= g.ttl:
+				logger.Warn("Lock lost", logger.String("key", g.key), logger.ErrorField(err))
+				g.cancel(ErrLockLost)
+				return
+			default:
+				// 暂时性错误，锁过期前继续重试
+				logger.Warn("Failed to renew lock", logger.String("key", g.key), logger.ErrorField(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLock 测试用进程内锁，按 TTL 过期
+type memoryLock struct {
+	mu         sync.Mutex
+	owner      any
+	expire     time.Time
+	refreshes  int
+	refreshErr error // 续期返回的暂时性错误
+}
+
+func (l *memoryLock) held(value any) bool {
+	return l.owner == value && time.Now().Before(l.expire)
+}
+
+func (l *memoryLock) Lock(ctx context.Context, key string, value any, ttl time.Duration) (*LockGuard, error) {
+	token, ok, err := l.TryLock(ctx, key, value, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockFailed
+	}
+	return NewLockGuard(ctx, l, key, value, ttl, token), nil
+}
+
+func (l *memoryLock) TryLock(_ context.Context, _ string, value any, ttl time.Duration) (int64, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.owner != nil && time.Now().Before(l.expire) && l.owner != value {
+		return 0, false, nil
+	}
+	l.owner, l.expire = value, time.Now().Add(ttl)
+	return 1, true, nil
+}
+
+func (l *memoryLock) LockWithTimeout(ctx context.Context, key string, value any, ttl, _ time.Duration) (*LockGuard, error) {
+	return l.Lock(ctx, key, value, ttl)
+}
+
+func (l *memoryLock) Unlock(_ context.Context, _ string, value any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.held(value) {
+		return ErrNotOwner
+	}
+	l.owner = nil
+	return nil
+}
+
+func (l *memoryLock) Refresh(_ context.Context, _ string, value any, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refreshErr != nil {
+		return l.refreshErr
+	}
+	if !l.held(value) {
+		return ErrNotOwner
+	}
+	l.expire = time.Now().Add(ttl)
+	l.refreshes++
+	return nil
+}
+
+func (l *memoryLock) GetLockTTL(context.Context, string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Until(l.expire), nil
+}
+
+// waitLost 等待守护判定锁已丢失
+func waitLost(t *testing.T, g *LockGuard) {
+	t.Helper()
+	select {
+	case <-g.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("锁丢失后应取消 Context")
+	}
+	if !errors.Is(g.Err(), ErrLockLost) {
+		t.Errorf("Err() = %v, want %v", g.Err(), ErrLockLost)
+	}
+}
+
+func TestLockGuard(t *testing.T) {
+	ctx := context.Background()
+	const ttl = 60 * time.Millisecond
+
+	t.Run("持锁期间自动续期", func(t *testing.T) {
+		l := &memoryLock{}
+		g, err := l.Lock(ctx, "job", "a", ttl)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		time.Sleep(3 * ttl)
+		if _, ok, _ := l.TryLock(ctx, "job", "b", ttl); ok {
+			t.Fatal("超过 TTL 后锁仍应被持有")
+		}
+		if g.Err() != nil || g.Context().Err() != nil {
+			t.Errorf("续期成功时 Err() = %v, Context().Err() = %v", g.Err(), g.Context().Err())
+		}
+
+		if err := g.Release(ctx); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+		if err := g.Release(ctx); err != nil {
+			t.Errorf("重复 Release() error = %v", err)
+		}
+		l.mu.Lock()
+		refreshes := l.refreshes
+		l.mu.Unlock()
+		time.Sleep(ttl)
+		if l.refreshes != refreshes {
+			t.Error("Release() 后不应继续续期")
+		}
+		if g.Context().Err() == nil || g.Err() != nil {
+			t.Errorf("Release() 后 Context().Err() = %v, Err() = %v", g.Context().Err(), g.Err())
+		}
+		if _, ok, _ := l.TryLock(ctx, "job", "b", ttl); !ok {
+			t.Error("Release() 后其他持有者应能加锁")
+		}
+	})
+
+	t.Run("锁被其他持有者获得", func(t *testing.T) {
+		l := &memoryLock{}
+		g, err := l.Lock(ctx, "job", "a", ttl)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		l.mu.Lock()
+		l.owner = "b"
+		l.mu.Unlock()
+		waitLost(t, g)
+		if err := g.Release(ctx); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Release() error = %v, want %v", err, ErrNotOwner)
+		}
+	})
+
+	t.Run("超过TTL未能续期", func(t *testing.T) {
+		l := &memoryLock{}
+		g, err := l.Lock(ctx, "job", "a", ttl)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		l.mu.Lock()
+		l.refreshErr = errors.New("connection refused")
+		l.mu.Unlock()
+		waitLost(t, g)
+	})
+
+	t.Run("父上下文取消后停止续期", func(t *testing.T) {
+		l := &memoryLock{}
+		parent, cancel := context.WithCancel(ctx)
+		g, err := l.Lock(parent, "job", "a", ttl)
+		if err != nil {
+			t.Fatalf("Lock() error = %v", err)
+		}
+		cancel()
+		<-g.Context().Done()
+		if g.Err() != nil {
+			t.Errorf("父上下文取消时 Err() = %v, want nil", g.Err())
+		}
+		if err := g.Release(ctx); err != nil {
+			t.Errorf("Release() error = %v", err)
+		}
+	})
+}
@@ -2,26 +2,42 @@ package lock
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
 	"time"
 )
 
 // Lock 分布式锁接口
+// value 标识锁的持有者，只有持有者才能解锁与刷新；同一持有者可重复加锁，解锁相同次数后释放。
+// 加锁成功返回栅栏令牌，每次新获得锁时单调递增，重入时不变；下游写入时携带令牌，拒绝小于已见过令牌的请求，
+// 可避免锁过期后仍在执行的旧持有者覆盖新持有者的写入。
 type Lock interface {
-	// Lock 加锁
-	Lock(ctx context.Context, key string, value any, ttl time.Duration) error
+	// Lock 加锁，锁被占用时返回 ErrLockFailed；返回的 LockGuard 自动续期直到 Release
+	Lock(ctx context.Context, key string, value any, ttl time.Duration) (*LockGuard, error)
 
-	// TryLock 尝试加锁,立即返回结果
-	TryLock(ctx context.Context, key string, value any, ttl time.Duration) (bool, error)
+	// TryLock 尝试加锁,立即返回结果，未获得锁时令牌为 0；不自动续期
+	TryLock(ctx context.Context, key string, value any, ttl time.Duration) (int64, bool, error)
 
-	// LockWithTimeout 在指定时间内尝试加锁
-	LockWithTimeout(ctx context.Context, key string, value any, ttl time.Duration, timeout time.Duration) error
+	// LockWithTimeout 在指定时间内尝试加锁，返回的 LockGuard 自动续期直到 Release
+	LockWithTimeout(ctx context.Context, key string, value any, ttl time.Duration, timeout time.Duration) (*LockGuard, error)
 
-	// Unlock 解锁
-	Unlock(ctx context.Context, key string) error
+	// Unlock 解锁，重入时持有计数减一，计数归零时释放；value 不是持有者时返回 ErrNotOwner
+	Unlock(ctx context.Context, key string, value any) error
 
-	// Refresh 刷新锁的过期时间
-	Refresh(ctx context.Context, key string, ttl time.Duration) error
+	// Refresh 刷新锁的过期时间，value 不是持有者时返回 ErrNotOwner
+	Refresh(ctx context.Context, key string, value any, ttl time.Duration) error
 
 	// GetLockTTL 获取锁的剩余过期时间
 	GetLockTTL(ctx context.Context, key string) (time.Duration, error)
 }
+
+// NewOwner 生成锁持有者标识：主机名:随机数
+func NewOwner() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	return host + ":" + hex.EncodeToString(b), nil
+}
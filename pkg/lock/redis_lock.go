@@ -11,24 +11,57 @@ import (
 var (
 	ErrLockFailed  = errors.New("failed to acquire lock")
 	ErrLockTimeout = errors.New("lock timeout")
+	ErrNotOwner    = errors.New("lock not held by owner")
 )
 
+// RedisLock 基于 Redis 的可重入分布式锁
+// 锁保存为 Hash（owner 持有者、count 持有计数、token 栅栏令牌），栅栏令牌由 {key}:fence 计数器生成且不过期。
+// Redis Cluster 下 key 需使用 hash tag（如 {order:1}），保证锁与计数器位于同一槽。
+// 旧版本以字符串保存锁，滚动升级期间字符串值的锁视为被其他持有者占用，直到其过期，避免 WRONGTYPE 错误。
 type RedisLock struct {
 	client *redisClient.Client
 }
 
 const (
+	// KEYS[1] 锁，KEYS[2] 栅栏令牌计数器；ARGV[1] 持有者，ARGV[2] 过期时间（毫秒）
+	// 返回栅栏令牌，锁被其他持有者占用时返回 0
 	lockScript = `
-		if redis.call("exists", KEYS[1]) == 0 then
-			return redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+		local kind = redis.call("type", KEYS[1]).ok
+		if kind ~= "none" and kind ~= "hash" then
+			return 0
 		end
-		return false`
+		local owner = redis.call("hget", KEYS[1], "owner")
+		if owner == false then
+			local token = redis.call("incr", KEYS[2])
+			redis.call("hset", KEYS[1], "owner", ARGV[1], "count", 1, "token", token)
+			redis.call("pexpire", KEYS[1], ARGV[2])
+			return token
+		end
+		if owner == ARGV[1] then
+			redis.call("hincrby", KEYS[1], "count", 1)
+			redis.call("pexpire", KEYS[1], ARGV[2])
+			return tonumber(redis.call("hget", KEYS[1], "token"))
+		end
+		return 0`
 
+	// 返回剩余持有计数，0 表示已释放，-1 表示不是持有者或锁已过期
 	unlockScript = `
-		if redis.call("exists", KEYS[1]) == 1 then
-			return redis.call("del", KEYS[1])
+		if redis.call("type", KEYS[1]).ok ~= "hash" or redis.call("hget", KEYS[1], "owner") ~= ARGV[1] then
+			return -1
+		end
+		local count = redis.call("hincrby", KEYS[1], "count", -1)
+		if count <= 0 then
+			redis.call("del", KEYS[1])
+			return 0
+		end
+		return count`
+
+	// 返回 1 表示已刷新，0 表示不是持有者或锁已过期
+	refreshScript = `
+		if redis.call("type", KEYS[1]).ok ~= "hash" or redis.call("hget", KEYS[1], "owner") ~= ARGV[1] then
+			return 0
 		end
-		return false`
+		return redis.call("pexpire", KEYS[1], ARGV[2])`
 )
 
 const defaultRetryInterval = 100 * time.Millisecond
@@ -40,38 +73,52 @@ func NewRedisLock(client *redisClient.Client) Lock {
 	}
 }
 
+// fenceKey 栅栏令牌计数器的键
+func fenceKey(key string) string {
+	return key + ":fence"
+}
+
 // Lock 加锁
-func (l *RedisLock) Lock(ctx context.Context, key string, value any, ttl time.Duration) error {
-	ok, err := l.TryLock(ctx, key, value, ttl)
+func (l *RedisLock) Lock(ctx context.Context, key string, value any, ttl time.Duration) (*LockGuard, error) {
+	token, ok, err := l.TryLock(ctx, key, value, ttl)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !ok {
-		return ErrLockFailed
+		return nil, ErrLockFailed
 	}
-	return nil
+	return NewLockGuard(ctx, l, key, value, ttl, token), nil
 }
 
 // TryLock 尝试加锁
-func (l *RedisLock) TryLock(ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
-	result, err := l.client.Eval(ctx, lockScript, []string{key}, value, ttl.Milliseconds()).Result()
+func (l *RedisLock) TryLock(ctx context.Context, key string, value any, ttl time.Duration) (int64, bool, error) {
+	token, err := l.client.Eval(ctx, lockScript, []string{key, fenceKey(key)}, value, ttl.Milliseconds()).Int64()
 	if err != nil {
-		return false, l.handleError("TryLock", key, err)
+		return 0, false, l.handleError("TryLock", key, err)
 	}
-	return result != false, nil
+	return token, token > 0, nil
 }
 
 // LockWithTimeout 在指定时间内尝试加锁
-func (l *RedisLock) LockWithTimeout(ctx context.Context, key string, value any, ttl time.Duration, timeout time.Duration) error {
+func (l *RedisLock) LockWithTimeout(ctx context.Context, key string, value any, ttl time.Duration, timeout time.Duration) (*LockGuard, error) {
+	token, err := l.waitLock(ctx, key, value, ttl, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewLockGuard(ctx, l, key, value, ttl, token), nil
+}
+
+// waitLock 重试加锁直到成功或超时，返回栅栏令牌
+func (l *RedisLock) waitLock(ctx context.Context, key string, value any, ttl time.Duration, timeout time.Duration) (int64, error) {
 	deadline := time.Now().Add(timeout)
 
 	// 先尝试一次加锁
-	ok, err := l.TryLock(ctx, key, value, ttl)
+	token, ok, err := l.TryLock(ctx, key, value, ttl)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if ok {
-		return nil
+		return token, nil
 	}
 
 	// 使用动态重试间隔
@@ -82,14 +129,14 @@ func (l *RedisLock) LockWithTimeout(ctx context.Context, key string, value any,
 	for time.Now().Before(deadline) {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return 0, ctx.Err()
 		case <-ticker.C:
-			ok, err := l.TryLock(ctx, key, value, ttl)
+			token, ok, err := l.TryLock(ctx, key, value, ttl)
 			if err != nil {
-				return err
+				return 0, err
 			}
 			if ok {
-				return nil
+				return token, nil
 			}
 
 			// 动态调整重试间隔（可选）
@@ -98,19 +145,31 @@ func (l *RedisLock) LockWithTimeout(ctx context.Context, key string, value any,
 		}
 	}
 
-	return ErrLockTimeout
+	return 0, ErrLockTimeout
 }
 
 // Unlock 解锁
-func (l *RedisLock) Unlock(ctx context.Context, key string) error {
-	_, err := l.client.Eval(ctx, unlockScript, []string{key}).Result()
-	return l.handleError("Unlock", key, err)
+func (l *RedisLock) Unlock(ctx context.Context, key string, value any) error {
+	remaining, err := l.client.Eval(ctx, unlockScript, []string{key}, value).Int64()
+	if err != nil {
+		return l.handleError("Unlock", key, err)
+	}
+	if remaining < 0 {
+		return ErrNotOwner
+	}
+	return nil
 }
 
 // Refresh 刷新锁的过期时间
-func (l *RedisLock) Refresh(ctx context.Context, key string, ttl time.Duration) error {
-	err := l.client.Expire(ctx, key, ttl)
-	return l.handleError("Refresh", key, err)
+func (l *RedisLock) Refresh(ctx context.Context, key string, value any, ttl time.Duration) error {
+	refreshed, err := l.client.Eval(ctx, refreshScript, []string{key}, value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return l.handleError("Refresh", key, err)
+	}
+	if refreshed == 0 {
+		return ErrNotOwner
+	}
+	return nil
 }
 
 // GetLockTTL 获取锁的剩余过期时间
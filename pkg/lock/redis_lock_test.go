@@ -0,0 +1,139 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/redis"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestRedisLock 连接 REDIS_ADDR 指定的 Redis，锁依赖 Lua 脚本，未设置时跳过
+func newTestRedisLock(t *testing.T) (*RedisLock, *redis.Client) {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("未设置 REDIS_ADDR，跳过 Redis 锁测试")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("REDIS_ADDR 格式错误: %v", err)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatalf("REDIS_ADDR 端口错误: %v", err)
+	}
+	manager, err := redis.NewRedisManager(&config.RedisConfig{
+		Default: config.RedisInstanceConfig{Host: host, Port: portNum},
+	})
+	if err != nil {
+		t.Fatalf("连接 Redis 失败: %v", err)
+	}
+	client, err := manager.GetClient("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisLock(client).(*RedisLock), client
+}
+
+// testLockKey 每个测试使用独立的键，结束时清理锁与栅栏令牌计数器
+func testLockKey(t *testing.T, client *redis.Client) string {
+	t.Helper()
+	key := "test:lock:{" + t.Name() + ":" + strconv.FormatInt(time.Now().UnixNano(), 10) + "}"
+	t.Cleanup(func() { _ = client.Delete(context.Background(), key, fenceKey(key)) })
+	return key
+}
+
+func TestRedisLock(t *testing.T) {
+	l, client := newTestRedisLock(t)
+	ctx := context.Background()
+	const ttl = 5 * time.Second
+
+	t.Run("非持有者不能解锁与续期", func(t *testing.T) {
+		key := testLockKey(t, client)
+		if _, ok, err := l.TryLock(ctx, key, "a", ttl); err != nil || !ok {
+			t.Fatalf("TryLock(a) = %v, %v", ok, err)
+		}
+		if _, ok, err := l.TryLock(ctx, key, "b", ttl); err != nil || ok {
+			t.Errorf("锁被占用时 TryLock(b) = %v, %v, want false", ok, err)
+		}
+		if err := l.Unlock(ctx, key, "b"); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Unlock(b) error = %v, want %v", err, ErrNotOwner)
+		}
+		if err := l.Refresh(ctx, key, "b", ttl); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Refresh(b) error = %v, want %v", err, ErrNotOwner)
+		}
+		if err := l.Unlock(ctx, key, "a"); err != nil {
+			t.Errorf("Unlock(a) error = %v", err)
+		}
+		if err := l.Unlock(ctx, key, "a"); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("已释放后 Unlock(a) error = %v, want %v", err, ErrNotOwner)
+		}
+	})
+
+	t.Run("可重入锁在计数归零时释放且令牌不变", func(t *testing.T) {
+		key := testLockKey(t, client)
+		first, ok, err := l.TryLock(ctx, key, "a", ttl)
+		if err != nil || !ok {
+			t.Fatalf("TryLock(a) = %v, %v", ok, err)
+		}
+		second, ok, err := l.TryLock(ctx, key, "a", ttl)
+		if err != nil || !ok {
+			t.Fatalf("重入 TryLock(a) = %v, %v", ok, err)
+		}
+		if second != first {
+			t.Errorf("重入后令牌 = %d, want %d", second, first)
+		}
+		if err := l.Unlock(ctx, key, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, _ := l.TryLock(ctx, key, "b", ttl); ok {
+			t.Error("解锁次数少于加锁次数时不应释放")
+		}
+		if err := l.Unlock(ctx, key, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := l.TryLock(ctx, key, "b", ttl); err != nil || !ok {
+			t.Errorf("计数归零后 TryLock(b) = %v, %v, want true", ok, err)
+		}
+	})
+
+	t.Run("每次重新加锁令牌递增", func(t *testing.T) {
+		key := testLockKey(t, client)
+		var last int64
+		for i, owner := range []string{"a", "b", "a"} {
+			token, ok, err := l.TryLock(ctx, key, owner, ttl)
+			if err != nil || !ok {
+				t.Fatalf("第 %d 次 TryLock(%s) = %v, %v", i+1, owner, ok, err)
+			}
+			if token <= last {
+				t.Errorf("第 %d 次加锁令牌 = %d, 应大于 %d", i+1, token, last)
+			}
+			last = token
+			if err := l.Unlock(ctx, key, owner); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("旧版本字符串锁视为被占用", func(t *testing.T) {
+		key := testLockKey(t, client)
+		if err := client.Set(ctx, key, "legacy", ttl); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := l.TryLock(ctx, key, "a", ttl); err != nil || ok {
+			t.Errorf("TryLock() = %v, %v, want false, nil", ok, err)
+		}
+		if err := l.Unlock(ctx, key, "legacy"); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Unlock() error = %v, want %v", err, ErrNotOwner)
+		}
+		if err := l.Refresh(ctx, key, "legacy", ttl); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Refresh() error = %v, want %v", err, ErrNotOwner)
+		}
+	})
+}
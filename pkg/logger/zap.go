@@ -683,6 +683,15 @@ func With(fields ...Field) *Logger {
 	return &Logger{}
 }
 
+// NewMongoLogger 创建只写入 MongoDB 集合的日志，用于审计等需要单独存储、按字段查询的日志
+func NewMongoLogger(mongoDB *database.MongoDB, collection string, opts ...Option) *Logger {
+	logger := &Logger{log: zap.New(NewMongoCore(mongoDB, collection), zap.AddCaller())}
+	for _, opt := range opts {
+		opt(logger)
+	}
+	return logger
+}
+
 // WithMongoDB 添加 MongoDB 日志支持
 func WithMongoDB(mongoDB *database.MongoDB, collection string) Option {
 	if collection == "" {
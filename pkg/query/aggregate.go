@@ -0,0 +1,146 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AggFunc 聚合函数
+type AggFunc string
+
+const (
+	AggCount         AggFunc = "count"          // 计数
+	AggSum           AggFunc = "sum"            // 求和
+	AggAvg           AggFunc = "avg"            // 平均值
+	AggMin           AggFunc = "min"            // 最小值
+	AggMax           AggFunc = "max"            // 最大值
+	AggCountDistinct AggFunc = "count_distinct" // 去重计数
+)
+
+// Aggregation 聚合项
+type Aggregation struct {
+	Func  AggFunc `json:"func"`  // 聚合函数
+	Field string  `json:"field"` // 聚合字段，count 可为空表示统计行数
+	Alias string  `json:"alias"` // 结果别名，为空时自动生成
+}
+
+// name 返回聚合结果的别名
+func (a Aggregation) name() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	if a.Field == "" {
+		return string(a.Func)
+	}
+	return string(a.Func) + "_" + strings.ReplaceAll(a.Field, ".", "_")
+}
+
+// Aggregate 追加聚合项
+func (q *Query) Aggregate(fn AggFunc, field, alias string) *Query {
+	q.Aggregations = append(q.Aggregations, Aggregation{Func: fn, Field: field, Alias: alias})
+	return q
+}
+
+// Count 追加行数统计
+func (q *Query) Count(alias string) *Query {
+	return q.Aggregate(AggCount, "", alias)
+}
+
+// Sum 追加求和
+func (q *Query) Sum(field, alias string) *Query {
+	return q.Aggregate(AggSum, field, alias)
+}
+
+// Avg 追加平均值
+func (q *Query) Avg(field, alias string) *Query {
+	return q.Aggregate(AggAvg, field, alias)
+}
+
+// Min 追加最小值
+func (q *Query) Min(field, alias string) *Query {
+	return q.Aggregate(AggMin, field, alias)
+}
+
+// Max 追加最大值
+func (q *Query) Max(field, alias string) *Query {
+	return q.Aggregate(AggMax, field, alias)
+}
+
+// CountDistinct 追加去重计数
+func (q *Query) CountDistinct(field, alias string) *Query {
+	return q.Aggregate(AggCountDistinct, field, alias)
+}
+
+// AddHaving 追加分组过滤条件，字段可以是聚合别名或分组字段
+func (q *Query) AddHaving(field string, operator Operator, value any) *Query {
+	q.Having = append(q.Having, Condition{
+		Field:    field,
+		Operator: operator,
+		Value:    value,
+	})
+	return q
+}
+
+// Aggregate 执行聚合查询并返回类型化的结果行
+// R 为结果行类型，字段需与分组字段和聚合别名对应：MySQL 按 GORM 列名映射，MongoDB 按 bson 标签映射。
+//
+//	type StatusStat struct {
+//		Status int   `bson:"status"`
+//		Total  int64 `bson:"total"`
+//	}
+//	rows, err := query.Aggregate[StatusStat](ctx, querier, q)
+func Aggregate[R any, T any](ctx context.Context, querier Querier[T], q *Query) ([]R, error) {
+	var rows []R
+	if err := querier.Aggregate(ctx, q, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// knownAggFuncs 支持的聚合函数
+var knownAggFuncs = map[AggFunc]struct{}{
+	AggCount: {}, AggSum: {}, AggAvg: {}, AggMin: {}, AggMax: {}, AggCountDistinct: {},
+}
+
+// resolveAggregations 校验聚合项并将字段映射为列名，别名必须是合法标识符
+func resolveAggregations(aggs []Aggregation, s *Schema) ([]Aggregation, error) {
+	resolved := make([]Aggregation, 0, len(aggs))
+	for _, agg := range aggs {
+		if _, ok := knownAggFuncs[agg.Func]; !ok {
+			return nil, newFieldError("不支持的聚合函数: %s", string(agg.Func))
+		}
+		alias := agg.name()
+		if !identifierPattern.MatchString(alias) || strings.Contains(alias, ".") {
+			return nil, newFieldError("非法的聚合别名: %s", alias)
+		}
+
+		if agg.Field == "" || agg.Field == "*" {
+			if agg.Func != AggCount {
+				return nil, newFieldError("聚合函数缺少字段: %s", string(agg.Func))
+			}
+			resolved = append(resolved, Aggregation{Func: agg.Func, Alias: alias})
+			continue
+		}
+		column, err := resolveField(agg.Field, s, func(f FieldSpec) bool { return f.Filterable })
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, Aggregation{Func: agg.Func, Field: column, Alias: alias})
+	}
+	return resolved, nil
+}
+
+// aggregateSQL 返回聚合项对应的 SQL 表达式
+func aggregateSQL(agg Aggregation) string {
+	switch agg.Func {
+	case AggCount:
+		if agg.Field == "" {
+			return fmt.Sprintf("COUNT(*) AS %s", agg.Alias)
+		}
+		return fmt.Sprintf("COUNT(%s) AS %s", agg.Field, agg.Alias)
+	case AggCountDistinct:
+		return fmt.Sprintf("COUNT(DISTINCT %s) AS %s", agg.Field, agg.Alias)
+	}
+	return fmt.Sprintf("%s(%s) AS %s", strings.ToUpper(string(agg.Func)), agg.Field, agg.Alias)
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fiber_web/pkg/utils/errorx"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+// statusStat 聚合结果行
+type statusStat struct {
+	Status   int     `bson:"status"`
+	Total    int64   `bson:"total"`
+	AgeSum   int64   `bson:"age_sum"`
+	AvgAge   float64 `bson:"avg_age"`
+	MinAge   int     `bson:"min_age"`
+	MaxAge   int     `bson:"max_age"`
+	Statuses int64   `bson:"statuses"`
+}
+
+func TestMySQLAggregate(t *testing.T) {
+	db := setupGroupTestDB(t)
+	querier := NewMySQLQuerier[groupTestUser](db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []statusStat
+	}{
+		{
+			name: "按状态分组统计",
+			query: NewQuery().
+				AddGroupBy("status").
+				Count("total").
+				Sum("age", "age_sum").
+				Avg("age", "avg_age").
+				Min("age", "min_age").
+				Max("age", "max_age").
+				AddOrderBy("status"),
+			want: []statusStat{
+				{Status: 0, Total: 1, AgeSum: 25, AvgAge: 25, MinAge: 25, MaxAge: 25},
+				{Status: 1, Total: 3, AgeSum: 90, AvgAge: 30, MinAge: 20, MaxAge: 40},
+				{Status: 2, Total: 1, AgeSum: 35, AvgAge: 35, MinAge: 35, MaxAge: 35},
+			},
+		},
+		{
+			name: "HAVING 与按别名排序",
+			query: NewQuery().
+				AddCondition("age", OpGte, 25).
+				AddGroupBy("status").
+				Count("total").
+				AddHaving("total", OpGte, 1).
+				AddHaving("status", OpNe, 0).
+				AddOrderBy("total DESC"),
+			want: []statusStat{
+				{Status: 1, Total: 2},
+				{Status: 2, Total: 1},
+			},
+		},
+		{
+			name:  "不分组时返回单行",
+			query: NewQuery().Count("total").CountDistinct("status", "statuses"),
+			want:  []statusStat{{Total: 5, Statuses: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Aggregate[statusStat](ctx, querier, tt.query)
+			if err != nil {
+				t.Fatalf("Aggregate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Aggregate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLAggregateSQL(t *testing.T) {
+	db := setupGroupTestDB(t)
+	var sql string
+	_ = db.Callback().Row().After("gorm:row").Register("test:capture_sql", func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	})
+
+	var rows []statusStat
+	q := NewQuery().AddGroupBy("status").Count("").CountDistinct("email", "").AddHaving("count", OpGt, 1)
+	if err := NewMySQLQuerier[groupTestUser](db).Aggregate(context.Background(), q, &rows); err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	want := "SELECT status, COUNT(*) AS count, COUNT(DISTINCT email) AS count_distinct_email FROM"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL = %s, want contains %s", sql, want)
+	}
+	if !strings.Contains(sql, "GROUP BY `status` HAVING count > 1") {
+		t.Errorf("SQL = %s, want GROUP BY ... HAVING", sql)
+	}
+}
+
+func TestMongoAggregatePipeline(t *testing.T) {
+	q := NewQuery().
+		AddCondition("status", OpEq, 1).
+		AddGroupBy("dept.name").
+		Count("total").
+		CountDistinct("role", "roles").
+		Avg("age", "").
+		AddHaving("total", OpGt, 2).
+		AddOrderBy("total DESC")
+
+	rq, err := resolveQuery(q, nil)
+	if err != nil {
+		t.Fatalf("resolveQuery() error = %v", err)
+	}
+	got := (&MongoQuerier[groupTestUser]{}).buildPipeline(rq)
+	want := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "status", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "dept_name", Value: "$dept.name"}}},
+			{Key: "total", Value: bson.M{"$sum": 1}},
+			{Key: "roles", Value: bson.M{"$addToSet": "$role"}},
+			{Key: "avg_age", Value: bson.M{"$avg": "$age"}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "dept.name", Value: "$_id.dept_name"},
+			{Key: "total", Value: 1},
+			{Key: "roles", Value: bson.M{"$size": "$roles"}},
+			{Key: "avg_age", Value: 1},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "total", Value: bson.M{"$gt": 2}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildPipeline() = %v, want %v", got, want)
+	}
+}
+
+func TestAggregateRejects(t *testing.T) {
+	s := NewSchema(
+		FieldSpec{Name: "status", Filterable: true, Sortable: true},
+		FieldSpec{Name: "age", Filterable: true},
+	)
+
+	tests := []struct {
+		name  string
+		query *Query
+	}{
+		{"未注册的聚合字段", NewQuery().Sum("salary", "total")},
+		{"非法别名", NewQuery().Aggregate(AggCount, "", "total FROM users; --")},
+		{"不支持的聚合函数", NewQuery().Aggregate(AggFunc("group_concat"), "age", "x")},
+		{"求和缺少字段", NewQuery().Sum("", "total")},
+		{"HAVING 使用未注册字段", NewQuery().AddGroupBy("status").Count("total").AddHaving("salary", OpGt, 1)},
+		{"排序使用未知别名", NewQuery().AddGroupBy("status").Count("total").AddOrderBy("cnt DESC")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveQuery(tt.query, s); !errorx.IsCode(err, errorx.CodeInvalidParam) {
+				t.Errorf("resolveQuery() error = %v, want param error", err)
+			}
+		})
+	}
+
+	db := setupGroupTestDB(t)
+	var rows []statusStat
+	err := NewMySQLQuerier[groupTestUser](db).Aggregate(context.Background(), NewQuery().AddGroupBy("status"), &rows)
+	if !errorx.IsCode(err, errorx.CodeInvalidParam) {
+		t.Errorf("缺少聚合项时 error = %v, want param error", err)
+	}
+}
@@ -0,0 +1,351 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/redis"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCacheTTL 单条记录默认缓存时间
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultPageCacheTTL 分页结果默认缓存时间
+	DefaultPageCacheTTL = time.Minute
+)
+
+var _ Cache = (*redis.Client)(nil)
+
+// Cache 缓存接口，*redis.Client 实现了该接口
+// Get 在键不存在时返回 redis.ErrNil
+type Cache interface {
+	Get(ctx context.Context, key string, value interface{}) error
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// CacheOptions 缓存配置
+type CacheOptions struct {
+	Namespace string        // 键前缀，默认为实体类型名
+	TTL       time.Duration // 单条记录缓存时间，默认 DefaultCacheTTL
+	PageTTL   time.Duration // 分页结果缓存时间，默认 DefaultPageCacheTTL
+}
+
+// CacheStats 缓存命中统计
+type CacheStats struct {
+	Hits   int64 `json:"hits"`   // 命中次数
+	Misses int64 `json:"misses"` // 未命中次数
+	Errors int64 `json:"errors"` // 缓存读写失败次数
+}
+
+// CachedRepository 带读穿透缓存的仓储装饰器
+// 缓存 FindByID 和 FindPage 的结果，未命中时通过 singleflight 合并并发加载；
+// 写操作后递增命名空间版本号并删除对应记录的缓存，分页缓存键包含版本号随之失效；事务中的读操作不经过缓存。
+// 加载期间版本号变化时不写入记录缓存，避免写操作前开始的加载在失效后写回旧数据；
+// 加载方在检查版本号与写入缓存之间停顿超过写操作的失效过程时仍可能写回旧数据，最长保留 TTL。
+// 缓存值使用 gob 编码，json:"-" 的字段同样会被缓存，不要用于含密码哈希、密钥等敏感字段的实体。
+type CachedRepository[T any] struct {
+	Repository[T]
+	cache  Cache
+	opts   CacheOptions
+	group  singleflight.Group
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// NewCachedRepository 创建带缓存的仓储
+func NewCachedRepository[T any](repo Repository[T], cache Cache, opts CacheOptions) *CachedRepository[T] {
+	if repo == nil || cache == nil {
+		panic("repository and cache cannot be nil")
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = reflect.TypeOf((*T)(nil)).Elem().Name()
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.PageTTL <= 0 {
+		opts.PageTTL = DefaultPageCacheTTL
+	}
+	return &CachedRepository[T]{Repository: repo, cache: cache, opts: opts}
+}
+
+// Stats 获取缓存命中统计
+func (r *CachedRepository[T]) Stats() CacheStats {
+	return CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Errors: r.errors.Load(),
+	}
+}
+
+// idKey 单条记录的缓存键
+func (r *CachedRepository[T]) idKey(id any) string {
+	return fmt.Sprintf("%s:id:%v", r.opts.Namespace, id)
+}
+
+// versionKey 分页缓存版本号的键
+func (r *CachedRepository[T]) versionKey() string {
+	return r.opts.Namespace + ":version"
+}
+
+// FindByID 根据主键查询，优先读取缓存；事务中直接查询，避免读到或缓存未提交的数据
+func (r *CachedRepository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	if database.InTransaction(ctx) {
+		return r.Repository.FindByID(ctx, id)
+	}
+
+	key := r.idKey(id)
+	var result T
+	if r.load(ctx, key, &result) {
+		return &result, nil
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		version, verr := r.version(ctx)
+		entity, err := r.Repository.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		// 加载期间有写操作时不写入缓存
+		if verr == nil {
+			if current, err := r.version(ctx); err == nil && current == version {
+				r.store(ctx, key, entity, r.opts.TTL)
+			}
+		}
+		return entity, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 复制一份，避免并发调用方共享同一实体
+	result = *v.(*T)
+	return &result, nil
+}
+
+// FindPage 分页查询，优先读取缓存；事务中直接查询
+func (r *CachedRepository[T]) FindPage(ctx context.Context, query *Query) (*PageResult[T], error) {
+	if database.InTransaction(ctx) {
+		return r.Repository.FindPage(ctx, query)
+	}
+
+	key, err := r.pageKey(ctx, query)
+	if err != nil {
+		r.errors.Add(1)
+		return r.Repository.FindPage(ctx, query)
+	}
+
+	var result PageResult[T]
+	if r.load(ctx, key, &result) {
+		return &result, nil
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		page, err := r.Repository.FindPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		r.store(ctx, key, page, r.opts.PageTTL)
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result = *v.(*PageResult[T])
+	return &result, nil
+}
+
+// version 获取命名空间版本号，不存在时为 0
+func (r *CachedRepository[T]) version(ctx context.Context) (int64, error) {
+	var version int64
+	if err := r.cache.Get(ctx, r.versionKey(), &version); err != nil && !errors.Is(err, redis.ErrNil) {
+		return 0, err
+	}
+	return version, nil
+}
+
+// pageKey 分页结果的缓存键，包含命名空间版本号与查询参数摘要
+// 加载期间版本号变化时旧数据写入旧版本的键，不会被读取
+func (r *CachedRepository[T]) pageKey(ctx context.Context, query *Query) (string, error) {
+	version, err := r.version(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(data)
+	return fmt.Sprintf("%s:page:%d:%s", r.opts.Namespace, version, hex.EncodeToString(sum[:])), nil
+}
+
+// load 读取并解码缓存，命中时返回 true
+func (r *CachedRepository[T]) load(ctx context.Context, key string, value any) bool {
+	var data []byte
+	err := r.cache.Get(ctx, key, &data)
+	if err == nil {
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+	}
+	switch {
+	case err == nil:
+		r.hits.Add(1)
+		return true
+	case errors.Is(err, redis.ErrNil):
+		r.misses.Add(1)
+	default:
+		r.misses.Add(1)
+		r.errors.Add(1)
+	}
+	return false
+}
+
+// store 编码并写入缓存，失败时仅计数
+func (r *CachedRepository[T]) store(ctx context.Context, key string, value any, ttl time.Duration) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		r.errors.Add(1)
+		return
+	}
+	if err := r.cache.Set(ctx, key, buf.Bytes(), ttl); err != nil {
+		r.errors.Add(1)
+	}
+}
+
+// Invalidate 使记录缓存及分页缓存失效，用于绕过仓储直接更新数据后，如条件更新
+func (r *CachedRepository[T]) Invalidate(ctx context.Context, ids ...any) error {
+	return r.invalidate(ctx, ids...)
+}
+
+// invalidate 删除记录缓存并使分页缓存失效
+// 在事务中时提交后再失效一次，避免提交前其他请求将旧数据重新写入缓存
+func (r *CachedRepository[T]) invalidate(ctx context.Context, ids ...any) error {
+	if err := r.evict(ctx, ids...); err != nil {
+		return err
+	}
+	if database.InTransaction(ctx) {
+		evictCtx := context.WithoutCancel(ctx)
+		database.AfterCommit(ctx, func() { _ = r.evict(evictCtx, ids...) })
+	}
+	return nil
+}
+
+// evict 递增版本号并删除记录缓存
+// 先递增版本号，使加载中的 FindByID 放弃写入缓存
+func (r *CachedRepository[T]) evict(ctx context.Context, ids ...any) error {
+	if _, err := r.cache.Incr(ctx, r.versionKey()); err != nil {
+		r.errors.Add(1)
+		return fmt.Errorf("invalidate page cache error: %w", err)
+	}
+	if len(ids) > 0 {
+		keys := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if id != nil {
+				keys = append(keys, r.idKey(id))
+			}
+		}
+		if len(keys) > 0 {
+			if err := r.cache.Delete(ctx, keys...); err != nil {
+				r.errors.Add(1)
+				return fmt.Errorf("invalidate cache error: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Create 创建记录并使分页缓存失效
+func (r *CachedRepository[T]) Create(ctx context.Context, entity *T) error {
+	if err := r.Repository.Create(ctx, entity); err != nil {
+		return err
+	}
+	return r.invalidate(ctx)
+}
+
+// CreateBatch 批量创建记录并使分页缓存失效
+func (r *CachedRepository[T]) CreateBatch(ctx context.Context, entities []*T, batchSize int) error {
+	if err := r.Repository.CreateBatch(ctx, entities, batchSize); err != nil {
+		return err
+	}
+	return r.invalidate(ctx)
+}
+
+// Upsert 插入或更新记录并使缓存失效
+func (r *CachedRepository[T]) Upsert(ctx context.Context, entity *T, updateFields ...string) error {
+	if err := r.Repository.Upsert(ctx, entity, updateFields...); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, entityID(entity))
+}
+
+// Update 更新记录并使缓存失效
+func (r *CachedRepository[T]) Update(ctx context.Context, entity *T) error {
+	if err := r.Repository.Update(ctx, entity); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, entityID(entity))
+}
+
+// UpdateFields 更新部分字段并使缓存失效
+func (r *CachedRepository[T]) UpdateFields(ctx context.Context, id any, fields map[string]any) error {
+	if err := r.Repository.UpdateFields(ctx, id, fields); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// Delete 删除记录并使缓存失效
+func (r *CachedRepository[T]) Delete(ctx context.Context, id any) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// ForceDelete 物理删除记录并使缓存失效
+func (r *CachedRepository[T]) ForceDelete(ctx context.Context, id any) error {
+	if err := r.Repository.ForceDelete(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// Restore 恢复软删除的记录并使缓存失效
+func (r *CachedRepository[T]) Restore(ctx context.Context, id any) error {
+	if err := r.Repository.Restore(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// entityID 通过反射获取实体主键：bson:"_id" 字段或名为 ID/Id 的字段，找不到时返回 nil
+func entityID(entity any) any {
+	rv := reflect.ValueOf(entity)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	if f, ok := bsonField(rv.Type(), "_id"); ok {
+		return rv.FieldByIndex(f.Index).Interface()
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		if strings.EqualFold(rv.Type().Field(i).Name, "id") {
+			return rv.Field(i).Interface()
+		}
+	}
+	return nil
+}
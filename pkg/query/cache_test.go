@@ -0,0 +1,277 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/redis"
+)
+
+// memoryCache 内存缓存，与 redis.Client 一样使用 JSON 序列化
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	fail bool
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string, value interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("connection refused")
+	}
+	data, ok := c.data[key]
+	if !ok {
+		return redis.ErrNil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("connection refused")
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.data[key] = data
+	return nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.data, key)
+	}
+	return nil
+}
+
+func (c *memoryCache) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	_ = json.Unmarshal(c.data[key], &n)
+	n++
+	c.data[key], _ = json.Marshal(n)
+	return n, nil
+}
+
+// countingRepository 统计底层查询次数的仓储
+type countingRepository[T any] struct {
+	Repository[T]
+	findByID atomic.Int64
+	findPage atomic.Int64
+	gate     chan struct{} // 不为 nil 时 FindByID 阻塞直到关闭
+	loaded   func()        // 不为 nil 时在 FindByID 读取数据后调用
+}
+
+func (r *countingRepository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	r.findByID.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	entity, err := r.Repository.FindByID(ctx, id)
+	if r.loaded != nil {
+		r.loaded()
+	}
+	return entity, err
+}
+
+func (r *countingRepository[T]) FindPage(ctx context.Context, q *Query) (*PageResult[T], error) {
+	r.findPage.Add(1)
+	return r.Repository.FindPage(ctx, q)
+}
+
+// cacheTestUser 测试用模型，Password 不参与 JSON 序列化
+type cacheTestUser struct {
+	ID       uint `gorm:"primarykey"`
+	Name     string
+	Password string `json:"-"`
+}
+
+func setupCachedRepository(t *testing.T) (*CachedRepository[cacheTestUser], *countingRepository[cacheTestUser], *memoryCache) {
+	db := setupRepoTestDB(t)
+	if err := db.AutoMigrate(&cacheTestUser{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	for _, u := range []cacheTestUser{{Name: "bob", Password: "secret"}, {Name: "alice"}} {
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatalf("写入测试数据失败: %v", err)
+		}
+	}
+	inner := &countingRepository[cacheTestUser]{Repository: NewMySQLRepository[cacheTestUser](db)}
+	cache := newMemoryCache()
+	return NewCachedRepository[cacheTestUser](inner, cache, CacheOptions{Namespace: "test:user"}), inner, cache
+}
+
+func TestCachedRepositoryFindByID(t *testing.T) {
+	repo, inner, cache := setupCachedRepository(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		u, err := repo.FindByID(ctx, uint(1))
+		if err != nil {
+			t.Fatalf("FindByID() error = %v", err)
+		}
+		if u.Password != "secret" {
+			t.Errorf("缓存丢失 json:\"-\" 字段: %+v", u)
+		}
+	}
+	if n := inner.findByID.Load(); n != 1 {
+		t.Errorf("底层查询次数 = %d, want 1", n)
+	}
+	if stats := repo.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Stats() = %+v, want 2 hits 1 miss", stats)
+	}
+	if _, ok := cache.data["test:user:id:1"]; !ok {
+		t.Error("缓存键应带命名空间")
+	}
+
+	if err := repo.UpdateFields(ctx, uint(1), map[string]any{"name": "bobby"}); err != nil {
+		t.Fatalf("UpdateFields() error = %v", err)
+	}
+	u, _ := repo.FindByID(ctx, 1)
+	if u.Name != "bobby" {
+		t.Errorf("更新后读取到旧数据: %+v", u)
+	}
+
+	u.Name = "robert"
+	if err := repo.Update(ctx, u); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if u, _ = repo.FindByID(ctx, uint(1)); u.Name != "robert" {
+		t.Errorf("Update() 后读取到旧数据: %+v", u)
+	}
+
+	if err := repo.Delete(ctx, uint(1)); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, uint(1)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("删除后 FindByID() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCachedRepositoryStaleLoad(t *testing.T) {
+	repo, inner, cache := setupCachedRepository(t)
+	ctx := context.Background()
+
+	// 读取旧数据后、写入缓存前发生更新
+	inner.loaded = func() {
+		inner.loaded = nil
+		if err := repo.UpdateFields(ctx, uint(1), map[string]any{"name": "bobby"}); err != nil {
+			t.Errorf("UpdateFields() error = %v", err)
+		}
+	}
+	if u, err := repo.FindByID(ctx, uint(1)); err != nil || u.Name != "bob" {
+		t.Fatalf("FindByID() = %+v, %v", u, err)
+	}
+	if _, ok := cache.data["test:user:id:1"]; ok {
+		t.Error("加载期间有写操作时不应写入缓存")
+	}
+	if u, _ := repo.FindByID(ctx, uint(1)); u.Name != "bobby" {
+		t.Errorf("更新后读取到旧数据: %+v", u)
+	}
+}
+
+func TestCachedRepositoryFindPage(t *testing.T) {
+	repo, inner, _ := setupCachedRepository(t)
+	ctx := context.Background()
+	q := NewQuery().AddOrderBy("id")
+
+	for i := 0; i < 2; i++ {
+		page, err := repo.FindPage(ctx, q)
+		if err != nil {
+			t.Fatalf("FindPage() error = %v", err)
+		}
+		if page.Total != 2 {
+			t.Errorf("Total = %d, want 2", page.Total)
+		}
+	}
+	if n := inner.findPage.Load(); n != 1 {
+		t.Errorf("底层查询次数 = %d, want 1", n)
+	}
+
+	// 不同的查询参数使用不同的缓存
+	if _, err := repo.FindPage(ctx, NewQuery().AddCondition("name", OpEq, "bob")); err != nil {
+		t.Fatalf("FindPage() error = %v", err)
+	}
+	if n := inner.findPage.Load(); n != 2 {
+		t.Errorf("底层查询次数 = %d, want 2", n)
+	}
+
+	if err := repo.Create(ctx, &cacheTestUser{Name: "carol"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	page, _ := repo.FindPage(ctx, q)
+	if page.Total != 3 {
+		t.Errorf("创建后 Total = %d, want 3", page.Total)
+	}
+}
+
+func TestCachedRepositorySingleflight(t *testing.T) {
+	repo, inner, _ := setupCachedRepository(t)
+	inner.gate = make(chan struct{})
+	ctx := context.Background()
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan *cacheTestUser, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := repo.FindByID(ctx, uint(2))
+			if err != nil {
+				t.Errorf("FindByID() error = %v", err)
+				return
+			}
+			results <- u
+		}()
+	}
+
+	// 等待所有请求进入加载阶段后放行
+	for repo.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(inner.gate)
+	wg.Wait()
+	close(results)
+
+	if got := inner.findByID.Load(); got != 1 {
+		t.Errorf("并发加载次数 = %d, want 1", got)
+	}
+	seen := make(map[*cacheTestUser]bool)
+	for u := range results {
+		if seen[u] {
+			t.Error("并发调用方不应共享同一实体指针")
+		}
+		seen[u] = true
+	}
+}
+
+func TestCachedRepositoryCacheFailure(t *testing.T) {
+	repo, inner, cache := setupCachedRepository(t)
+	cache.fail = true
+
+	u, err := repo.FindByID(context.Background(), uint(1))
+	if err != nil || u.Name != "bob" {
+		t.Fatalf("缓存不可用时应回源: %+v, %v", u, err)
+	}
+	if inner.findByID.Load() != 1 || repo.Stats().Errors == 0 {
+		t.Errorf("缓存失败应计数: %+v", repo.Stats())
+	}
+}
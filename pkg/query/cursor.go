@@ -0,0 +1,339 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fiber_web/pkg/utils/errorx"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidCursor = errorx.NewParamError("无效的游标")
+)
+
+var (
+	cursorSecret   []byte
+	cursorSecretMu sync.RWMutex
+)
+
+func init() {
+	// 默认使用进程内随机密钥，多实例部署时需通过 SetCursorSecret 设置统一密钥
+	cursorSecret = make([]byte, 32)
+	_, _ = rand.Read(cursorSecret)
+}
+
+// SetCursorSecret 设置游标签名密钥
+func SetCursorSecret(secret []byte) {
+	if len(secret) == 0 {
+		return
+	}
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+	cursorSecret = append([]byte(nil), secret...)
+}
+
+// CursorResult 游标分页结果
+type CursorResult[T any] struct {
+	List       []T    `json:"list"`                 // 数据列表
+	NextCursor string `json:"nextCursor,omitempty"` // 下一页游标，为空表示没有下一页
+	PrevCursor string `json:"prevCursor,omitempty"` // 上一页游标，为空表示没有上一页
+	HasMore    bool   `json:"hasMore"`              // 当前方向上是否还有更多数据
+	PageSize   int    `json:"pageSize"`             // 每页数量
+}
+
+// sortKey 排序键
+type sortKey struct {
+	Field string
+	Desc  bool
+}
+
+// order 返回排序键对应的 ORDER BY 片段，reverse 为 true 时反转方向
+func (k sortKey) order(reverse bool) string {
+	if k.Desc != reverse {
+		return k.Field + " DESC"
+	}
+	return k.Field + " ASC"
+}
+
+// parseOrder 解析排序字段，格式：字段名 ASC/DESC
+func parseOrder(order string) (sortKey, bool) {
+	parts := strings.Fields(order)
+	if len(parts) == 0 {
+		return sortKey{}, false
+	}
+	key := sortKey{Field: parts[0]}
+	if len(parts) > 1 && strings.EqualFold(parts[1], "DESC") {
+		key.Desc = true
+	}
+	return key, true
+}
+
+// cursorKeys 根据 OrderBy 计算游标排序键，并在末尾追加唯一键以保证顺序稳定
+func cursorKeys(query *Query, uniqueKey string) []sortKey {
+	keys := make([]sortKey, 0, len(query.OrderBy)+1)
+	hasUnique := false
+	for _, order := range query.OrderBy {
+		key, ok := parseOrder(order)
+		if !ok {
+			continue
+		}
+		if key.Field == uniqueKey {
+			hasUnique = true
+		}
+		keys = append(keys, key)
+	}
+	if !hasUnique {
+		desc := len(keys) > 0 && keys[len(keys)-1].Desc
+		keys = append(keys, sortKey{Field: uniqueKey, Desc: desc})
+	}
+	return keys
+}
+
+// cursorQuery 复制查询参数，补齐游标排序键所需的选择字段
+func cursorQuery(query *Query, keys []sortKey) *Query {
+	cq := *query
+	cq.Groups = slices.Clone(query.Groups)
+	if len(cq.SelectFields) > 0 {
+		cq.SelectFields = slices.Clone(query.SelectFields)
+		for _, key := range keys {
+			if !slices.Contains(cq.SelectFields, key.Field) {
+				cq.SelectFields = append(cq.SelectFields, key.Field)
+			}
+		}
+	}
+	return &cq
+}
+
+// keysetGroup 构建键集分页条件
+// 对于排序键 k1..kn，向后翻页条件为：
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... ，降序字段使用 <，向前翻页时方向相反
+// MySQL 与 MongoDB 都将 NULL 排在最小的位置，值为 NULL 时按此改写比较：
+// 等于 NULL 使用 IS NULL；大于 NULL 即 IS NOT NULL；没有小于 NULL 的值；小于非 NULL 值时包括 NULL。
+func keysetGroup(keys []sortKey, values []any, backward bool) *ConditionGroup {
+	group := Or()
+	for i, key := range keys {
+		branch := And()
+		for j := 0; j < i; j++ {
+			branch.Add(keys[j].Field, OpEq, values[j])
+		}
+		greater := key.Desc == backward
+		switch {
+		case greater && values[i] == nil:
+			branch.Add(key.Field, OpNe, nil)
+		case greater:
+			branch.Add(key.Field, OpGt, values[i])
+		case values[i] == nil:
+			continue
+		default:
+			branch.AddGroup(Or().Add(key.Field, OpLt, values[i]).Add(key.Field, OpEq, nil))
+		}
+		group.AddGroup(branch)
+	}
+	return group
+}
+
+// cursorPayload 游标内容
+type cursorPayload struct {
+	Fields   []string      `json:"f"`           // 排序字段
+	Values   []cursorValue `json:"v"`           // 排序字段的值
+	Backward bool          `json:"b,omitempty"` // 是否向前翻页
+}
+
+// cursorValue 带类型的游标值，保证解码后类型与数据库字段一致
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// encodeCursor 编码并签名游标
+func encodeCursor(keys []sortKey, values []any, backward bool) (string, error) {
+	payload := cursorPayload{
+		Fields:   make([]string, len(keys)),
+		Values:   make([]cursorValue, len(values)),
+		Backward: backward,
+	}
+	for i, key := range keys {
+		payload.Fields[i] = key.Field
+	}
+	for i, v := range values {
+		payload.Values[i] = toCursorValue(v)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal cursor error: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(data)), nil
+}
+
+// decodeCursor 校验签名并解码游标，排序字段必须与当前查询一致
+func decodeCursor(cursor string, keys []sortKey) (values []any, backward bool, err error) {
+	encoded, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, false, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signCursor(data)) {
+		return nil, false, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	if len(payload.Fields) != len(keys) || len(payload.Values) != len(keys) {
+		return nil, false, ErrInvalidCursor
+	}
+	values = make([]any, len(keys))
+	for i, key := range keys {
+		if payload.Fields[i] != key.Field {
+			return nil, false, ErrInvalidCursor
+		}
+		if values[i], err = payload.Values[i].decode(); err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+	}
+	return values, payload.Backward, nil
+}
+
+// signCursor 计算游标签名
+func signCursor(data []byte) []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+	h := hmac.New(sha256.New, cursorSecret)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// toCursorValue 将字段值转换为带类型的游标值，指针与 sql.Null* 等类型取其实际值
+func toCursorValue(v any) cursorValue {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(valuer); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+			v, _ = valuer.Value()
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return cursorValue{Type: "n"}
+		}
+		return toCursorValue(rv.Elem().Interface())
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return cursorValue{Type: "n"}
+	case time.Time:
+		return cursorValue{Type: "t", Value: val.Format(time.RFC3339Nano)}
+	case primitive.DateTime:
+		return cursorValue{Type: "t", Value: val.Time().Format(time.RFC3339Nano)}
+	case primitive.ObjectID:
+		return cursorValue{Type: "o", Value: val.Hex()}
+	case string:
+		return cursorValue{Type: "s", Value: val}
+	case bool:
+		return cursorValue{Type: "b", Value: strconv.FormatBool(val)}
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "u", Value: strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "f", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}
+	case reflect.String:
+		return cursorValue{Type: "s", Value: rv.String()}
+	}
+	return cursorValue{Type: "s", Value: fmt.Sprint(v)}
+}
+
+// decode 将游标值还原为对应类型
+func (v cursorValue) decode() (any, error) {
+	switch v.Type {
+	case "n":
+		return nil, nil
+	case "t":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "o":
+		return primitive.ObjectIDFromHex(v.Value)
+	case "s":
+		return v.Value, nil
+	case "b":
+		return strconv.ParseBool(v.Value)
+	case "i":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "u":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(v.Value, 64)
+	}
+	return nil, fmt.Errorf("unknown cursor value type: %s", v.Type)
+}
+
+// newCursorResult 根据多取一条的查询结果创建游标分页结果
+// 参数：
+//   - list: 查询结果，最多 pageSize+1 条，向前翻页时为反向排序的结果
+//   - keys: 排序键
+//   - pageSize: 每页数量
+//   - hasCursor: 请求是否携带游标
+//   - backward: 是否向前翻页
+//   - valuesOf: 提取记录排序键值的函数
+func (b *BaseQuerier[T]) newCursorResult(list []T, keys []sortKey, pageSize int, hasCursor, backward bool,
+	valuesOf func(row *T) ([]any, error)) (*CursorResult[T], error) {
+	hasMore := len(list) > pageSize
+	if hasMore {
+		list = list[:pageSize]
+	}
+	if backward {
+		slices.Reverse(list)
+	}
+
+	result := &CursorResult[T]{
+		List:     list,
+		HasMore:  hasMore,
+		PageSize: pageSize,
+	}
+	if len(list) == 0 {
+		return result, nil
+	}
+
+	// 向后翻页时，存在更多数据才有下一页；向前翻页时，来源页即为下一页
+	if (!backward && hasMore) || (backward && hasCursor) {
+		values, err := valuesOf(&list[len(list)-1])
+		if err != nil {
+			return nil, err
+		}
+		if result.NextCursor, err = encodeCursor(keys, values, false); err != nil {
+			return nil, err
+		}
+	}
+	// 向前翻页时，存在更多数据才有上一页；向后翻页时，携带游标即存在上一页
+	if (backward && hasMore) || (!backward && hasCursor) {
+		values, err := valuesOf(&list[0])
+		if err != nil {
+			return nil, err
+		}
+		if result.PrevCursor, err = encodeCursor(keys, values, true); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
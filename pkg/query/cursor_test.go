@@ -0,0 +1,274 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// collectIDs 提取结果中的ID
+func collectIDs(list []groupTestUser) []uint {
+	ids := make([]uint, 0, len(list))
+	for _, u := range list {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func TestMySQLFindCursor(t *testing.T) {
+	db := setupGroupTestDB(t)
+	querier := NewMySQLQuerier[groupTestUser](db)
+	ctx := context.Background()
+
+	newQuery := func(cursor string) *Query {
+		return NewQuery().AddOrderBy("status DESC").SetCursor(cursor, 2)
+	}
+
+	// 向后翻页：status DESC, id DESC
+	wantPages := [][]uint{{5, 3}, {2, 1}, {4}}
+	cursor := ""
+	var pages []*CursorResult[groupTestUser]
+	for i, want := range wantPages {
+		result, err := querier.FindCursor(ctx, newQuery(cursor))
+		if err != nil {
+			t.Fatalf("第%d页查询失败: %v", i+1, err)
+		}
+		if got := collectIDs(result.List); !reflect.DeepEqual(got, want) {
+			t.Fatalf("第%d页 = %v, want %v", i+1, got, want)
+		}
+		if (i == 0) != (result.PrevCursor == "") {
+			t.Errorf("第%d页 PrevCursor = %q", i+1, result.PrevCursor)
+		}
+		if (i == len(wantPages)-1) != (result.NextCursor == "") {
+			t.Errorf("第%d页 NextCursor = %q", i+1, result.NextCursor)
+		}
+		pages = append(pages, result)
+		cursor = result.NextCursor
+	}
+
+	// 向前翻页：从最后一页返回
+	result, err := querier.FindCursor(ctx, newQuery(pages[2].PrevCursor))
+	if err != nil {
+		t.Fatalf("向前翻页失败: %v", err)
+	}
+	if got := collectIDs(result.List); !reflect.DeepEqual(got, []uint{2, 1}) {
+		t.Errorf("向前翻页 = %v, want [2 1]", got)
+	}
+	if result.PrevCursor == "" || result.NextCursor == "" {
+		t.Errorf("中间页应同时存在上一页和下一页游标: %+v", result)
+	}
+
+	result, err = querier.FindCursor(ctx, newQuery(result.PrevCursor))
+	if err != nil {
+		t.Fatalf("向前翻页失败: %v", err)
+	}
+	if got := collectIDs(result.List); !reflect.DeepEqual(got, []uint{5, 3}) {
+		t.Errorf("向前翻页到首页 = %v, want [5 3]", got)
+	}
+	if result.PrevCursor != "" {
+		t.Errorf("首页不应有上一页游标: %q", result.PrevCursor)
+	}
+}
+
+func TestMySQLFindCursorWithConditions(t *testing.T) {
+	db := setupGroupTestDB(t)
+	querier := NewMySQLQuerier[groupTestUser](db)
+
+	q := NewQuery().
+		AddCondition("status", OpEq, 1).
+		AddOrderBy("age ASC").
+		Select("name").
+		SetCursor("", 2)
+	first, err := querier.FindCursor(context.Background(), q)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if got := collectIDs(first.List); !reflect.DeepEqual(got, []uint{1, 2}) {
+		t.Fatalf("首页 = %v, want [1 2]", got)
+	}
+
+	q.SetCursor(first.NextCursor, 2)
+	second, err := querier.FindCursor(context.Background(), q)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if got := collectIDs(second.List); !reflect.DeepEqual(got, []uint{3}) {
+		t.Errorf("第二页 = %v, want [3]", got)
+	}
+	if second.HasMore || second.NextCursor != "" {
+		t.Errorf("最后一页不应有更多数据: %+v", second)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	db := setupGroupTestDB(t)
+	querier := NewMySQLQuerier[groupTestUser](db)
+	ctx := context.Background()
+
+	first, err := querier.FindCursor(ctx, NewQuery().AddOrderBy("age DESC").SetCursor("", 2))
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query *Query
+	}{
+		{"格式错误", NewQuery().AddOrderBy("age DESC").SetCursor("garbage", 2)},
+		{"签名被篡改", NewQuery().AddOrderBy("age DESC").SetCursor(first.NextCursor+"x", 2)},
+		{"排序字段不一致", NewQuery().AddOrderBy("name DESC").SetCursor(first.NextCursor, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := querier.FindCursor(ctx, tt.query); err != ErrInvalidCursor {
+				t.Errorf("FindCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestCursorValueRoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)
+	oid := primitive.NewObjectID()
+	keys := []sortKey{{Field: "a"}, {Field: "b"}, {Field: "c"}, {Field: "d"}, {Field: "e"}, {Field: "f"}}
+	values := []any{now, oid, "x", int32(7), uint(8), 1.5}
+
+	cursor, err := encodeCursor(keys, values, true)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	got, backward, err := decodeCursor(cursor, keys)
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if !backward {
+		t.Error("backward 应为 true")
+	}
+	want := []any{now, oid, "x", int64(7), uint64(8), 1.5}
+	for i := range want {
+		if w, ok := want[i].(time.Time); ok {
+			if !w.Equal(got[i].(time.Time)) {
+				t.Errorf("values[%d] = %v, want %v", i, got[i], w)
+			}
+			continue
+		}
+		if got[i] != want[i] {
+			t.Errorf("values[%d] = %#v, want %#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMongoCursorKeyset(t *testing.T) {
+	// status DESC, id DESC 排序下，位于 (status=1, id=2) 之后的记录
+	keys := cursorKeys(NewQuery().AddOrderBy("status DESC"), "id")
+	q := NewQuery().AddGroup(keysetGroup(keys, []any{int64(1), int64(2)}, false))
+	if got := mongoIDs(t, q); !reflect.DeepEqual(got, []uint{1, 4}) {
+		t.Errorf("向后 = %v, want [1 4]", got)
+	}
+
+	q = NewQuery().AddGroup(keysetGroup(keys, []any{int64(1), int64(2)}, true))
+	if got := mongoIDs(t, q); !reflect.DeepEqual(got, []uint{3, 5}) {
+		t.Errorf("向前 = %v, want [3 5]", got)
+	}
+
+	values, err := cursorValuesFromBSON(&groupTestUsers[1], keys)
+	if err != nil {
+		t.Fatalf("提取游标值失败: %v", err)
+	}
+	if len(values) != 2 || toCursorValue(values[0]) != (cursorValue{Type: "i", Value: "1"}) ||
+		toCursorValue(values[1]) != (cursorValue{Type: "i", Value: "2"}) {
+		t.Errorf("cursorValuesFromBSON() = %#v", values)
+	}
+}
+
+// cursorNullUser 排序字段可为 NULL 的测试模型
+type cursorNullUser struct {
+	ID    uint `gorm:"primarykey"`
+	Score *int
+}
+
+func TestMySQLFindCursorNullSortField(t *testing.T) {
+	db := setupGroupTestDB(t)
+	if err := db.AutoMigrate(&cursorNullUser{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	score := func(v int) *int { return &v }
+	users := []cursorNullUser{
+		{ID: 1, Score: score(20)}, {ID: 2}, {ID: 3, Score: score(10)},
+		{ID: 4}, {ID: 5, Score: score(20)}, {ID: 6},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+	querier := NewMySQLQuerier[cursorNullUser](db)
+	ctx := context.Background()
+
+	// NULL 排在最小的位置，翻页跨过 NULL 与非 NULL 的边界时不能提前结束
+	tests := []struct {
+		name  string
+		order string
+		want  []uint
+	}{
+		{"升序", "score ASC", []uint{2, 4, 6, 3, 1, 5}},
+		{"降序", "score DESC", []uint{5, 1, 3, 6, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, size := range []int{1, 2, 4} {
+				var got []uint
+				var pages []*CursorResult[cursorNullUser]
+				cursor := ""
+				for {
+					result, err := querier.FindCursor(ctx, NewQuery().AddOrderBy(tt.order).SetCursor(cursor, size))
+					if err != nil {
+						t.Fatalf("每页%d条查询失败: %v", size, err)
+					}
+					for _, u := range result.List {
+						got = append(got, u.ID)
+					}
+					pages = append(pages, result)
+					if result.NextCursor == "" {
+						break
+					}
+					if len(pages) > len(tt.want) {
+						t.Fatalf("每页%d条翻页未结束: %v", size, got)
+					}
+					cursor = result.NextCursor
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("每页%d条向后翻页 = %v, want %v", size, got, tt.want)
+				}
+
+				// 从最后一页逐页向前翻回首页
+				var back []uint
+				cursor = pages[len(pages)-1].PrevCursor
+				for i := 0; cursor != ""; i++ {
+					if i > len(tt.want) {
+						t.Fatalf("每页%d条向前翻页未结束: %v", size, back)
+					}
+					result, err := querier.FindCursor(ctx, NewQuery().AddOrderBy(tt.order).SetCursor(cursor, size))
+					if err != nil {
+						t.Fatalf("每页%d条向前翻页失败: %v", size, err)
+					}
+					back = append(collectNullIDs(result.List), back...)
+					cursor = result.PrevCursor
+				}
+				if want := tt.want[:len(tt.want)-len(pages[len(pages)-1].List)]; !reflect.DeepEqual(back, want) {
+					t.Errorf("每页%d条向前翻页 = %v, want %v", size, back, want)
+				}
+			}
+		})
+	}
+}
+
+// collectNullIDs 提取结果中的ID
+func collectNullIDs(list []cursorNullUser) []uint {
+	ids := make([]uint, 0, len(list))
+	for _, u := range list {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
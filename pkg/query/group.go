@@ -0,0 +1,86 @@
+package query
+
+// Logic 条件组的逻辑关系
+type Logic string
+
+const (
+	LogicAnd Logic = "and" // 组内条件全部满足
+	LogicOr  Logic = "or"  // 组内条件满足任意一个
+	LogicNot Logic = "not" // 组内条件均不满足，即 NOT (a OR b ...)
+)
+
+// ConditionGroup 条件组，支持 AND/OR/NOT 任意嵌套
+//
+// 组内的 Conditions 与 Groups 作为同一层级的成员，按 Logic 组合：
+//   - LogicAnd: a AND b AND (...)
+//   - LogicOr:  a OR b OR (...)
+//   - LogicNot: NOT (a OR b OR (...))
+type ConditionGroup struct {
+	Logic      Logic            `json:"logic"`                // 逻辑关系
+	Conditions []Condition      `json:"conditions,omitempty"` // 组内条件
+	Groups     []ConditionGroup `json:"groups,omitempty"`     // 嵌套子组
+}
+
+// NewGroup 创建指定逻辑关系的条件组
+func NewGroup(logic Logic) *ConditionGroup {
+	return &ConditionGroup{
+		Logic:      logic,
+		Conditions: make([]Condition, 0, 2),
+	}
+}
+
+// And 创建 AND 条件组
+func And() *ConditionGroup {
+	return NewGroup(LogicAnd)
+}
+
+// Or 创建 OR 条件组
+func Or() *ConditionGroup {
+	return NewGroup(LogicOr)
+}
+
+// Not 创建 NOT 条件组
+func Not() *ConditionGroup {
+	return NewGroup(LogicNot)
+}
+
+// Add 向条件组追加条件
+func (g *ConditionGroup) Add(field string, operator Operator, value any) *ConditionGroup {
+	g.Conditions = append(g.Conditions, Condition{
+		Field:    field,
+		Operator: operator,
+		Value:    value,
+	})
+	return g
+}
+
+// AddGroup 向条件组追加嵌套子组
+func (g *ConditionGroup) AddGroup(group *ConditionGroup) *ConditionGroup {
+	if group != nil {
+		g.Groups = append(g.Groups, *group)
+	}
+	return g
+}
+
+// IsEmpty 判断条件组是否不含任何有效条件
+func (g *ConditionGroup) IsEmpty() bool {
+	for _, cond := range g.Conditions {
+		if cond.Field != "" {
+			return false
+		}
+	}
+	for i := range g.Groups {
+		if !g.Groups[i].IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// AddGroup 追加条件组，与 Conditions 以 AND 组合
+func (q *Query) AddGroup(group *ConditionGroup) *Query {
+	if group != nil {
+		q.Groups = append(q.Groups, *group)
+	}
+	return q
+}
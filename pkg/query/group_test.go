@@ -0,0 +1,334 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// groupTestUser 测试用模型
+type groupTestUser struct {
+	ID     uint   `gorm:"primarykey" bson:"id"`
+	Name   string `bson:"name"`
+	Email  string `bson:"email"`
+	Status int    `bson:"status"`
+	Age    int    `bson:"age"`
+}
+
+var groupTestUsers = []groupTestUser{
+	{ID: 1, Name: "bob", Email: "bob@example.com", Status: 1, Age: 20},
+	{ID: 2, Name: "alice", Email: "bobby@example.com", Status: 1, Age: 30},
+	{ID: 3, Name: "carol", Email: "carol@example.com", Status: 1, Age: 40},
+	{ID: 4, Name: "bobcat", Email: "cat@example.com", Status: 0, Age: 25},
+	{ID: 5, Name: "dave", Email: "dave@example.com", Status: 2, Age: 35},
+}
+
+// setupGroupTestDB 创建内存 SQLite 数据库并写入测试数据
+func setupGroupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&groupTestUser{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if err := db.Create(&groupTestUsers).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+	return db
+}
+
+// mysqlIDs 使用 MySQLQuerier 查询并返回排序后的ID
+func mysqlIDs(t *testing.T, db *gorm.DB, q *Query) []uint {
+	list, err := NewMySQLQuerier[groupTestUser](db).Find(context.Background(), q)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	ids := make([]uint, 0, len(list))
+	for _, u := range list {
+		ids = append(ids, u.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// mongoIDs 在内存中用 MongoQuerier 生成的过滤条件匹配测试数据
+func mongoIDs(t *testing.T, q *Query) []uint {
+	filter := (&MongoQuerier[groupTestUser]{}).buildFilter(q)
+	ids := make([]uint, 0)
+	for _, u := range groupTestUsers {
+		raw, err := bson.Marshal(u)
+		if err != nil {
+			t.Fatalf("序列化失败: %v", err)
+		}
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			t.Fatalf("反序列化失败: %v", err)
+		}
+		if matchFilter(t, doc, filter) {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids
+}
+
+// matchFilter 简易的 MongoDB 过滤条件求值器，仅支持测试用到的操作符
+func matchFilter(t *testing.T, doc bson.M, filter bson.D) bool {
+	for _, e := range filter {
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			members := e.Value.(bson.A)
+			matched := 0
+			for _, m := range members {
+				if matchFilter(t, doc, m.(bson.D)) {
+					matched++
+				}
+			}
+			switch {
+			case e.Key == "$and" && matched != len(members),
+				e.Key == "$or" && matched == 0,
+				e.Key == "$nor" && matched > 0:
+				return false
+			}
+		default:
+			if !matchValue(t, doc[e.Key], e.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchValue 对单个字段值求值
+func matchValue(t *testing.T, actual, expected any) bool {
+	ops, ok := expected.(bson.M)
+	if !ok {
+		return compare(actual, expected) == 0
+	}
+	for op, v := range ops {
+		switch op {
+		case "$ne":
+			if compare(actual, v) == 0 {
+				return false
+			}
+		case "$gt":
+			if compare(actual, v) <= 0 {
+				return false
+			}
+		case "$gte":
+			if compare(actual, v) < 0 {
+				return false
+			}
+		case "$lt":
+			if compare(actual, v) >= 0 {
+				return false
+			}
+		case "$lte":
+			if compare(actual, v) > 0 {
+				return false
+			}
+		case "$in", "$nin":
+			found := false
+			rv := reflect.ValueOf(v)
+			for i := 0; i < rv.Len(); i++ {
+				if compare(actual, rv.Index(i).Interface()) == 0 {
+					found = true
+				}
+			}
+			if found != (op == "$in") {
+				return false
+			}
+		case "$regex":
+			re := regexp.MustCompile("(?i)" + v.(string))
+			if !re.MatchString(fmt.Sprint(actual)) {
+				return false
+			}
+		case "$options":
+		case "$not":
+			if matchValue(t, actual, v) {
+				return false
+			}
+		default:
+			t.Fatalf("不支持的操作符: %s", op)
+		}
+	}
+	return true
+}
+
+// compare 比较两个值，数值统一按 float64 比较
+func compare(a, b any) int {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func TestConditionGroupBuilder(t *testing.T) {
+	g := Or().
+		Add("name", OpLike, "bob").
+		AddGroup(And().Add("status", OpEq, 1).Add("age", OpGt, 18))
+
+	if g.Logic != LogicOr {
+		t.Errorf("Logic = %v, want %v", g.Logic, LogicOr)
+	}
+	if len(g.Conditions) != 1 || len(g.Groups) != 1 {
+		t.Fatalf("成员数量错误: conditions=%d groups=%d", len(g.Conditions), len(g.Groups))
+	}
+	if len(g.Groups[0].Conditions) != 2 {
+		t.Errorf("子组条件数量 = %d, want 2", len(g.Groups[0].Conditions))
+	}
+
+	q := NewQuery().AddGroup(g).AddGroup(nil)
+	if len(q.Groups) != 1 {
+		t.Errorf("Groups 数量 = %d, want 1", len(q.Groups))
+	}
+
+	if !Or().AddGroup(And().Add("", OpEq, 1)).IsEmpty() {
+		t.Error("仅包含空字段的条件组应视为空")
+	}
+}
+
+func TestConditionGroupBackendsEquivalent(t *testing.T) {
+	db := setupGroupTestDB(t)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []uint
+	}{
+		{
+			name: "status = 1 AND (name LIKE bob OR email LIKE bob)",
+			query: NewQuery().
+				AddCondition("status", OpEq, 1).
+				AddGroup(Or().Add("name", OpLike, "bob").Add("email", OpLike, "bob")),
+			want: []uint{1, 2},
+		},
+		{
+			name:  "NOT (status = 1 OR age > 30)",
+			query: NewQuery().AddGroup(Not().Add("status", OpEq, 1).Add("age", OpGt, 30)),
+			want:  []uint{4},
+		},
+		{
+			name: "(status = 0 OR (age >= 30 AND age <= 35))",
+			query: NewQuery().AddGroup(Or().
+				Add("status", OpEq, 0).
+				AddGroup(And().Add("age", OpGte, 30).Add("age", OpLte, 35))),
+			want: []uint{2, 4, 5},
+		},
+		{
+			name: "多个顶层组 AND 组合",
+			query: NewQuery().
+				AddGroup(Or().Add("status", OpEq, 1).Add("status", OpEq, 2)).
+				AddGroup(Or().Add("age", OpLt, 25).Add("age", OpGt, 35)),
+			want: []uint{1, 3},
+		},
+		{
+			name: "OR 组内嵌套 NOT 组",
+			query: NewQuery().AddGroup(Or().
+				Add("name", OpEq, "dave").
+				AddGroup(Not().Add("status", OpIn, []int{1, 2}))),
+			want: []uint{4, 5},
+		},
+		{
+			name:  "空组被忽略",
+			query: NewQuery().AddCondition("status", OpEq, 2).AddGroup(Or()),
+			want:  []uint{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mysqlIDs(t, db, tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MySQLQuerier 结果 = %v, want %v", got, tt.want)
+			}
+			got = mongoIDs(t, tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MongoQuerier 结果 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLGroupSQL(t *testing.T) {
+	db := setupGroupTestDB(t)
+	q := NewQuery().
+		AddCondition("status", OpEq, 1).
+		AddGroup(Or().Add("name", OpLike, "x").AddGroup(Not().Add("age", OpLt, 18).Add("age", OpGt, 60)))
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var list []groupTestUser
+		return (&MySQLQuerier[groupTestUser]{db: tx}).buildConditions(tx.Statement.Context, q).Find(&list)
+	})
+	want := "WHERE status = 1 AND (name LIKE \"%x%\" OR NOT (age < 18 OR age > 60))"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL = %s, want contains %s", sql, want)
+	}
+}
+
+func TestMongoGroupFilter(t *testing.T) {
+	q := NewQuery().
+		AddCondition("status", OpEq, 1).
+		AddGroup(Or().Add("name", OpEq, "a").AddGroup(Not().Add("age", OpLt, 18)))
+
+	got := (&MongoQuerier[groupTestUser]{}).buildFilter(q)
+	want := bson.D{
+		{Key: "status", Value: 1},
+		{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "name", Value: "a"}},
+				bson.D{{Key: "$nor", Value: bson.A{
+					bson.D{{Key: "age", Value: bson.M{"$lt": 18}}},
+				}}},
+			}}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildFilter() = %v, want %v", got, want)
+	}
+
+	// 组内条件都无效时整组忽略，不生成空数组
+	invalid := NewQuery().
+		AddCondition("status", OpEq, 1).
+		AddGroup(Or().Add("name", OpLike, 1).AddGroup(Not().Add("age", OpBetween, []interface{}{1})))
+	got = (&MongoQuerier[groupTestUser]{}).buildFilter(invalid)
+	if want := (bson.D{{Key: "status", Value: 1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("无效条件组 buildFilter() = %v, want %v", got, want)
+	}
+}
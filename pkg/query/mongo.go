@@ -3,16 +3,29 @@ package query
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"fiber_web/pkg/utils/errorx"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// mongoCursorKey MongoDB游标分页默认追加的唯一排序键
+const mongoCursorKey = "_id"
+
+// mongoSoftDeleteField MongoDB软删除字段，实体包含 bson 标签为该名称的字段时启用软删除
+const mongoSoftDeleteField = "deleted_at"
+
 // MongoQuerier MongoDB查询器
 type MongoQuerier[T any] struct {
 	BaseQuerier[T]
 	collection *mongo.Collection
+	schema     *Schema
+	softDelete bool // 是否启用软删除，启用时查询自动排除已删除的文档
 }
 
 // NewMongoQuerier 创建MongoDB查询器
@@ -20,62 +33,144 @@ func NewMongoQuerier[T any](collection *mongo.Collection) Querier[T] {
 	if collection == nil {
 		panic("collection cannot be nil")
 	}
+	return newMongoQuerier[T](collection)
+}
+
+// newMongoQuerier 创建MongoDB查询器实例
+func newMongoQuerier[T any](collection *mongo.Collection) *MongoQuerier[T] {
+	_, softDelete := bsonField(reflect.TypeOf((*T)(nil)).Elem(), mongoSoftDeleteField)
 	return &MongoQuerier[T]{
 		collection: collection,
+		schema:     LookupSchema[T](),
+		softDelete: softDelete,
 	}
 }
 
+// bsonField 查找 bson 名称为 name 的结构体字段
+func bsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if tag == "" {
+			tag = strings.ToLower(f.Name)
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
 // buildFilter 构建MongoDB查询条件
 func (q *MongoQuerier[T]) buildFilter(query *Query) bson.D {
 	if query == nil {
-		return bson.D{}
+		query = &Query{}
 	}
 
 	filter := bson.D{}
 	for _, cond := range query.Conditions {
-		if cond.Field == "" {
-			continue
+		if elem, ok := conditionFilter(cond); ok {
+			filter = append(filter, elem)
 		}
+	}
 
-		switch cond.Operator {
-		case OpEq:
-			filter = append(filter, bson.E{Key: cond.Field, Value: cond.Value})
-		case OpNe:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$ne": cond.Value}})
-		case OpGt:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$gt": cond.Value}})
-		case OpGte:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$gte": cond.Value}})
-		case OpLt:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$lt": cond.Value}})
-		case OpLte:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$lte": cond.Value}})
-		case OpIn:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$in": cond.Value}})
-		case OpNotIn:
-			filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$nin": cond.Value}})
-		case OpLike:
-			if str, ok := cond.Value.(string); ok {
-				filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$regex": str, "$options": "i"}})
-			}
-		case OpNotLike:
-			if str, ok := cond.Value.(string); ok {
-				filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$not": bson.M{"$regex": str, "$options": "i"}}})
-			}
-		case OpBetween:
-			if values, ok := cond.Value.([]interface{}); ok && len(values) == 2 {
-				filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$gte": values[0], "$lte": values[1]}})
-			}
-		case OpNotBetween:
-			if values, ok := cond.Value.([]interface{}); ok && len(values) == 2 {
-				filter = append(filter, bson.E{Key: cond.Field, Value: bson.M{"$not": bson.M{"$gte": values[0], "$lte": values[1]}}})
-			}
+	// 条件组统一放入 $and，避免多个 $or/$nor 顶层键冲突
+	groups := bson.A{}
+	for i := range query.Groups {
+		if group := buildGroupFilter(&query.Groups[i]); group != nil {
+			groups = append(groups, group)
 		}
 	}
+	if len(groups) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: groups})
+	}
+
+	// 排除已软删除的文档，字段为 null 或不存在时均视为未删除
+	if q.softDelete {
+		filter = append(filter, bson.E{Key: mongoSoftDeleteField, Value: nil})
+	}
 
 	return filter
 }
 
+// buildGroupFilter 将条件组转换为 $and/$or/$nor 过滤条件，空组或没有有效条件的组返回 nil
+func buildGroupFilter(group *ConditionGroup) bson.D {
+	if group == nil || group.IsEmpty() {
+		return nil
+	}
+
+	members := bson.A{}
+	for _, cond := range group.Conditions {
+		if elem, ok := conditionFilter(cond); ok {
+			members = append(members, bson.D{elem})
+		}
+	}
+	for i := range group.Groups {
+		if sub := buildGroupFilter(&group.Groups[i]); sub != nil {
+			members = append(members, sub)
+		}
+	}
+	// MongoDB 不接受空数组的 $and/$or/$nor
+	if len(members) == 0 {
+		return nil
+	}
+
+	switch group.Logic {
+	case LogicOr:
+		return bson.D{{Key: "$or", Value: members}}
+	case LogicNot:
+		return bson.D{{Key: "$nor", Value: members}}
+	default:
+		return bson.D{{Key: "$and", Value: members}}
+	}
+}
+
+// conditionFilter 将单个条件转换为MongoDB过滤元素
+func conditionFilter(cond Condition) (bson.E, bool) {
+	if cond.Field == "" {
+		return bson.E{}, false
+	}
+
+	switch cond.Operator {
+	case OpEq:
+		return bson.E{Key: cond.Field, Value: cond.Value}, true
+	case OpNe:
+		return bson.E{Key: cond.Field, Value: bson.M{"$ne": cond.Value}}, true
+	case OpGt:
+		return bson.E{Key: cond.Field, Value: bson.M{"$gt": cond.Value}}, true
+	case OpGte:
+		return bson.E{Key: cond.Field, Value: bson.M{"$gte": cond.Value}}, true
+	case OpLt:
+		return bson.E{Key: cond.Field, Value: bson.M{"$lt": cond.Value}}, true
+	case OpLte:
+		return bson.E{Key: cond.Field, Value: bson.M{"$lte": cond.Value}}, true
+	case OpIn:
+		return bson.E{Key: cond.Field, Value: bson.M{"$in": cond.Value}}, true
+	case OpNotIn:
+		return bson.E{Key: cond.Field, Value: bson.M{"$nin": cond.Value}}, true
+	case OpLike:
+		if str, ok := cond.Value.(string); ok {
+			return bson.E{Key: cond.Field, Value: bson.M{"$regex": str, "$options": "i"}}, true
+		}
+	case OpNotLike:
+		if str, ok := cond.Value.(string); ok {
+			return bson.E{Key: cond.Field, Value: bson.M{"$not": bson.M{"$regex": str, "$options": "i"}}}, true
+		}
+	case OpBetween:
+		if values, ok := cond.Value.([]interface{}); ok && len(values) == 2 {
+			return bson.E{Key: cond.Field, Value: bson.M{"$gte": values[0], "$lte": values[1]}}, true
+		}
+	case OpNotBetween:
+		if values, ok := cond.Value.([]interface{}); ok && len(values) == 2 {
+			return bson.E{Key: cond.Field, Value: bson.M{"$not": bson.M{"$gte": values[0], "$lte": values[1]}}}, true
+		}
+	}
+	return bson.E{}, false
+}
+
 // buildSort 构建MongoDB排序条件
 func (q *MongoQuerier[T]) buildSort(query *Query) bson.D {
 	if query == nil || len(query.OrderBy) == 0 {
@@ -127,48 +222,30 @@ func (q *MongoQuerier[T]) FindPage(ctx context.Context, query *Query) (*PageResu
 	if query == nil {
 		query = NewQuery()
 	}
-
-	filter := q.buildFilter(query)
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取总数
-	total, err := q.collection.CountDocuments(ctx, filter)
+	total, err := q.count(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("count documents error: %w", err)
+		return nil, err
 	}
 
 	// 处理分页参数
 	page, pageSize := q.HandlePagination(query.Pagination)
 
-	// 构建查询选项
-	findOptions := options.Find()
-
 	// 如果启用分页，设置分页参数
+	var skip, limit int64
 	if query.EnablePagination {
-		findOptions.SetSkip(int64((page - 1) * pageSize))
-		findOptions.SetLimit(int64(pageSize))
-	}
-
-	// 添加排序
-	if len(query.OrderBy) > 0 {
-		findOptions.SetSort(q.buildSort(query))
+		skip = int64((page - 1) * pageSize)
+		limit = int64(pageSize)
 	}
 
-	// 添加字段选择
-	if projection := q.buildProjection(query); projection != nil {
-		findOptions.SetProjection(projection)
-	}
-
-	// 执行查询
-	cursor, err := q.collection.Find(ctx, filter, findOptions)
+	list, err := q.find(ctx, query, q.buildSort(query), skip, limit)
 	if err != nil {
-		return nil, fmt.Errorf("find documents error: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	// 解码结果
-	var list []T
-	if err := cursor.All(ctx, &list); err != nil {
-		return nil, fmt.Errorf("decode documents error: %w", err)
+		return nil, err
 	}
 
 	return q.NewPageResult(list, total, page, pageSize), nil
@@ -180,13 +257,49 @@ func (q *MongoQuerier[T]) Count(ctx context.Context, query *Query) (int64, error
 		return 0, fmt.Errorf("context cannot be nil")
 	}
 
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return 0, err
+	}
+	return q.count(ctx, query)
+}
+
+// count 获取总数，query 需已校验；包含 Join 时通过聚合管道统计
+func (q *MongoQuerier[T]) count(ctx context.Context, query *Query) (int64, error) {
 	filter := q.buildFilter(query)
-	count, err := q.collection.CountDocuments(ctx, filter)
+	if query == nil || len(query.Joins) == 0 {
+		count, err := q.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("count documents error: %w", err)
+		}
+		return count, nil
+	}
+
+	pipeline, err := q.joinStages(query)
+	if err != nil {
+		return 0, err
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$count", Value: "total"}})
+
+	cursor, err := q.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, fmt.Errorf("count documents error: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	return count, nil
+	var rows []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, fmt.Errorf("decode count result error: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Total, nil
 }
 
 // First 获取单条记录
@@ -195,6 +308,23 @@ func (q *MongoQuerier[T]) First(ctx context.Context, query *Query) (*T, error) {
 		return nil, fmt.Errorf("context cannot be nil")
 	}
 
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// 包含关联时通过聚合管道查询
+	if query.hasRelations() {
+		list, err := q.find(ctx, query, q.buildSort(query), 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("find one document error: %w", mongo.ErrNoDocuments)
+		}
+		return &list[0], nil
+	}
+
 	filter := q.buildFilter(query)
 	findOptions := options.FindOne()
 
@@ -204,12 +334,12 @@ func (q *MongoQuerier[T]) First(ctx context.Context, query *Query) (*T, error) {
 	}
 
 	// 添加排序
-	if len(query.OrderBy) > 0 {
+	if query != nil && len(query.OrderBy) > 0 {
 		findOptions.SetSort(q.buildSort(query))
 	}
 
 	var result T
-	err := q.collection.FindOne(ctx, filter, findOptions).Decode(&result)
+	err = q.collection.FindOne(ctx, filter, findOptions).Decode(&result)
 	if err != nil {
 		//if err == mongo.ErrNoDocuments {
 		//	return nil, nil
@@ -226,20 +356,41 @@ func (q *MongoQuerier[T]) Find(ctx context.Context, query *Query) ([]T, error) {
 		return nil, fmt.Errorf("context cannot be nil")
 	}
 
-	filter := q.buildFilter(query)
-	findOptions := options.Find()
-
-	// 添加字段选择
-	if projection := q.buildProjection(query); projection != nil {
-		findOptions.SetProjection(projection)
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return nil, err
 	}
+	return q.find(ctx, query, q.buildSort(query), 0, 0)
+}
 
-	// 添加排序
-	if len(query.OrderBy) > 0 {
-		findOptions.SetSort(q.buildSort(query))
+// find 查询文档列表，query 需已校验；包含关联时使用聚合管道，skip/limit 为 0 表示不限制
+func (q *MongoQuerier[T]) find(ctx context.Context, query *Query, sort bson.D, skip, limit int64) ([]T, error) {
+	var (
+		cursor *mongo.Cursor
+		err    error
+	)
+	if query.hasRelations() {
+		pipeline, perr := q.findPipeline(query, sort, skip, limit)
+		if perr != nil {
+			return nil, perr
+		}
+		cursor, err = q.collection.Aggregate(ctx, pipeline)
+	} else {
+		findOptions := options.Find()
+		if len(sort) > 0 {
+			findOptions.SetSort(sort)
+		}
+		if skip > 0 {
+			findOptions.SetSkip(skip)
+		}
+		if limit > 0 {
+			findOptions.SetLimit(limit)
+		}
+		if projection := q.buildProjection(query); projection != nil {
+			findOptions.SetProjection(projection)
+		}
+		cursor, err = q.collection.Find(ctx, q.buildFilter(query), findOptions)
 	}
-
-	cursor, err := q.collection.Find(ctx, filter, findOptions)
 	if err != nil {
 		return nil, fmt.Errorf("find documents error: %w", err)
 	}
@@ -249,6 +400,256 @@ func (q *MongoQuerier[T]) Find(ctx context.Context, query *Query) ([]T, error) {
 	if err := cursor.All(ctx, &list); err != nil {
 		return nil, fmt.Errorf("decode documents error: %w", err)
 	}
-
 	return list, nil
 }
+
+// findPipeline 构建关联查询管道：$lookup(Join) -> $unwind -> $match -> $sort -> $skip -> $limit -> $lookup(Preload) -> $project
+// Join 的关联在过滤前展开，以支持按 "关联名.字段" 过滤；预加载的关联在分页之后加载，减少 $lookup 处理的文档数
+func (q *MongoQuerier[T]) findPipeline(query *Query, sort bson.D, skip, limit int64) (mongo.Pipeline, error) {
+	pipeline, err := q.joinStages(query)
+	if err != nil {
+		return nil, err
+	}
+	if filter := q.buildFilter(query); len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	if len(sort) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	for _, rel := range query.Preloads {
+		stage, err := lookupStage(rel)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, stage)
+	}
+
+	// 选择字段时保留关联结果字段
+	if projection := q.buildProjection(query); projection != nil {
+		for _, rel := range append(slices.Clone(query.Joins), query.Preloads...) {
+			if !slices.ContainsFunc(projection, func(e bson.E) bool { return e.Key == rel.Name }) {
+				projection = append(projection, bson.E{Key: rel.Name, Value: 1})
+			}
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
+	return pipeline, nil
+}
+
+// joinStages 构建 Join 关联的 $lookup 与 $unwind 阶段
+// 关联结果展开为单个子文档，INNER JOIN 时排除没有关联数据的文档
+func (q *MongoQuerier[T]) joinStages(query *Query) (mongo.Pipeline, error) {
+	pipeline := mongo.Pipeline{}
+	for _, rel := range query.Joins {
+		stage, err := lookupStage(rel)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, stage, bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$" + rel.Name},
+			{Key: "preserveNullAndEmptyArrays", Value: !rel.Inner},
+		}}})
+	}
+	return pipeline, nil
+}
+
+// lookupStage 构建关联的 $lookup 阶段，关联条件作为子管道的 $match
+func lookupStage(rel Relation) (bson.D, error) {
+	if rel.From == "" || rel.LocalField == "" || rel.ForeignField == "" {
+		return nil, newFieldError("关联缺少 $lookup 参数: %s", rel.Name)
+	}
+
+	lookup := bson.D{
+		{Key: "from", Value: rel.From},
+		{Key: "localField", Value: rel.LocalField},
+		{Key: "foreignField", Value: rel.ForeignField},
+	}
+	if len(rel.Conditions) > 0 {
+		match := bson.D{}
+		for _, cond := range rel.Conditions {
+			if elem, ok := conditionFilter(cond); ok {
+				match = append(match, elem)
+			}
+		}
+		lookup = append(lookup, bson.E{Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: match}}}})
+	}
+	lookup = append(lookup, bson.E{Key: "as", Value: rel.Name})
+	return bson.D{{Key: "$lookup", Value: lookup}}, nil
+}
+
+// Aggregate 分组聚合查询
+func (q *MongoQuerier[T]) Aggregate(ctx context.Context, query *Query, dest any) error {
+	if ctx == nil {
+		return fmt.Errorf("context cannot be nil")
+	}
+	if query == nil || len(query.Aggregations) == 0 {
+		return errorx.NewParamError("聚合查询至少需要一个聚合项")
+	}
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return err
+	}
+
+	// Join 的关联先展开，聚合时可按关联字段过滤和分组
+	pipeline, err := q.joinStages(query)
+	if err != nil {
+		return err
+	}
+	pipeline = append(pipeline, q.buildPipeline(query)...)
+
+	cursor, err := q.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("aggregate documents error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, dest); err != nil {
+		return fmt.Errorf("decode aggregate result error: %w", err)
+	}
+	return nil
+}
+
+// buildPipeline 构建聚合管道：$match -> $group -> $project -> $match(having) -> $sort
+// $project 将分组字段从 _id 中展开，结果文档的字段为分组字段和聚合别名
+func (q *MongoQuerier[T]) buildPipeline(query *Query) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if filter := q.buildFilter(query); len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	var id any
+	project := bson.D{{Key: "_id", Value: 0}}
+	if len(query.GroupBy) > 0 {
+		keys := bson.D{}
+		for _, field := range query.GroupBy {
+			// _id 子文档的键不能包含 "."
+			key := strings.ReplaceAll(field, ".", "_")
+			keys = append(keys, bson.E{Key: key, Value: "$" + field})
+			project = append(project, bson.E{Key: field, Value: "$_id." + key})
+		}
+		id = keys
+	}
+
+	group := bson.D{{Key: "_id", Value: id}}
+	for _, agg := range query.Aggregations {
+		group = append(group, bson.E{Key: agg.Alias, Value: accumulator(agg)})
+		if agg.Func == AggCountDistinct {
+			project = append(project, bson.E{Key: agg.Alias, Value: bson.M{"$size": "$" + agg.Alias}})
+		} else {
+			project = append(project, bson.E{Key: agg.Alias, Value: 1})
+		}
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: group}},
+		bson.D{{Key: "$project", Value: project}},
+	)
+
+	if len(query.Having) > 0 {
+		having := bson.D{}
+		for _, cond := range query.Having {
+			if elem, ok := conditionFilter(cond); ok {
+				having = append(having, elem)
+			}
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: having}})
+	}
+	if len(query.OrderBy) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: q.buildSort(query)}})
+	}
+	return pipeline
+}
+
+// accumulator 返回聚合项对应的 $group 累加器
+func accumulator(agg Aggregation) bson.M {
+	field := "$" + agg.Field
+	switch agg.Func {
+	case AggCount:
+		if agg.Field == "" {
+			return bson.M{"$sum": 1}
+		}
+		// 仅统计字段非空的文档，与 SQL COUNT(field) 一致
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{field, nil}}, 1, 0}}}
+	case AggCountDistinct:
+		return bson.M{"$addToSet": field}
+	}
+	return bson.M{"$" + string(agg.Func): field}
+}
+
+// FindCursor 游标分页查询
+func (q *MongoQuerier[T]) FindCursor(ctx context.Context, query *Query) (*CursorResult[T], error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if query == nil {
+		query = NewQuery()
+	}
+	query, err := resolveQuery(query, q.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := cursorKeys(query, mongoCursorKey)
+	cq := cursorQuery(query, keys)
+
+	_, pageSize := q.HandlePagination(query.Pagination)
+	cursor := ""
+	if query.Pagination != nil {
+		cursor = query.Pagination.Cursor
+	}
+
+	backward := false
+	if cursor != "" {
+		values, back, err := decodeCursor(cursor, keys)
+		if err != nil {
+			return nil, err
+		}
+		backward = back
+		cq.AddGroup(keysetGroup(keys, values, backward))
+	}
+
+	sort := bson.D{}
+	for _, key := range keys {
+		value := 1
+		if key.Desc != backward {
+			value = -1
+		}
+		sort = append(sort, bson.E{Key: key.Field, Value: value})
+	}
+
+	list, err := q.find(ctx, cq, sort, 0, int64(pageSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	return q.newCursorResult(list, keys, pageSize, cursor != "", backward, func(row *T) ([]any, error) {
+		return cursorValuesFromBSON(row, keys)
+	})
+}
+
+// cursorValuesFromBSON 通过BSON序列化提取记录中排序键对应的字段值
+func cursorValuesFromBSON(row any, keys []sortKey) ([]any, error) {
+	raw, err := bson.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("marshal document error: %w", err)
+	}
+
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		val, err := bson.Raw(raw).LookupErr(strings.Split(key.Field, ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("cursor field %s not found in document: %w", key.Field, err)
+		}
+		var v any
+		if err := val.Unmarshal(&v); err != nil {
+			return nil, fmt.Errorf("decode cursor field %s error: %w", key.Field, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,233 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRepository MongoDB通用仓储
+// 实体需通过 bson:"_id" 标签声明主键；包含 bson:"deleted_at" 字段时启用软删除
+type MongoRepository[T any] struct {
+	*MongoQuerier[T]
+}
+
+// NewMongoRepository 创建MongoDB通用仓储
+func NewMongoRepository[T any](collection *mongo.Collection) Repository[T] {
+	if collection == nil {
+		panic("collection cannot be nil")
+	}
+	return &MongoRepository[T]{MongoQuerier: newMongoQuerier[T](collection)}
+}
+
+// idFilter 构建按 _id 过滤的条件，scoped 为 true 时排除已软删除的文档
+func (r *MongoRepository[T]) idFilter(id any, scoped bool) bson.D {
+	filter := bson.D{{Key: "_id", Value: id}}
+	if scoped && r.softDelete {
+		filter = append(filter, bson.E{Key: mongoSoftDeleteField, Value: nil})
+	}
+	return filter
+}
+
+// Create 创建记录，实体 _id 为零值时回填生成的 _id
+func (r *MongoRepository[T]) Create(ctx context.Context, entity *T) error {
+	res, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		return fmt.Errorf("insert document error: %w", err)
+	}
+	setInsertedID(entity, res.InsertedID)
+	return nil
+}
+
+// CreateBatch 批量创建记录
+func (r *MongoRepository[T]) CreateBatch(ctx context.Context, entities []*T, batchSize int) error {
+	for _, batch := range batches(entities, batchSize) {
+		docs := make([]any, len(batch))
+		for i, entity := range batch {
+			docs[i] = entity
+		}
+		res, err := r.collection.InsertMany(ctx, docs)
+		if err != nil {
+			return fmt.Errorf("insert documents error: %w", err)
+		}
+		for i, id := range res.InsertedIDs {
+			setInsertedID(batch[i], id)
+		}
+	}
+	return nil
+}
+
+// Upsert 按 _id 插入或更新记录，实体没有 _id 时直接插入
+func (r *MongoRepository[T]) Upsert(ctx context.Context, entity *T, updateFields ...string) error {
+	if err := resolveColumns(updateFields); err != nil {
+		return err
+	}
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return fmt.Errorf("marshal document error: %w", err)
+	}
+	idVal, err := bson.Raw(raw).LookupErr("_id")
+	if err != nil {
+		return r.Create(ctx, entity)
+	}
+	var id any
+	if err := idVal.Unmarshal(&id); err != nil {
+		return fmt.Errorf("decode document id error: %w", err)
+	}
+
+	if len(updateFields) == 0 {
+		_, err = r.collection.ReplaceOne(ctx, r.idFilter(id, false), entity, options.Replace().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("upsert document error: %w", err)
+		}
+		return nil
+	}
+
+	// 冲突时仅更新指定字段，其余字段只在插入时写入
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("decode document error: %w", err)
+	}
+	delete(doc, "_id")
+	set := bson.M{}
+	for _, field := range updateFields {
+		set[field] = doc[field]
+		delete(doc, field)
+	}
+	update := bson.M{"$set": set}
+	if len(doc) > 0 {
+		update["$setOnInsert"] = doc
+	}
+	if _, err := r.collection.UpdateOne(ctx, r.idFilter(id, false), update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("upsert document error: %w", err)
+	}
+	return nil
+}
+
+// FindByID 根据 _id 查询
+func (r *MongoRepository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	var result T
+	if err := r.collection.FindOne(ctx, r.idFilter(id, true)).Decode(&result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return nil, fmt.Errorf("find document by id error: %w", err)
+	}
+	return &result, nil
+}
+
+// Update 根据 _id 替换整个文档
+func (r *MongoRepository[T]) Update(ctx context.Context, entity *T) error {
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return fmt.Errorf("marshal document error: %w", err)
+	}
+	idVal, err := bson.Raw(raw).LookupErr("_id")
+	if err != nil {
+		return fmt.Errorf("document has no _id: %w", err)
+	}
+	var id any
+	if err := idVal.Unmarshal(&id); err != nil {
+		return fmt.Errorf("decode document id error: %w", err)
+	}
+
+	if _, err := r.collection.ReplaceOne(ctx, r.idFilter(id, true), entity); err != nil {
+		return fmt.Errorf("replace document error: %w", err)
+	}
+	return nil
+}
+
+// UpdateFields 根据 _id 更新部分字段
+func (r *MongoRepository[T]) UpdateFields(ctx context.Context, id any, fields map[string]any) error {
+	if err := resolveFields(fields); err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateOne(ctx, r.idFilter(id, true), bson.M{"$set": fields}); err != nil {
+		return fmt.Errorf("update document fields error: %w", err)
+	}
+	return nil
+}
+
+// Delete 根据 _id 删除记录，启用软删除时设置 deleted_at
+func (r *MongoRepository[T]) Delete(ctx context.Context, id any) error {
+	if !r.softDelete {
+		return r.ForceDelete(ctx, id)
+	}
+	update := bson.M{"$set": bson.M{mongoSoftDeleteField: time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, r.idFilter(id, true), update); err != nil {
+		return fmt.Errorf("soft delete document error: %w", err)
+	}
+	return nil
+}
+
+// ForceDelete 根据 _id 物理删除记录
+func (r *MongoRepository[T]) ForceDelete(ctx context.Context, id any) error {
+	if _, err := r.collection.DeleteOne(ctx, r.idFilter(id, false)); err != nil {
+		return fmt.Errorf("delete document error: %w", err)
+	}
+	return nil
+}
+
+// Restore 恢复软删除的记录
+func (r *MongoRepository[T]) Restore(ctx context.Context, id any) error {
+	if !r.softDelete {
+		return ErrSoftDeleteNotSupported
+	}
+	update := bson.M{"$set": bson.M{mongoSoftDeleteField: nil}}
+	if _, err := r.collection.UpdateOne(ctx, r.idFilter(id, false), update); err != nil {
+		return fmt.Errorf("restore document error: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断是否存在满足条件的记录
+func (r *MongoRepository[T]) Exists(ctx context.Context, query *Query) (bool, error) {
+	if ctx == nil {
+		return false, fmt.Errorf("context cannot be nil")
+	}
+
+	query, err := resolveQuery(query, r.schema)
+	if err != nil {
+		return false, err
+	}
+
+	// 包含关联时通过聚合管道判断
+	if query.hasRelations() {
+		list, err := r.find(ctx, query, nil, 0, 1)
+		if err != nil {
+			return false, err
+		}
+		return len(list) > 0, nil
+	}
+
+	count, err := r.collection.CountDocuments(ctx, r.buildFilter(query), options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("check document exists error: %w", err)
+	}
+	return count > 0, nil
+}
+
+// setInsertedID 将生成的 _id 回填到实体的 bson:"_id" 字段，字段已有值或类型不匹配时忽略
+func setInsertedID(entity any, id any) {
+	rv := reflect.ValueOf(entity)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return
+	}
+	rv = rv.Elem()
+	f, ok := bsonField(rv.Type(), "_id")
+	if !ok {
+		return
+	}
+	field := rv.FieldByIndex(f.Index)
+	idv := reflect.ValueOf(id)
+	if !field.CanSet() || !field.IsZero() || !idv.IsValid() || !idv.Type().AssignableTo(field.Type()) {
+		return
+	}
+	field.Set(idv)
+}
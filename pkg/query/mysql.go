@@ -3,14 +3,24 @@ package query
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/utils/errorx"
 
 	"gorm.io/gorm"
 )
 
+// mysqlCursorKey MySQL游标分页默认追加的唯一排序键
+const mysqlCursorKey = "id"
+
 // MySQLQuerier MySQL查询器
 type MySQLQuerier[T any] struct {
 	BaseQuerier[T]
-	db *gorm.DB
+	db     *gorm.DB
+	schema *Schema
 }
 
 // NewMySQLQuerier 创建MySQL查询器
@@ -18,18 +28,41 @@ func NewMySQLQuerier[T any](db *gorm.DB) Querier[T] {
 	if db == nil {
 		panic("db cannot be nil")
 	}
+	return newMySQLQuerier[T](db)
+}
+
+// newMySQLQuerier 创建MySQL查询器实例
+func newMySQLQuerier[T any](db *gorm.DB) *MySQLQuerier[T] {
 	return &MySQLQuerier[T]{
-		db: db,
+		db:     db,
+		schema: LookupSchema[T](),
 	}
 }
 
-// buildConditions 构建查询条件
-func (q *MySQLQuerier[T]) buildConditions(query *Query) *gorm.DB {
-	if query == nil {
-		return q.db
+// resolve 校验查询参数，存在 Join 时为未指定表名的字段补全主表名，避免与关联表的列冲突
+func (q *MySQLQuerier[T]) resolve(query *Query) (*Query, error) {
+	query, err := resolveQuery(query, q.schema)
+	if err != nil || query == nil || len(query.Joins) == 0 {
+		return query, err
 	}
+	return qualifyQuery(query, q.table()), nil
+}
 
-	db := q.db
+// table 获取实体对应的表名，解析失败时返回空字符串
+func (q *MySQLQuerier[T]) table() string {
+	stmt := &gorm.Statement{DB: q.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return ""
+	}
+	return stmt.Schema.Table
+}
+
+// buildConditions 构建查询条件，ctx 中有事务时在事务连接上查询
+func (q *MySQLQuerier[T]) buildConditions(ctx context.Context, query *Query) *gorm.DB {
+	db := database.Conn(ctx, q.db)
+	if query == nil {
+		return db
+	}
 
 	// 添加选择字段
 	if len(query.SelectFields) > 0 {
@@ -38,39 +71,15 @@ func (q *MySQLQuerier[T]) buildConditions(query *Query) *gorm.DB {
 
 	// 添加查询条件
 	for _, cond := range query.Conditions {
-		if cond.Field == "" {
-			continue
-		}
-
-		switch cond.Operator {
-		case OpEq:
-			db = db.Where(fmt.Sprintf("%s = ?", cond.Field), cond.Value)
-		case OpNe:
-			db = db.Where(fmt.Sprintf("%s != ?", cond.Field), cond.Value)
-		case OpGt:
-			db = db.Where(fmt.Sprintf("%s > ?", cond.Field), cond.Value)
-		case OpGte:
-			db = db.Where(fmt.Sprintf("%s >= ?", cond.Field), cond.Value)
-		case OpLt:
-			db = db.Where(fmt.Sprintf("%s < ?", cond.Field), cond.Value)
-		case OpLte:
-			db = db.Where(fmt.Sprintf("%s <= ?", cond.Field), cond.Value)
-		case OpIn:
-			db = db.Where(fmt.Sprintf("%s IN ?", cond.Field), cond.Value)
-		case OpNotIn:
-			db = db.Where(fmt.Sprintf("%s NOT IN ?", cond.Field), cond.Value)
-		case OpLike:
-			db = db.Where(fmt.Sprintf("%s LIKE ?", cond.Field), fmt.Sprintf("%%%v%%", cond.Value))
-		case OpNotLike:
-			db = db.Where(fmt.Sprintf("%s NOT LIKE ?", cond.Field), fmt.Sprintf("%%%v%%", cond.Value))
-		case OpBetween:
-			if values, ok := cond.Value.([]any); ok && len(values) == 2 {
-				db = db.Where(fmt.Sprintf("%s BETWEEN ? AND ?", cond.Field), values[0], values[1])
-			}
-		case OpNotBetween:
-			if values, ok := cond.Value.([]any); ok && len(values) == 2 {
-				db = db.Where(fmt.Sprintf("%s NOT BETWEEN ? AND ?", cond.Field), values[0], values[1])
-			}
+		if expr, args, ok := conditionSQL(cond); ok {
+			db = db.Where(expr, args...)
+		}
+	}
+
+	// 添加条件组
+	for i := range query.Groups {
+		if group := q.buildGroup(&query.Groups[i]); group != nil {
+			db = db.Where(group)
 		}
 	}
 
@@ -92,17 +101,155 @@ func (q *MySQLQuerier[T]) buildConditions(query *Query) *gorm.DB {
 		}
 	}
 
+	// 添加关联
+	for _, rel := range query.Joins {
+		db = q.buildJoin(db, rel)
+	}
+	for _, rel := range query.Preloads {
+		db = q.buildPreload(db, rel)
+	}
+
+	return db
+}
+
+// buildJoin 通过 GORM Joins 关联一对一/多对一数据，关联条件作为 ON 条件
+// 关联表以关联名为别名（嵌套关联为 A__B），条件字段未指定表名时补全为别名
+func (q *MySQLQuerier[T]) buildJoin(db *gorm.DB, rel Relation) *gorm.DB {
+	join := db.Joins
+	if rel.Inner {
+		join = db.InnerJoins
+	}
+	if len(rel.Conditions) == 0 {
+		return join(rel.Name)
+	}
+
+	on := q.db.Session(&gorm.Session{NewDB: true})
+	for _, cond := range qualifyConditions(rel.Conditions, rel.alias()) {
+		if expr, args, ok := conditionSQL(cond); ok {
+			on = on.Where(expr, args...)
+		}
+	}
+	return join(rel.Name, on)
+}
+
+// buildPreload 通过 GORM Preload 预加载关联数据，嵌套关联的条件作用于最后一级
+func (q *MySQLQuerier[T]) buildPreload(db *gorm.DB, rel Relation) *gorm.DB {
+	if len(rel.Conditions) == 0 {
+		return db.Preload(rel.Name)
+	}
+	return db.Preload(rel.Name, func(tx *gorm.DB) *gorm.DB {
+		for _, cond := range rel.Conditions {
+			if expr, args, ok := conditionSQL(cond); ok {
+				tx = tx.Where(expr, args...)
+			}
+		}
+		return tx
+	})
+}
+
+// buildGroup 将条件组构建为带括号的 GORM 条件，空组返回 nil
+func (q *MySQLQuerier[T]) buildGroup(group *ConditionGroup) *gorm.DB {
+	if group == nil || group.IsEmpty() {
+		return nil
+	}
+
+	db := q.db.Session(&gorm.Session{NewDB: true})
+	first := true
+	add := func(expr any, args ...any) {
+		if first || group.Logic == LogicAnd {
+			db = db.Where(expr, args...)
+		} else {
+			db = db.Or(expr, args...)
+		}
+		first = false
+	}
+
+	for _, cond := range group.Conditions {
+		if expr, args, ok := conditionSQL(cond); ok {
+			add(expr, args...)
+		}
+	}
+	for i := range group.Groups {
+		if sub := q.buildGroup(&group.Groups[i]); sub != nil {
+			add(sub)
+		}
+	}
+
+	if group.Logic == LogicNot {
+		return q.db.Session(&gorm.Session{NewDB: true}).Not(db)
+	}
 	return db
 }
 
+// conditionSQL 将单个条件转换为 SQL 片段及其参数
+func conditionSQL(cond Condition) (string, []any, bool) {
+	if cond.Field == "" {
+		return "", nil, false
+	}
+
+	switch cond.Operator {
+	case OpEq:
+		if cond.Value == nil {
+			return fmt.Sprintf("%s IS NULL", cond.Field), nil, true
+		}
+		return fmt.Sprintf("%s = ?", cond.Field), []any{cond.Value}, true
+	case OpNe:
+		if cond.Value == nil {
+			return fmt.Sprintf("%s IS NOT NULL", cond.Field), nil, true
+		}
+		return fmt.Sprintf("%s != ?", cond.Field), []any{cond.Value}, true
+	case OpGt:
+		return fmt.Sprintf("%s > ?", cond.Field), []any{cond.Value}, true
+	case OpGte:
+		return fmt.Sprintf("%s >= ?", cond.Field), []any{cond.Value}, true
+	case OpLt:
+		return fmt.Sprintf("%s < ?", cond.Field), []any{cond.Value}, true
+	case OpLte:
+		return fmt.Sprintf("%s <= ?", cond.Field), []any{cond.Value}, true
+	case OpIn:
+		return fmt.Sprintf("%s IN ?", cond.Field), []any{cond.Value}, true
+	case OpNotIn:
+		return fmt.Sprintf("%s NOT IN ?", cond.Field), []any{cond.Value}, true
+	case OpLike:
+		return fmt.Sprintf("%s LIKE ?", cond.Field), []any{fmt.Sprintf("%%%v%%", cond.Value)}, true
+	case OpNotLike:
+		return fmt.Sprintf("%s NOT LIKE ?", cond.Field), []any{fmt.Sprintf("%%%v%%", cond.Value)}, true
+	case OpBetween:
+		if values, ok := cond.Value.([]any); ok && len(values) == 2 {
+			return fmt.Sprintf("%s BETWEEN ? AND ?", cond.Field), []any{values[0], values[1]}, true
+		}
+	case OpNotBetween:
+		if values, ok := cond.Value.([]any); ok && len(values) == 2 {
+			return fmt.Sprintf("%s NOT BETWEEN ? AND ?", cond.Field), []any{values[0], values[1]}, true
+		}
+	}
+	return "", nil, false
+}
+
 // Count 获取总数
 func (q *MySQLQuerier[T]) Count(ctx context.Context, query *Query) (int64, error) {
 	if ctx == nil {
 		return 0, fmt.Errorf("context cannot be nil")
 	}
 
+	query, err := q.resolve(query)
+	if err != nil {
+		return 0, err
+	}
+	return q.count(ctx, query)
+}
+
+// count 获取总数，query 需已校验
+func (q *MySQLQuerier[T]) count(ctx context.Context, query *Query) (int64, error) {
+	// 统计总数不需要预加载关联
+	if query != nil && len(query.Preloads) > 0 {
+		cq := *query
+		cq.Preloads = nil
+		query = &cq
+	}
+
 	var total int64
-	db := q.buildConditions(query).WithContext(ctx)
+	db := q.buildConditions(ctx, query)
 	if err := db.Model(new(T)).Count(&total).Error; err != nil {
 		return 0, fmt.Errorf("count records error: %w", err)
 	}
@@ -115,8 +262,13 @@ func (q *MySQLQuerier[T]) First(ctx context.Context, query *Query) (*T, error) {
 		return nil, fmt.Errorf("context cannot be nil")
 	}
 
+	query, err := q.resolve(query)
+	if err != nil {
+		return nil, err
+	}
+
 	var result T
-	db := q.buildConditions(query).WithContext(ctx)
+	db := q.buildConditions(ctx, query)
 	if err := db.First(&result).Error; err != nil {
 		//if errors.Is(err, gorm.ErrRecordNotFound) {
 		//	return nil, nil
@@ -132,8 +284,17 @@ func (q *MySQLQuerier[T]) Find(ctx context.Context, query *Query) ([]T, error) {
 		return nil, fmt.Errorf("context cannot be nil")
 	}
 
+	query, err := q.resolve(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.find(ctx, query)
+}
+
+// find 获取记录列表，query 需已校验
+func (q *MySQLQuerier[T]) find(ctx context.Context, query *Query) ([]T, error) {
 	var list []T
-	db := q.buildConditions(query).WithContext(ctx)
+	db := q.buildConditions(ctx, query)
 	if err := db.Find(&list).Error; err != nil {
 		return nil, fmt.Errorf("find records error: %w", err)
 	}
@@ -148,9 +309,13 @@ func (q *MySQLQuerier[T]) FindPage(ctx context.Context, query *Query) (*PageResu
 	if query == nil {
 		query = NewQuery()
 	}
+	query, err := q.resolve(query)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取总数
-	total, err := q.Count(ctx, query)
+	total, err := q.count(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +325,7 @@ func (q *MySQLQuerier[T]) FindPage(ctx context.Context, query *Query) (*PageResu
 
 	// 如果禁用分页，直接查询
 	if !query.EnablePagination {
-		list, err := q.Find(ctx, query)
+		list, err := q.find(ctx, query)
 		if err != nil {
 			return nil, err
 		}
@@ -169,7 +334,7 @@ func (q *MySQLQuerier[T]) FindPage(ctx context.Context, query *Query) (*PageResu
 
 	// 分页查询
 	var list []T
-	db := q.buildConditions(query).WithContext(ctx)
+	db := q.buildConditions(ctx, query)
 	offset := (page - 1) * pageSize
 	if err := db.Limit(pageSize).Offset(offset).Find(&list).Error; err != nil {
 		return nil, fmt.Errorf("find records with pagination error: %w", err)
@@ -177,3 +342,113 @@ func (q *MySQLQuerier[T]) FindPage(ctx context.Context, query *Query) (*PageResu
 
 	return q.NewPageResult(list, total, page, pageSize), nil
 }
+
+// Aggregate 分组聚合查询
+func (q *MySQLQuerier[T]) Aggregate(ctx context.Context, query *Query, dest any) error {
+	if ctx == nil {
+		return fmt.Errorf("context cannot be nil")
+	}
+	if query == nil || len(query.Aggregations) == 0 {
+		return errorx.NewParamError("聚合查询至少需要一个聚合项")
+	}
+	query, err := q.resolve(query)
+	if err != nil {
+		return err
+	}
+
+	// 选择字段由分组字段和聚合表达式组成
+	aq := *query
+	aq.SelectFields = nil
+	aq.Preloads = nil
+	selects := slices.Clone(query.GroupBy)
+	for _, agg := range query.Aggregations {
+		selects = append(selects, aggregateSQL(agg))
+	}
+
+	db := q.buildConditions(ctx, &aq).Model(new(T)).Select(strings.Join(selects, ", "))
+	for _, cond := range query.Having {
+		if expr, args, ok := conditionSQL(cond); ok {
+			db = db.Having(expr, args...)
+		}
+	}
+
+	if err := db.Scan(dest).Error; err != nil {
+		return fmt.Errorf("aggregate records error: %w", err)
+	}
+	return nil
+}
+
+// FindCursor 游标分页查询
+func (q *MySQLQuerier[T]) FindCursor(ctx context.Context, query *Query) (*CursorResult[T], error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if query == nil {
+		query = NewQuery()
+	}
+	query, err := q.resolve(query)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueKey := mysqlCursorKey
+	if len(query.Joins) > 0 {
+		uniqueKey = q.table() + "." + mysqlCursorKey
+	}
+	keys := cursorKeys(query, uniqueKey)
+	cq := cursorQuery(query, keys)
+	cq.OrderBy = nil
+
+	_, pageSize := q.HandlePagination(query.Pagination)
+	cursor := ""
+	if query.Pagination != nil {
+		cursor = query.Pagination.Cursor
+	}
+
+	backward := false
+	if cursor != "" {
+		values, back, err := decodeCursor(cursor, keys)
+		if err != nil {
+			return nil, err
+		}
+		backward = back
+		cq.AddGroup(keysetGroup(keys, values, backward))
+	}
+
+	db := q.buildConditions(ctx, cq)
+	for _, key := range keys {
+		db = db.Order(key.order(backward))
+	}
+
+	var list []T
+	if err := db.Limit(pageSize + 1).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("find records with cursor error: %w", err)
+	}
+
+	return q.newCursorResult(list, keys, pageSize, cursor != "", backward, func(row *T) ([]any, error) {
+		return q.cursorValues(row, keys)
+	})
+}
+
+// cursorValues 提取记录中排序键对应的字段值
+func (q *MySQLQuerier[T]) cursorValues(row *T, keys []sortKey) ([]any, error) {
+	stmt := &gorm.Statement{DB: q.db}
+	if err := stmt.Parse(row); err != nil {
+		return nil, fmt.Errorf("parse model error: %w", err)
+	}
+
+	rv := reflect.ValueOf(row).Elem()
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		name := key.Field
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return nil, fmt.Errorf("cursor field %s not found in model", key.Field)
+		}
+		values[i], _ = field.ValueOf(context.Background(), rv)
+	}
+	return values, nil
+}
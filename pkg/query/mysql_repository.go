@@ -0,0 +1,210 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"fiber_web/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// MySQLRepository MySQL通用仓储
+type MySQLRepository[T any] struct {
+	*MySQLQuerier[T]
+}
+
+// NewMySQLRepository 创建MySQL通用仓储
+func NewMySQLRepository[T any](db *gorm.DB) Repository[T] {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &MySQLRepository[T]{MySQLQuerier: newMySQLQuerier[T](db)}
+}
+
+// model 解析实体的 GORM 模型定义
+func (r *MySQLRepository[T]) model() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, fmt.Errorf("parse model error: %w", err)
+	}
+	return stmt.Schema, nil
+}
+
+// byID 构建按主键过滤的查询
+func (r *MySQLRepository[T]) byID(ctx context.Context, id any) (*gorm.DB, error) {
+	s, err := r.model()
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("model %s has no primary key", s.Name)
+	}
+	return database.Conn(ctx, r.db).Model(new(T)).Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName},
+		Value:  id,
+	}), nil
+}
+
+// Create 创建记录
+func (r *MySQLRepository[T]) Create(ctx context.Context, entity *T) error {
+	if err := database.Conn(ctx, r.db).Create(entity).Error; err != nil {
+		return fmt.Errorf("create record error: %w", err)
+	}
+	return nil
+}
+
+// CreateBatch 批量创建记录
+func (r *MySQLRepository[T]) CreateBatch(ctx context.Context, entities []*T, batchSize int) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if err := database.Conn(ctx, r.db).CreateInBatches(entities, batchSize).Error; err != nil {
+		return fmt.Errorf("create records in batches error: %w", err)
+	}
+	return nil
+}
+
+// Upsert 按主键插入或更新记录
+func (r *MySQLRepository[T]) Upsert(ctx context.Context, entity *T, updateFields ...string) error {
+	if err := resolveColumns(updateFields); err != nil {
+		return err
+	}
+	s, err := r.model()
+	if err != nil {
+		return err
+	}
+
+	onConflict := clause.OnConflict{}
+	for _, field := range s.PrimaryFields {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: field.DBName})
+	}
+	if len(updateFields) == 0 {
+		onConflict.UpdateAll = true
+	} else {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateFields)
+	}
+
+	if err := database.Conn(ctx, r.db).Clauses(onConflict).Create(entity).Error; err != nil {
+		return fmt.Errorf("upsert record error: %w", err)
+	}
+	return nil
+}
+
+// FindByID 根据主键查询
+func (r *MySQLRepository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	db, err := r.byID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+	if err := db.First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return nil, fmt.Errorf("find record by id error: %w", err)
+	}
+	return &result, nil
+}
+
+// Update 根据主键保存实体的全部字段
+func (r *MySQLRepository[T]) Update(ctx context.Context, entity *T) error {
+	if err := database.Conn(ctx, r.db).Save(entity).Error; err != nil {
+		return fmt.Errorf("update record error: %w", err)
+	}
+	return nil
+}
+
+// UpdateFields 根据主键更新部分字段
+func (r *MySQLRepository[T]) UpdateFields(ctx context.Context, id any, fields map[string]any) error {
+	if err := resolveFields(fields); err != nil {
+		return err
+	}
+	db, err := r.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := db.Updates(fields).Error; err != nil {
+		return fmt.Errorf("update record fields error: %w", err)
+	}
+	return nil
+}
+
+// Delete 根据主键删除记录，实体包含 gorm.DeletedAt 字段时执行软删除
+func (r *MySQLRepository[T]) Delete(ctx context.Context, id any) error {
+	db, err := r.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := db.Delete(new(T)).Error; err != nil {
+		return fmt.Errorf("delete record error: %w", err)
+	}
+	return nil
+}
+
+// ForceDelete 根据主键物理删除记录
+func (r *MySQLRepository[T]) ForceDelete(ctx context.Context, id any) error {
+	db, err := r.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := db.Unscoped().Delete(new(T)).Error; err != nil {
+		return fmt.Errorf("force delete record error: %w", err)
+	}
+	return nil
+}
+
+// Restore 恢复软删除的记录
+func (r *MySQLRepository[T]) Restore(ctx context.Context, id any) error {
+	s, err := r.model()
+	if err != nil {
+		return err
+	}
+
+	var deletedAt *schema.Field
+	for _, field := range s.Fields {
+		if field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+			deletedAt = field
+			break
+		}
+	}
+	if deletedAt == nil {
+		return ErrSoftDeleteNotSupported
+	}
+
+	db, err := r.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := db.Unscoped().Update(deletedAt.DBName, nil).Error; err != nil {
+		return fmt.Errorf("restore record error: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断是否存在满足条件的记录
+func (r *MySQLRepository[T]) Exists(ctx context.Context, query *Query) (bool, error) {
+	if ctx == nil {
+		return false, fmt.Errorf("context cannot be nil")
+	}
+
+	query, err := r.resolve(query)
+	if err != nil {
+		return false, err
+	}
+
+	var one int
+	db := r.buildConditions(ctx, query).Model(new(T)).Select("1").Limit(1).Scan(&one)
+	if db.Error != nil {
+		return false, fmt.Errorf("check record exists error: %w", db.Error)
+	}
+	return db.RowsAffected > 0, nil
+}
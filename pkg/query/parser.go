@@ -0,0 +1,233 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fiber_web/pkg/utils/errorx"
+)
+
+// filterKeyPattern 过滤参数格式：filter[字段] 或 filter[字段][操作符]
+var filterKeyPattern = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// timeLayouts 支持的时间格式
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// ValidationErrors 查询参数校验错误，键为参数名，值为错误信息
+type ValidationErrors map[string]string
+
+// Error 实现 error 接口
+func (e ValidationErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %s", k, e[k]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseValues 将 HTTP 查询参数解析为 Query
+// 支持的参数：
+//   - filter[字段][操作符]=值：操作符省略时为 eq；in/not_in 的值以逗号分隔，between/not_between 为逗号分隔的两个值
+//   - sort=-created_at,id：逗号分隔的排序字段，前缀 - 表示降序
+//   - fields=id,name：逗号分隔的选择字段
+//
+// s 不为 nil 时按 Schema 校验字段、操作符并按字段类型转换值，否则仅校验字段格式并按值的格式推断类型。
+// 返回的 Query 使用公开字段名，由查询器映射为列名；校验失败时返回 ValidationErrors。
+func ParseValues(values url.Values, s *Schema) (*Query, error) {
+	q := NewQuery()
+	errs := ValidationErrors{}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		match := filterKeyPattern.FindStringSubmatch(key)
+		if match == nil {
+			errs[key] = "参数格式错误，应为 filter[字段][操作符]"
+			continue
+		}
+		field, op := match[1], Operator(match[2])
+		if op == "" {
+			op = OpEq
+		}
+		for _, raw := range values[key] {
+			cond, err := parseCondition(field, op, raw, s)
+			if err != nil {
+				errs[key] = err.Error()
+				break
+			}
+			q.Conditions = append(q.Conditions, cond)
+		}
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		for _, item := range splitList(raw) {
+			desc := strings.HasPrefix(item, "-")
+			field := strings.TrimLeft(item, "+-")
+			if _, err := resolveField(field, s, func(f FieldSpec) bool { return f.Sortable }); err != nil {
+				errs["sort"] = fieldErrorMessage(err)
+				break
+			}
+			q.AddOrderBy(sortKey{Field: field, Desc: desc}.order(false))
+		}
+	}
+
+	if raw := values.Get("fields"); raw != "" {
+		for _, field := range splitList(raw) {
+			if _, err := resolveField(field, s, nil); err != nil {
+				errs["fields"] = fieldErrorMessage(err)
+				break
+			}
+			q.SelectFields = append(q.SelectFields, field)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return q, nil
+}
+
+// parseCondition 校验并解析单个过滤条件
+func parseCondition(field string, op Operator, raw string, s *Schema) (Condition, error) {
+	if _, ok := knownOperators[op]; !ok {
+		return Condition{}, fmt.Errorf("不支持的操作符: %s", op)
+	}
+	if _, err := resolveField(field, s, func(f FieldSpec) bool {
+		return f.Filterable && f.allows(op)
+	}); err != nil {
+		return Condition{}, errors.New(fieldErrorMessage(err))
+	}
+
+	kind := KindAny
+	if s != nil {
+		spec, _ := s.Lookup(field)
+		kind = spec.Kind
+	}
+
+	cond := Condition{Field: field, Operator: op}
+	switch op {
+	case OpLike, OpNotLike:
+		cond.Value = raw
+	case OpIn, OpNotIn, OpBetween, OpNotBetween:
+		items := splitList(raw)
+		if len(items) == 0 {
+			return Condition{}, errors.New("值不能为空")
+		}
+		if (op == OpBetween || op == OpNotBetween) && len(items) != 2 {
+			return Condition{}, errors.New("区间需要两个以逗号分隔的值")
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			v, err := coerceValue(item, kind)
+			if err != nil {
+				return Condition{}, err
+			}
+			list[i] = v
+		}
+		cond.Value = list
+	default:
+		v, err := coerceValue(raw, kind)
+		if err != nil {
+			return Condition{}, err
+		}
+		cond.Value = v
+	}
+	return cond, nil
+}
+
+// coerceValue 将字符串参数转换为字段类型对应的值
+func coerceValue(raw string, kind FieldKind) (any, error) {
+	switch kind {
+	case KindString:
+		return raw, nil
+	case KindInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("值必须是整数: %s", raw)
+		}
+		return v, nil
+	case KindUint:
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("值必须是非负整数: %s", raw)
+		}
+		return v, nil
+	case KindFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("值必须是数字: %s", raw)
+		}
+		return v, nil
+	case KindBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("值必须是布尔值: %s", raw)
+		}
+		return v, nil
+	case KindTime:
+		if v, ok := parseTime(raw); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("值必须是日期，格式为 2006-01-02、2006-01-02 15:04:05 或 RFC3339: %s", raw)
+	}
+
+	// 未知类型时按值的格式推断：整数、浮点数、日期，其余视为字符串
+	if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(raw, 64); err == nil {
+		return v, nil
+	}
+	if v, ok := parseTime(raw); ok {
+		return v, nil
+	}
+	return raw, nil
+}
+
+// parseTime 按支持的格式解析时间，不含时区的格式使用本地时区
+func parseTime(raw string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitList 按逗号拆分参数并去除空白项
+func splitList(raw string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// fieldErrorMessage 获取字段校验错误的提示信息
+func fieldErrorMessage(err error) string {
+	var xerr *errorx.Error
+	if errors.As(err, &xerr) {
+		return xerr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,142 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// parserTestUser 测试用模型
+type parserTestUser struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Status    int       `json:"status"`
+	Score     float64   `json:"score"`
+	Active    bool      `json:"active"`
+	Password  string    `json:"-"`
+	CreatedAt time.Time `json:"created_at" query:"filter,sort"`
+}
+
+func TestParseValues(t *testing.T) {
+	s := MustParseSchema(&parserTestUser{})
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		raw    string
+		schema *Schema
+		want   *Query
+	}{
+		{
+			name:   "过滤、排序与字段选择",
+			raw:    "filter[status][eq]=1&filter[name][like]=bob&sort=-created_at,id&fields=id,name",
+			schema: s,
+			want: &Query{
+				Conditions: []Condition{
+					{Field: "name", Operator: OpLike, Value: "bob"},
+					{Field: "status", Operator: OpEq, Value: int64(1)},
+				},
+				OrderBy:      []string{"created_at DESC", "id ASC"},
+				SelectFields: []string{"id", "name"},
+			},
+		},
+		{
+			name:   "省略操作符默认为 eq",
+			raw:    "filter[active]=true",
+			schema: s,
+			want:   &Query{Conditions: []Condition{{Field: "active", Operator: OpEq, Value: true}}},
+		},
+		{
+			name:   "列表与区间",
+			raw:    "filter[id][in]=1,2,3&filter[created_at][between]=2024-01-02,2024-01-02&filter[score][gte]=1.5",
+			schema: s,
+			want: &Query{Conditions: []Condition{
+				{Field: "created_at", Operator: OpBetween, Value: []any{date, date}},
+				{Field: "id", Operator: OpIn, Value: []any{uint64(1), uint64(2), uint64(3)}},
+				{Field: "score", Operator: OpGte, Value: 1.5},
+			}},
+		},
+		{
+			name: "无 Schema 时按格式推断类型",
+			raw:  "filter[age][gt]=18&filter[name]=bob&filter[created_at][lt]=2024-01-02",
+			want: &Query{Conditions: []Condition{
+				{Field: "age", Operator: OpGt, Value: int64(18)},
+				{Field: "created_at", Operator: OpLt, Value: date},
+				{Field: "name", Operator: OpEq, Value: "bob"},
+			}},
+		},
+		{
+			name:   "同一参数多个值",
+			raw:    "filter[status][ne]=1&filter[status][ne]=2",
+			schema: s,
+			want: &Query{Conditions: []Condition{
+				{Field: "status", Operator: OpNe, Value: int64(1)},
+				{Field: "status", Operator: OpNe, Value: int64(2)},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.raw)
+			got, err := ParseValues(values, tt.schema)
+			if err != nil {
+				t.Fatalf("ParseValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Conditions, tt.want.Conditions) {
+				t.Errorf("Conditions = %#v, want %#v", got.Conditions, tt.want.Conditions)
+			}
+			if len(got.OrderBy)+len(tt.want.OrderBy) > 0 && !reflect.DeepEqual(got.OrderBy, tt.want.OrderBy) {
+				t.Errorf("OrderBy = %v, want %v", got.OrderBy, tt.want.OrderBy)
+			}
+			if len(got.SelectFields)+len(tt.want.SelectFields) > 0 && !reflect.DeepEqual(got.SelectFields, tt.want.SelectFields) {
+				t.Errorf("SelectFields = %v, want %v", got.SelectFields, tt.want.SelectFields)
+			}
+		})
+	}
+}
+
+func TestParseValuesErrors(t *testing.T) {
+	s := MustParseSchema(&parserTestUser{})
+
+	tests := []struct {
+		name   string
+		raw    string
+		schema *Schema
+		keys   []string
+	}{
+		{"未注册字段", "filter[password][eq]=x", s, []string{"filter[password][eq]"}},
+		{"不支持的操作符", "filter[status][regex]=1", s, []string{"filter[status][regex]"}},
+		{"参数格式错误", "filter[status][eq][x]=1", s, []string{"filter[status][eq][x]"}},
+		{"整数类型错误", "filter[status][eq]=abc", s, []string{"filter[status][eq]"}},
+		{"日期类型错误", "filter[created_at][gt]=yesterday", s, []string{"filter[created_at][gt]"}},
+		{"区间值数量错误", "filter[id][between]=1", s, []string{"filter[id][between]"}},
+		{"排序字段未注册", "sort=-password", s, []string{"sort"}},
+		{"选择字段未注册", "fields=id,password", s, []string{"fields"}},
+		{"字段注入", "filter[id+%3D+1+OR+1][eq]=1&sort=id%3BDROP", nil, []string{"filter[id = 1 OR 1][eq]", "sort"}},
+		{"多个错误同时返回", "filter[status]=x&fields=secret", s, []string{"filter[status]", "fields"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.raw)
+			q, err := ParseValues(values, tt.schema)
+			if q != nil {
+				t.Errorf("ParseValues() = %v, want nil", q)
+			}
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("ParseValues() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.keys) {
+				t.Errorf("错误数量 = %d, want %d: %v", len(errs), len(tt.keys), errs)
+			}
+			for _, key := range tt.keys {
+				if errs[key] == "" {
+					t.Errorf("缺少参数 %s 的错误信息: %v", key, errs)
+				}
+			}
+		})
+	}
+}
@@ -13,6 +13,15 @@ type Querier[T any] interface {
 	//   - error: 查询过程中的错误信息
 	FindPage(ctx context.Context, q *Query) (*PageResult[T], error)
 
+	// FindCursor 游标（键集）分页查询，不执行 COUNT
+	// 参数：
+	//   - ctx: 上下文
+	//   - q: 查询参数，通过 SetCursor 设置游标与每页数量，排序字段取自 OrderBy
+	// 返回：
+	//   - *CursorResult[T]: 游标分页结果，包含上一页/下一页游标
+	//   - error: 游标无效时返回 ErrInvalidCursor
+	FindCursor(ctx context.Context, q *Query) (*CursorResult[T], error)
+
 	// Count 获取总数
 	// 参数：
 	//   - ctx: 上下文
@@ -39,6 +48,15 @@ type Querier[T any] interface {
 	//   - []T: 记录列表
 	//   - error: 查询过程中的错误信息
 	Find(ctx context.Context, q *Query) ([]T, error)
+
+	// Aggregate 分组聚合查询（不分页）
+	// 参数：
+	//   - ctx: 上下文
+	//   - q: 查询参数，通过 Count/Sum/Avg 等设置聚合项，GroupBy 为分组字段，Having 为分组过滤条件
+	//   - dest: 结果切片指针，如 *[]StatusStat，推荐使用泛型函数 Aggregate
+	// 返回：
+	//   - error: 查询过程中的错误信息
+	Aggregate(ctx context.Context, q *Query, dest any) error
 }
 
 // BaseQuerier 基础查询实现
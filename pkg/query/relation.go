@@ -0,0 +1,176 @@
+package query
+
+import "strings"
+
+// Relation 关联查询定义
+type Relation struct {
+	Name       string      `json:"name"`                 // 关联名称，嵌套关联以 . 分隔，如 "Roles.Apis"；MongoDB 中为结果字段名
+	Conditions []Condition `json:"conditions,omitempty"` // 关联数据的过滤条件，字段为关联表的列名
+	Inner      bool        `json:"inner,omitempty"`      // Join 时是否使用 INNER JOIN，默认 LEFT JOIN
+
+	// MongoDB $lookup 参数
+	From         string `json:"from,omitempty"`         // 关联集合
+	LocalField   string `json:"localField,omitempty"`   // 当前集合的关联字段
+	ForeignField string `json:"foreignField,omitempty"` // 关联集合的关联字段
+}
+
+// NewRelation 创建关联定义
+func NewRelation(name string, conds ...Condition) Relation {
+	return Relation{Name: name, Conditions: conds}
+}
+
+// Lookup 设置 MongoDB $lookup 参数
+func (r Relation) Lookup(from, localField, foreignField string) Relation {
+	r.From = from
+	r.LocalField = localField
+	r.ForeignField = foreignField
+	return r
+}
+
+// alias 返回 Join 时关联表的别名，与 GORM 嵌套 Joins 的命名一致
+func (r Relation) alias() string {
+	return strings.ReplaceAll(r.Name, ".", "__")
+}
+
+// Preload 预加载关联数据，关联数据通过单独的查询加载，不能用于过滤主表
+func (q *Query) Preload(name string, conds ...Condition) *Query {
+	return q.AddPreload(NewRelation(name, conds...))
+}
+
+// AddPreload 追加预加载关联
+func (q *Query) AddPreload(rel Relation) *Query {
+	q.Preloads = append(q.Preloads, rel)
+	return q
+}
+
+// Join 以 LEFT JOIN 关联一对一/多对一数据，可在查询条件中通过 "关联名.字段" 过滤
+func (q *Query) Join(name string, conds ...Condition) *Query {
+	return q.AddJoin(NewRelation(name, conds...))
+}
+
+// InnerJoin 以 INNER JOIN 关联数据，关联数据不存在的记录会被排除
+func (q *Query) InnerJoin(name string, conds ...Condition) *Query {
+	rel := NewRelation(name, conds...)
+	rel.Inner = true
+	return q.AddJoin(rel)
+}
+
+// AddJoin 追加 Join 关联
+func (q *Query) AddJoin(rel Relation) *Query {
+	q.Joins = append(q.Joins, rel)
+	return q
+}
+
+// hasRelations 是否包含关联查询
+func (q *Query) hasRelations() bool {
+	return q != nil && (len(q.Preloads) > 0 || len(q.Joins) > 0)
+}
+
+// resolveRelations 校验关联定义，关联条件的字段只校验标识符格式
+func resolveRelations(rels []Relation) ([]Relation, error) {
+	if rels == nil {
+		return nil, nil
+	}
+	resolved := make([]Relation, 0, len(rels))
+	for _, rel := range rels {
+		if !identifierPattern.MatchString(rel.Name) {
+			return nil, newFieldError("非法的关联名称: %s", rel.Name)
+		}
+		for _, field := range []string{rel.From, rel.LocalField, rel.ForeignField} {
+			if field != "" && !identifierPattern.MatchString(field) {
+				return nil, newFieldError("非法的关联字段: %s", field)
+			}
+		}
+		conds, err := resolveConditions(rel.Conditions, nil)
+		if err != nil {
+			return nil, err
+		}
+		rel.Conditions = conds
+		resolved = append(resolved, rel)
+	}
+	return resolved, nil
+}
+
+// qualifyQuery 为未指定表名的字段补全表名，返回查询副本，聚合别名不受影响
+func qualifyQuery(query *Query, table string) *Query {
+	if table == "" {
+		return query
+	}
+
+	rq := *query
+	rq.Conditions = qualifyConditions(query.Conditions, table)
+	if query.Groups != nil {
+		rq.Groups = make([]ConditionGroup, len(query.Groups))
+		for i := range query.Groups {
+			rq.Groups[i] = qualifyGroup(query.Groups[i], table)
+		}
+	}
+
+	aliases := make(map[string]bool, len(query.Aggregations))
+	if query.Aggregations != nil {
+		rq.Aggregations = make([]Aggregation, len(query.Aggregations))
+		for i, agg := range query.Aggregations {
+			aliases[agg.Alias] = true
+			if agg.Field != "" {
+				agg.Field = qualifyField(agg.Field, table)
+			}
+			rq.Aggregations[i] = agg
+		}
+	}
+	if query.OrderBy != nil {
+		rq.OrderBy = make([]string, 0, len(query.OrderBy))
+		for _, order := range query.OrderBy {
+			if key, ok := parseOrder(order); ok && !aliases[key.Field] {
+				key.Field = qualifyField(key.Field, table)
+				order = key.order(false)
+			}
+			rq.OrderBy = append(rq.OrderBy, order)
+		}
+	}
+	if query.GroupBy != nil {
+		rq.GroupBy = make([]string, len(query.GroupBy))
+		for i, group := range query.GroupBy {
+			rq.GroupBy[i] = qualifyField(group, table)
+		}
+	}
+	if query.SelectFields != nil {
+		rq.SelectFields = make([]string, len(query.SelectFields))
+		for i, field := range query.SelectFields {
+			rq.SelectFields[i] = qualifyField(field, table)
+		}
+	}
+	return &rq
+}
+
+// qualifyGroup 递归为条件组的字段补全表名
+func qualifyGroup(group ConditionGroup, table string) ConditionGroup {
+	qualified := ConditionGroup{Logic: group.Logic, Conditions: qualifyConditions(group.Conditions, table)}
+	if group.Groups != nil {
+		qualified.Groups = make([]ConditionGroup, len(group.Groups))
+		for i := range group.Groups {
+			qualified.Groups[i] = qualifyGroup(group.Groups[i], table)
+		}
+	}
+	return qualified
+}
+
+// qualifyConditions 为条件字段补全表名
+func qualifyConditions(conds []Condition, table string) []Condition {
+	if conds == nil {
+		return nil
+	}
+	qualified := make([]Condition, len(conds))
+	for i, cond := range conds {
+		cond.Field = qualifyField(cond.Field, table)
+		qualified[i] = cond
+	}
+	return qualified
+}
+
+// qualifyField 字段未指定表名时补全为 table.field
+func qualifyField(field, table string) string {
+	if field == "" || strings.Contains(field, ".") {
+		return field
+	}
+	return table + "." + field
+}
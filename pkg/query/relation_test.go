@@ -0,0 +1,245 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// relTestCompany 测试用多对一关联
+type relTestCompany struct {
+	ID   uint
+	Name string
+}
+
+// relTestItem 测试用嵌套关联
+type relTestItem struct {
+	ID      uint
+	OrderID uint
+	Sku     string
+}
+
+// relTestOrder 测试用一对多关联
+type relTestOrder struct {
+	ID     uint
+	UserID uint
+	Amount int
+	Items  []relTestItem `gorm:"foreignKey:OrderID"`
+}
+
+// relTestUser 测试用主表，与关联表均有 id/name 列
+type relTestUser struct {
+	ID        uint
+	Name      string
+	CompanyID *uint
+	Company   *relTestCompany
+	Orders    []relTestOrder `gorm:"foreignKey:UserID"`
+}
+
+// setupRelationTestDB 创建带关联数据的内存数据库
+func setupRelationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&relTestCompany{}, &relTestUser{}, &relTestOrder{}, &relTestItem{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	acme, globex := uint(1), uint(2)
+	fixtures := []any{
+		&[]relTestCompany{{ID: acme, Name: "acme"}, {ID: globex, Name: "globex"}},
+		&[]relTestUser{
+			{ID: 1, Name: "bob", CompanyID: &acme},
+			{ID: 2, Name: "alice", CompanyID: &globex},
+			{ID: 3, Name: "carol", CompanyID: &acme},
+			{ID: 4, Name: "dave"},
+		},
+		&[]relTestOrder{{ID: 1, UserID: 1, Amount: 5}, {ID: 2, UserID: 1, Amount: 50}, {ID: 3, UserID: 2, Amount: 20}},
+		&[]relTestItem{{ID: 1, OrderID: 2, Sku: "a"}, {ID: 2, OrderID: 2, Sku: "b"}},
+	}
+	for _, f := range fixtures {
+		if err := db.Omit(clause.Associations).Create(f).Error; err != nil {
+			t.Fatalf("写入测试数据失败: %v", err)
+		}
+	}
+	return db
+}
+
+func TestMySQLJoinFilter(t *testing.T) {
+	querier := NewMySQLQuerier[relTestUser](setupRelationTestDB(t))
+	ctx := context.Background()
+
+	// 主表与关联表都有 id/name 列，未指定表名的字段应补全为主表
+	q := NewQuery().
+		Join("Company").
+		AddCondition("Company.name", OpEq, "acme").
+		AddCondition("name", OpNe, "").
+		AddOrderBy("id DESC")
+	page, err := querier.FindPage(ctx, q)
+	if err != nil {
+		t.Fatalf("FindPage() error = %v", err)
+	}
+	if page.Total != 2 || len(page.List) != 2 {
+		t.Fatalf("FindPage() total = %d, len = %d, want 2", page.Total, len(page.List))
+	}
+	if got := page.List[0]; got.ID != 3 || got.Company == nil || got.Company.Name != "acme" {
+		t.Errorf("FindPage()[0] = %+v, want carol@acme", got)
+	}
+
+	// LEFT JOIN 保留没有关联的记录，INNER JOIN 排除
+	left, err := querier.Count(ctx, NewQuery().Join("Company"))
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	inner, err := querier.Count(ctx, NewQuery().InnerJoin("Company"))
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if left != 4 || inner != 3 {
+		t.Errorf("Count() left = %d, inner = %d, want 4, 3", left, inner)
+	}
+
+	// 关联条件作为 ON 条件，不匹配时关联为空但主表记录保留
+	list, err := querier.Find(ctx, NewQuery().
+		Join("Company", Condition{Field: "name", Operator: OpEq, Value: "globex"}).
+		AddOrderBy("id"))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(list) != 4 || list[0].Company != nil || list[1].Company == nil {
+		t.Errorf("Find() with join conditions = %+v", list)
+	}
+}
+
+func TestMySQLJoinCursor(t *testing.T) {
+	querier := NewMySQLQuerier[relTestUser](setupRelationTestDB(t))
+	ctx := context.Background()
+
+	q := NewQuery().InnerJoin("Company").SetCursor("", 2)
+	first, err := querier.FindCursor(ctx, q)
+	if err != nil {
+		t.Fatalf("FindCursor() error = %v", err)
+	}
+	if len(first.List) != 2 || !first.HasMore {
+		t.Fatalf("FindCursor() 首页 = %+v", first)
+	}
+
+	q = NewQuery().InnerJoin("Company").SetCursor(first.NextCursor, 2)
+	next, err := querier.FindCursor(ctx, q)
+	if err != nil {
+		t.Fatalf("FindCursor() error = %v", err)
+	}
+	if len(next.List) != 1 || next.List[0].ID != 3 || next.PrevCursor == "" {
+		t.Errorf("FindCursor() 第二页 = %+v", next)
+	}
+}
+
+func TestMySQLPreload(t *testing.T) {
+	querier := NewMySQLQuerier[relTestUser](setupRelationTestDB(t))
+	ctx := context.Background()
+
+	user, err := querier.First(ctx, NewQuery().
+		AddCondition("id", OpEq, 1).
+		Preload("Orders", Condition{Field: "amount", Operator: OpGt, Value: 10}).
+		Preload("Orders.Items"))
+	if err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if len(user.Orders) != 1 || user.Orders[0].Amount != 50 {
+		t.Fatalf("Preload() 条件未生效: %+v", user.Orders)
+	}
+	if len(user.Orders[0].Items) != 2 {
+		t.Errorf("嵌套 Preload() items = %+v, want 2", user.Orders[0].Items)
+	}
+
+	// 预加载不影响分页总数
+	page, err := querier.FindPage(ctx, NewQuery().Preload("Orders").Preload("Company"))
+	if err != nil {
+		t.Fatalf("FindPage() error = %v", err)
+	}
+	if page.Total != 4 || page.List[0].Company == nil || len(page.List[0].Orders) != 2 {
+		t.Errorf("FindPage() with preload = %+v", page)
+	}
+}
+
+func TestResolveRelations(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *Query
+		wantErr bool
+	}{
+		{"嵌套关联", NewQuery().Preload("Orders.Items"), false},
+		{"关联条件", NewQuery().Join("Company", Condition{Field: "name", Operator: OpEq, Value: "a"}), false},
+		{"非法关联名", NewQuery().Preload("Orders;DROP TABLE users"), true},
+		{"非法关联条件字段", NewQuery().Preload("Orders", Condition{Field: "1=1 OR amount", Operator: OpEq}), true},
+		{"非法关联条件操作符", NewQuery().Join("Company", Condition{Field: "name", Operator: "regexp"}), true},
+		{"非法 lookup 字段", NewQuery().AddPreload(NewRelation("roles").Lookup("roles", "role_ids", "_id;")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveQuery(tt.query, nil); (err != nil) != tt.wantErr {
+				t.Errorf("resolveQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMongoRelationPipeline(t *testing.T) {
+	q := NewQuery().
+		AddJoin(NewRelation("dept").Lookup("depts", "dept_id", "_id")).
+		AddPreload(NewRelation("roles", Condition{Field: "status", Operator: OpEq, Value: 1}).Lookup("roles", "role_ids", "_id")).
+		AddCondition("dept.name", OpEq, "dev").
+		Select("name")
+
+	rq, err := resolveQuery(q, nil)
+	if err != nil {
+		t.Fatalf("resolveQuery() error = %v", err)
+	}
+	querier := &MongoQuerier[groupTestUser]{}
+	got, err := querier.findPipeline(rq, bson.D{{Key: "name", Value: 1}}, 10, 5)
+	if err != nil {
+		t.Fatalf("findPipeline() error = %v", err)
+	}
+	want := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "depts"},
+			{Key: "localField", Value: "dept_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "dept"},
+		}}},
+		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$dept"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+		{{Key: "$match", Value: bson.D{{Key: "dept.name", Value: "dev"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}}}},
+		{{Key: "$skip", Value: int64(10)}},
+		{{Key: "$limit", Value: int64(5)}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "roles"},
+			{Key: "localField", Value: "role_ids"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: bson.D{{Key: "status", Value: 1}}}}}},
+			{Key: "as", Value: "roles"},
+		}}},
+		{{Key: "$project", Value: bson.D{{Key: "name", Value: 1}, {Key: "dept", Value: 1}, {Key: "roles", Value: 1}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPipeline() = %v, want %v", got, want)
+	}
+
+	// 缺少 $lookup 参数时返回参数错误
+	if _, err := querier.findPipeline(&Query{Joins: []Relation{NewRelation("dept")}}, nil, 0, 0); err == nil {
+		t.Error("findPipeline() 缺少 $lookup 参数时应返回错误")
+	}
+}
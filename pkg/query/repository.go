@@ -0,0 +1,99 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"fiber_web/pkg/utils/errorx"
+)
+
+var (
+	ErrNotFound               = errors.New("record not found")
+	ErrSoftDeleteNotSupported = errors.New("soft delete not supported")
+)
+
+// DefaultBatchSize 批量插入默认每批数量
+const DefaultBatchSize = 100
+
+// Repository 通用仓储接口，在 Querier 读操作的基础上提供写操作
+type Repository[T any] interface {
+	Querier[T]
+
+	// Create 创建记录，自增主键或 MongoDB 生成的 _id 会回填到实体
+	Create(ctx context.Context, entity *T) error
+
+	// CreateBatch 批量创建记录
+	// 参数：
+	//   - ctx: 上下文
+	//   - entities: 实体列表
+	//   - batchSize: 每批数量，小于等于 0 时使用 DefaultBatchSize
+	CreateBatch(ctx context.Context, entities []*T, batchSize int) error
+
+	// Upsert 按主键插入或更新记录
+	// 参数：
+	//   - ctx: 上下文
+	//   - entity: 实体
+	//   - updateFields: 主键冲突时更新的列（MongoDB 为文档字段），为空时更新全部字段
+	Upsert(ctx context.Context, entity *T, updateFields ...string) error
+
+	// FindByID 根据主键查询，记录不存在时返回 ErrNotFound
+	FindByID(ctx context.Context, id any) (*T, error)
+
+	// Update 根据主键保存实体的全部字段
+	Update(ctx context.Context, entity *T) error
+
+	// UpdateFields 根据主键更新部分字段
+	// 参数：
+	//   - ctx: 上下文
+	//   - id: 主键
+	//   - fields: 列名（MongoDB 为文档字段）到新值的映射，仅更新映射中的字段，零值同样会被更新
+	UpdateFields(ctx context.Context, id any, fields map[string]any) error
+
+	// Delete 根据主键删除记录，实体支持软删除时执行软删除
+	Delete(ctx context.Context, id any) error
+
+	// ForceDelete 根据主键物理删除记录
+	ForceDelete(ctx context.Context, id any) error
+
+	// Restore 恢复软删除的记录，实体不支持软删除时返回 ErrSoftDeleteNotSupported
+	Restore(ctx context.Context, id any) error
+
+	// Exists 判断是否存在满足条件的记录
+	Exists(ctx context.Context, q *Query) (bool, error)
+}
+
+// resolveFields 校验部分更新的字段名
+func resolveFields(fields map[string]any) error {
+	if len(fields) == 0 {
+		return errorx.NewParamError("更新字段不能为空")
+	}
+	for field := range fields {
+		if !identifierPattern.MatchString(field) {
+			return newFieldError("非法的更新字段: %s", field)
+		}
+	}
+	return nil
+}
+
+// resolveColumns 校验字段名列表
+func resolveColumns(columns []string) error {
+	for _, column := range columns {
+		if !identifierPattern.MatchString(column) {
+			return newFieldError("非法的更新字段: %s", column)
+		}
+	}
+	return nil
+}
+
+// batches 将实体列表按批次拆分
+func batches[T any](entities []*T, batchSize int) [][]*T {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	result := make([][]*T, 0, (len(entities)+batchSize-1)/batchSize)
+	for start := 0; start < len(entities); start += batchSize {
+		end := min(start+batchSize, len(entities))
+		result = append(result, entities[start:end])
+	}
+	return result
+}
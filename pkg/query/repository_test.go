@@ -0,0 +1,242 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fiber_web/pkg/database"
+	"fiber_web/pkg/utils/errorx"
+
+	"github.com/glebarez/sqlite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// repoTestRole 测试用软删除模型
+type repoTestRole struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"uniqueIndex"`
+	Sort      int
+	DeletedAt gorm.DeletedAt
+}
+
+// setupRepoTestDB 创建内存 SQLite 数据库
+func setupRepoTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&repoTestRole{}, &groupTestUser{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	return db
+}
+
+func TestMySQLRepositoryCRUD(t *testing.T) {
+	db := setupRepoTestDB(t)
+	repo := NewMySQLRepository[repoTestRole](db)
+	ctx := context.Background()
+
+	role := &repoTestRole{Name: "admin", Sort: 1}
+	if err := repo.Create(ctx, role); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if role.ID == 0 {
+		t.Fatal("Create() 未回填主键")
+	}
+
+	batch := []*repoTestRole{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	if err := repo.CreateBatch(ctx, batch, 2); err != nil {
+		t.Fatalf("CreateBatch() error = %v", err)
+	}
+	if total, _ := repo.Count(ctx, nil); total != 4 {
+		t.Errorf("Count() = %d, want 4", total)
+	}
+
+	if err := repo.UpdateFields(ctx, role.ID, map[string]any{"sort": 0}); err != nil {
+		t.Fatalf("UpdateFields() error = %v", err)
+	}
+	got, err := repo.FindByID(ctx, role.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Sort != 0 || got.Name != "admin" {
+		t.Errorf("UpdateFields() 后 = %+v", got)
+	}
+
+	got.Name = "root"
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if exists, _ := repo.Exists(ctx, NewQuery().AddCondition("name", OpEq, "root")); !exists {
+		t.Error("Update() 后记录应存在")
+	}
+
+	if err := repo.UpdateFields(ctx, role.ID, map[string]any{"sort = 1, name": 1}); !errorx.IsCode(err, errorx.CodeInvalidParam) {
+		t.Errorf("UpdateFields() 非法字段 error = %v, want param error", err)
+	}
+	if _, err := repo.FindByID(ctx, 999); !errors.Is(err, ErrNotFound) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("FindByID() 不存在 error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMySQLRepositorySoftDelete(t *testing.T) {
+	db := setupRepoTestDB(t)
+	repo := NewMySQLRepository[repoTestRole](db)
+	ctx := context.Background()
+
+	role := &repoTestRole{Name: "admin"}
+	if err := repo.Create(ctx, role); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := repo.Delete(ctx, role.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, role.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("软删除后 FindByID() error = %v, want ErrNotFound", err)
+	}
+	if exists, _ := repo.Exists(ctx, NewQuery().AddCondition("name", OpEq, "admin")); exists {
+		t.Error("软删除后 Exists() 应为 false")
+	}
+	var count int64
+	db.Unscoped().Model(&repoTestRole{}).Count(&count)
+	if count != 1 {
+		t.Errorf("软删除后物理记录数 = %d, want 1", count)
+	}
+
+	if err := repo.Restore(ctx, role.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, role.ID); err != nil {
+		t.Errorf("Restore() 后 FindByID() error = %v", err)
+	}
+
+	if err := repo.ForceDelete(ctx, role.ID); err != nil {
+		t.Fatalf("ForceDelete() error = %v", err)
+	}
+	db.Unscoped().Model(&repoTestRole{}).Count(&count)
+	if count != 0 {
+		t.Errorf("物理删除后记录数 = %d, want 0", count)
+	}
+
+	if err := NewMySQLRepository[groupTestUser](db).Restore(ctx, 1); !errors.Is(err, ErrSoftDeleteNotSupported) {
+		t.Errorf("Restore() error = %v, want ErrSoftDeleteNotSupported", err)
+	}
+}
+
+func TestMySQLRepositoryUpsert(t *testing.T) {
+	db := setupRepoTestDB(t)
+	repo := NewMySQLRepository[repoTestRole](db)
+	ctx := context.Background()
+
+	if err := repo.Upsert(ctx, &repoTestRole{ID: 1, Name: "admin", Sort: 1}); err != nil {
+		t.Fatalf("Upsert() 插入 error = %v", err)
+	}
+	if err := repo.Upsert(ctx, &repoTestRole{ID: 1, Name: "root", Sort: 2}, "sort"); err != nil {
+		t.Fatalf("Upsert() 更新指定字段 error = %v", err)
+	}
+	got, _ := repo.FindByID(ctx, 1)
+	if got.Name != "admin" || got.Sort != 2 {
+		t.Errorf("Upsert() 仅更新 sort 后 = %+v", got)
+	}
+
+	if err := repo.Upsert(ctx, &repoTestRole{ID: 1, Name: "root", Sort: 3}); err != nil {
+		t.Fatalf("Upsert() 更新全部字段 error = %v", err)
+	}
+	got, _ = repo.FindByID(ctx, 1)
+	if got.Name != "root" || got.Sort != 3 {
+		t.Errorf("Upsert() 更新全部字段后 = %+v", got)
+	}
+
+	if err := repo.Upsert(ctx, &repoTestRole{ID: 1}, "sort; DROP TABLE"); !errorx.IsCode(err, errorx.CodeInvalidParam) {
+		t.Errorf("Upsert() 非法字段 error = %v, want param error", err)
+	}
+}
+
+func TestMySQLRepositoryTransaction(t *testing.T) {
+	db := setupRepoTestDB(t)
+	repo := NewMySQLRepository[repoTestRole](db)
+	querier := NewMySQLQuerier[repoTestRole](db)
+	tm := database.NewTxManager(db, database.TxOptions{})
+	ctx := context.Background()
+
+	// 仓储与查询器通过 ctx 加入同一个事务，失败时整体回滚
+	errFail := errors.New("fail")
+	err := tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &repoTestRole{Name: "admin"}); err != nil {
+			return err
+		}
+		if total, err := querier.Count(ctx, NewQuery()); err != nil || total != 1 {
+			t.Errorf("事务内 Count() = %d, %v, want 1", total, err)
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Transaction() error = %v, want %v", err, errFail)
+	}
+	if total, _ := repo.Count(ctx, nil); total != 0 {
+		t.Errorf("回滚后 Count() = %d, want 0", total)
+	}
+
+	err = tm.Transaction(ctx, func(ctx context.Context) error {
+		return repo.Create(ctx, &repoTestRole{Name: "admin"})
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if exists, _ := repo.Exists(ctx, NewQuery().AddCondition("name", OpEq, "admin")); !exists {
+		t.Error("提交后记录应存在")
+	}
+}
+
+// mongoRepoTestDoc MongoDB测试文档
+type mongoRepoTestDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty"`
+}
+
+func TestMongoRepositoryHelpers(t *testing.T) {
+	q := newMongoQuerier[mongoRepoTestDoc](nil)
+	if !q.softDelete {
+		t.Fatal("包含 deleted_at 字段的文档应启用软删除")
+	}
+	filter := q.buildFilter(NewQuery().AddCondition("name", OpEq, "a"))
+	want := bson.D{{Key: "name", Value: "a"}, {Key: "deleted_at", Value: nil}}
+	if len(filter) != 2 || filter[1] != want[1] {
+		t.Errorf("buildFilter() = %v, want %v", filter, want)
+	}
+	if newMongoQuerier[groupTestUser](nil).softDelete {
+		t.Error("不包含 deleted_at 字段的文档不应启用软删除")
+	}
+
+	doc := &mongoRepoTestDoc{}
+	id := primitive.NewObjectID()
+	setInsertedID(doc, id)
+	if doc.ID != id {
+		t.Errorf("setInsertedID() = %v, want %v", doc.ID, id)
+	}
+	setInsertedID(doc, primitive.NewObjectID())
+	if doc.ID != id {
+		t.Error("setInsertedID() 不应覆盖已有的 _id")
+	}
+	setInsertedID(&mongoRepoTestDoc{}, "not-an-object-id")
+
+	entities := make([]*mongoRepoTestDoc, 5)
+	if got := batches(entities, 2); len(got) != 3 || len(got[2]) != 1 {
+		t.Errorf("batches() = %d 批", len(got))
+	}
+	if got := batches(entities, 0); len(got) != 1 {
+		t.Errorf("batches() 默认批次 = %d 批, want 1", len(got))
+	}
+}
@@ -0,0 +1,359 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"fiber_web/pkg/utils/errorx"
+
+	"gorm.io/gorm/schema"
+)
+
+// identifierPattern 合法的字段标识符，允许 table.column 形式
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// FieldKind 字段值类型，用于将字符串参数转换为对应类型
+type FieldKind int
+
+const (
+	KindAny    FieldKind = iota // 未知类型，按值的格式推断
+	KindString                  // 字符串
+	KindInt                     // 整数
+	KindUint                    // 无符号整数
+	KindFloat                   // 浮点数
+	KindBool                    // 布尔值
+	KindTime                    // 时间
+)
+
+// FieldSpec 字段定义
+type FieldSpec struct {
+	Name       string     // 对外公开的字段名
+	Column     string     // 数据库列名（MongoDB 中为文档字段名）
+	Kind       FieldKind  // 字段值类型
+	Filterable bool       // 是否允许作为查询条件和分组字段
+	Sortable   bool       // 是否允许排序
+	Operators  []Operator // 允许的操作符，为空表示不限制
+}
+
+// allows 判断字段是否允许使用指定操作符
+func (f FieldSpec) allows(op Operator) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+	for _, allowed := range f.Operators {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema 实体查询字段白名单，负责校验字段并将公开字段名映射为列名
+type Schema struct {
+	fields map[string]FieldSpec
+}
+
+// NewSchema 显式注册字段创建 Schema
+func NewSchema(fields ...FieldSpec) *Schema {
+	s := &Schema{fields: make(map[string]FieldSpec, len(fields))}
+	for _, f := range fields {
+		s.Add(f)
+	}
+	return s
+}
+
+// ParseSchema 根据 GORM 模型的结构体标签生成 Schema
+// 公开字段名取 json 标签，未设置时使用列名；列名取 GORM 解析结果。
+// json:"-" 或 query:"-" 的字段不会被注册；query 标签可设置为 "filter"、"sort" 或 "filter,sort" 以限制用途，默认两者皆可。
+func ParseSchema(model any) (*Schema, error) {
+	parsed, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, fmt.Errorf("parse model schema error: %w", err)
+	}
+
+	s := NewSchema()
+	for _, field := range parsed.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		tag := field.Tag.Get("query")
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" || jsonName == "-" {
+			continue
+		}
+
+		spec := FieldSpec{Name: jsonName, Column: field.DBName, Kind: fieldKind(field.GORMDataType), Filterable: true, Sortable: true}
+		if spec.Name == "" {
+			spec.Name = field.DBName
+		}
+		if tag != "" {
+			spec.Filterable = strings.Contains(tag, "filter")
+			spec.Sortable = strings.Contains(tag, "sort")
+		}
+		s.Add(spec)
+	}
+	return s, nil
+}
+
+// fieldKind 将 GORM 数据类型转换为字段值类型
+func fieldKind(dataType schema.DataType) FieldKind {
+	switch dataType {
+	case schema.String:
+		return KindString
+	case schema.Int:
+		return KindInt
+	case schema.Uint:
+		return KindUint
+	case schema.Float:
+		return KindFloat
+	case schema.Bool:
+		return KindBool
+	case schema.Time:
+		return KindTime
+	}
+	return KindAny
+}
+
+// MustParseSchema 同 ParseSchema，解析失败时 panic
+func MustParseSchema(model any) *Schema {
+	s, err := ParseSchema(model)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Add 注册字段，未设置列名时与公开字段名相同
+func (s *Schema) Add(field FieldSpec) *Schema {
+	if field.Column == "" {
+		field.Column = field.Name
+	}
+	s.fields[field.Name] = field
+	return s
+}
+
+// Omit 移除指定的公开字段
+func (s *Schema) Omit(names ...string) *Schema {
+	for _, name := range names {
+		delete(s.fields, name)
+	}
+	return s
+}
+
+// Lookup 根据公开字段名查找字段定义
+func (s *Schema) Lookup(name string) (FieldSpec, bool) {
+	f, ok := s.fields[name]
+	return f, ok
+}
+
+var schemas sync.Map // map[reflect.Type]*Schema
+
+// RegisterSchema 为实体类型注册查询字段白名单
+func RegisterSchema[T any](s *Schema) {
+	schemas.Store(reflect.TypeOf((*T)(nil)).Elem(), s)
+}
+
+// LookupSchema 获取实体类型注册的 Schema，未注册时返回 nil
+func LookupSchema[T any]() *Schema {
+	if s, ok := schemas.Load(reflect.TypeOf((*T)(nil)).Elem()); ok {
+		return s.(*Schema)
+	}
+	return nil
+}
+
+// newFieldError 创建字段参数错误
+func newFieldError(format, field string) *errorx.Error {
+	return errorx.NewParamError(fmt.Sprintf(format, field)).WithContext("field", field)
+}
+
+// knownOperators 支持的操作符
+var knownOperators = map[Operator]struct{}{
+	OpEq: {}, OpNe: {}, OpGt: {}, OpGte: {}, OpLt: {}, OpLte: {},
+	OpIn: {}, OpNotIn: {}, OpLike: {}, OpNotLike: {}, OpBetween: {}, OpNotBetween: {},
+}
+
+// resolveQuery 在构建任何 SQL/过滤条件之前校验查询参数
+// 所有字段都必须是合法标识符；设置了 Schema 时，字段还必须在白名单内，并被映射为列名。
+// 返回映射后的查询副本，原查询不会被修改。
+func resolveQuery(query *Query, s *Schema) (*Query, error) {
+	if query == nil {
+		return nil, nil
+	}
+
+	rq := *query
+	var err error
+
+	if rq.Conditions, err = resolveConditions(query.Conditions, s); err != nil {
+		return nil, err
+	}
+
+	// 聚合别名可用于 HAVING 和排序
+	aliases := make(map[string]bool, len(query.Aggregations))
+	if query.Aggregations != nil {
+		if rq.Aggregations, err = resolveAggregations(query.Aggregations, s); err != nil {
+			return nil, err
+		}
+		for _, agg := range rq.Aggregations {
+			aliases[agg.Alias] = true
+		}
+	}
+	if query.Having != nil {
+		rq.Having = make([]Condition, 0, len(query.Having))
+		for _, cond := range query.Having {
+			if aliases[cond.Field] {
+				if _, ok := knownOperators[cond.Operator]; !ok {
+					return nil, newFieldError("不支持的操作符: %s", string(cond.Operator))
+				}
+				rq.Having = append(rq.Having, cond)
+				continue
+			}
+			resolved, err := resolveConditions([]Condition{cond}, s)
+			if err != nil {
+				return nil, err
+			}
+			rq.Having = append(rq.Having, resolved...)
+		}
+	}
+	if query.Groups != nil {
+		rq.Groups = make([]ConditionGroup, len(query.Groups))
+		for i := range query.Groups {
+			if rq.Groups[i], err = resolveGroup(query.Groups[i], s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if query.OrderBy != nil {
+		rq.OrderBy = make([]string, 0, len(query.OrderBy))
+		for _, order := range query.OrderBy {
+			if strings.TrimSpace(order) == "" {
+				continue
+			}
+			parts := strings.Fields(order)
+			if len(parts) > 2 || (len(parts) == 2 && !strings.EqualFold(parts[1], "ASC") && !strings.EqualFold(parts[1], "DESC")) {
+				return nil, newFieldError("不支持的排序格式: %s", order)
+			}
+			key, _ := parseOrder(order)
+			if !aliases[key.Field] {
+				column, err := resolveField(key.Field, s, func(f FieldSpec) bool { return f.Sortable })
+				if err != nil {
+					return nil, err
+				}
+				key.Field = column
+			}
+			rq.OrderBy = append(rq.OrderBy, key.order(false))
+		}
+	}
+
+	if query.GroupBy != nil {
+		rq.GroupBy = make([]string, 0, len(query.GroupBy))
+		for _, group := range query.GroupBy {
+			if group == "" {
+				continue
+			}
+			column, err := resolveField(group, s, func(f FieldSpec) bool { return f.Filterable })
+			if err != nil {
+				return nil, err
+			}
+			rq.GroupBy = append(rq.GroupBy, column)
+		}
+	}
+
+	if query.SelectFields != nil {
+		rq.SelectFields = make([]string, 0, len(query.SelectFields))
+		for _, field := range query.SelectFields {
+			if field == "" {
+				continue
+			}
+			column, err := resolveField(field, s, nil)
+			if err != nil {
+				return nil, err
+			}
+			rq.SelectFields = append(rq.SelectFields, column)
+		}
+	}
+
+	if rq.Preloads, err = resolveRelations(query.Preloads); err != nil {
+		return nil, err
+	}
+	if rq.Joins, err = resolveRelations(query.Joins); err != nil {
+		return nil, err
+	}
+
+	return &rq, nil
+}
+
+// resolveGroup 递归校验条件组
+func resolveGroup(group ConditionGroup, s *Schema) (ConditionGroup, error) {
+	switch group.Logic {
+	case LogicAnd, LogicOr, LogicNot:
+	default:
+		return group, newFieldError("不支持的条件组逻辑: %s", string(group.Logic))
+	}
+
+	resolved := ConditionGroup{Logic: group.Logic}
+	var err error
+	if resolved.Conditions, err = resolveConditions(group.Conditions, s); err != nil {
+		return group, err
+	}
+	if group.Groups != nil {
+		resolved.Groups = make([]ConditionGroup, len(group.Groups))
+		for i := range group.Groups {
+			if resolved.Groups[i], err = resolveGroup(group.Groups[i], s); err != nil {
+				return group, err
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// resolveConditions 校验条件列表
+func resolveConditions(conds []Condition, s *Schema) ([]Condition, error) {
+	if conds == nil {
+		return nil, nil
+	}
+	resolved := make([]Condition, 0, len(conds))
+	for _, cond := range conds {
+		if cond.Field == "" {
+			continue
+		}
+		if _, ok := knownOperators[cond.Operator]; !ok {
+			return nil, newFieldError("不支持的操作符: %s", string(cond.Operator))
+		}
+		column, err := resolveField(cond.Field, s, func(f FieldSpec) bool {
+			return f.Filterable && f.allows(cond.Operator)
+		})
+		if err != nil {
+			return nil, err
+		}
+		cond.Field = column
+		resolved = append(resolved, cond)
+	}
+	return resolved, nil
+}
+
+// resolveField 校验单个字段并返回列名
+func resolveField(name string, s *Schema, allowed func(FieldSpec) bool) (string, error) {
+	if s == nil {
+		if !identifierPattern.MatchString(name) {
+			return "", newFieldError("非法的查询字段: %s", name)
+		}
+		return name, nil
+	}
+
+	f, ok := s.Lookup(name)
+	if !ok {
+		return "", newFieldError("不支持的查询字段: %s", name)
+	}
+	if allowed != nil && !allowed(f) {
+		return "", newFieldError("字段不支持该用法: %s", name)
+	}
+	if !identifierPattern.MatchString(f.Column) {
+		return "", newFieldError("非法的查询字段: %s", f.Column)
+	}
+	return f.Column, nil
+}
@@ -0,0 +1,156 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"fiber_web/pkg/utils/errorx"
+)
+
+// schemaTestUser 测试用模型
+type schemaTestUser struct {
+	ID       uint   `json:"id" gorm:"primarykey"`
+	UserName string `json:"userName" gorm:"column:name"`
+	Email    string `json:"email" query:"filter"`
+	Age      int    `json:"age" query:"sort"`
+	Password string `json:"-"`
+	Secret   string `query:"-"`
+	Status   int
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema(&schemaTestUser{})
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		exists     bool
+		column     string
+		filterable bool
+		sortable   bool
+	}{
+		{"id", true, "id", true, true},
+		{"userName", true, "name", true, true},
+		{"email", true, "email", true, false},
+		{"age", true, "age", false, true},
+		{"status", true, "status", true, true},
+		{"password", false, "", false, false},
+		{"secret", false, "", false, false},
+		{"Password", false, "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := s.Lookup(tt.name)
+			if ok != tt.exists {
+				t.Fatalf("Lookup(%q) exists = %v, want %v", tt.name, ok, tt.exists)
+			}
+			if !ok {
+				return
+			}
+			if f.Column != tt.column || f.Filterable != tt.filterable || f.Sortable != tt.sortable {
+				t.Errorf("Lookup(%q) = %+v", tt.name, f)
+			}
+		})
+	}
+}
+
+func TestResolveQueryWithSchema(t *testing.T) {
+	s := MustParseSchema(&schemaTestUser{})
+	q := NewQuery().
+		AddCondition("userName", OpLike, "bob").
+		AddGroup(Or().Add("email", OpEq, "a@b.c").Add("status", OpIn, []int{1, 2})).
+		AddOrderBy("age desc").
+		AddOrderBy("id").
+		AddGroupBy("status").
+		Select("id", "userName")
+
+	got, err := resolveQuery(q, s)
+	if err != nil {
+		t.Fatalf("resolveQuery() error = %v", err)
+	}
+	if got.Conditions[0].Field != "name" {
+		t.Errorf("condition field = %s, want name", got.Conditions[0].Field)
+	}
+	if got.Groups[0].Conditions[0].Field != "email" {
+		t.Errorf("group condition field = %s, want email", got.Groups[0].Conditions[0].Field)
+	}
+	if !reflect.DeepEqual(got.OrderBy, []string{"age DESC", "id ASC"}) {
+		t.Errorf("OrderBy = %v", got.OrderBy)
+	}
+	if !reflect.DeepEqual(got.SelectFields, []string{"id", "name"}) {
+		t.Errorf("SelectFields = %v", got.SelectFields)
+	}
+	if q.Conditions[0].Field != "userName" {
+		t.Error("resolveQuery 不应修改原查询")
+	}
+}
+
+func TestResolveQueryRejects(t *testing.T) {
+	s := MustParseSchema(&schemaTestUser{})
+
+	tests := []struct {
+		name   string
+		query  *Query
+		schema *Schema
+	}{
+		{"未注册字段", NewQuery().AddCondition("password", OpEq, "x"), s},
+		{"列名不能代替公开字段名", NewQuery().AddCondition("name", OpEq, "x"), s},
+		{"字段不可过滤", NewQuery().AddCondition("age", OpEq, 1), s},
+		{"字段不可排序", NewQuery().AddOrderBy("email DESC"), s},
+		{"操作符不在白名单", NewQuery().AddCondition("id", Operator("regexp"), "x"), s},
+		{"条件组中的未注册字段", NewQuery().AddGroup(Or().AddGroup(And().Add("secret", OpEq, 1))), s},
+		{"非法的条件组逻辑", NewQuery().AddGroup(&ConditionGroup{Logic: "xor"}), s},
+		{"条件字段注入", NewQuery().AddCondition("id = 1 OR 1", OpEq, 1), nil},
+		{"排序注入", NewQuery().AddOrderBy("id; DROP TABLE users"), nil},
+		{"排序方向注入", NewQuery().AddOrderBy("id DESC, (SELECT 1)"), nil},
+		{"分组注入", NewQuery().AddGroupBy("status)"), nil},
+		{"选择字段注入", NewQuery().Select("id", "(SELECT password FROM users)"), nil},
+		{"MongoDB操作符字段", NewQuery().AddCondition("$where", OpEq, "1"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveQuery(tt.query, tt.schema)
+			if !errorx.IsCode(err, errorx.CodeInvalidParam) {
+				t.Errorf("resolveQuery() error = %v, want param error", err)
+			}
+		})
+	}
+}
+
+func TestQuerierUsesRegisteredSchema(t *testing.T) {
+	db := setupGroupTestDB(t)
+	RegisterSchema[groupTestUser](NewSchema(
+		FieldSpec{Name: "id", Filterable: true, Sortable: true},
+		FieldSpec{Name: "userName", Column: "name", Filterable: true, Operators: []Operator{OpEq, OpLike}},
+	))
+	defer schemas.Delete(reflect.TypeOf(groupTestUser{}))
+
+	querier := NewMySQLQuerier[groupTestUser](db)
+	ctx := context.Background()
+
+	list, err := querier.Find(ctx, NewQuery().AddCondition("userName", OpLike, "bob").AddOrderBy("id DESC"))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if got := collectIDs(list); !reflect.DeepEqual(got, []uint{4, 1}) {
+		t.Errorf("Find() = %v, want [4 1]", got)
+	}
+
+	page, err := querier.FindPage(ctx, NewQuery().AddCondition("userName", OpEq, "bob"))
+	if err != nil {
+		t.Fatalf("FindPage() error = %v", err)
+	}
+	if page.Total != 1 {
+		t.Errorf("FindPage().Total = %d, want 1", page.Total)
+	}
+
+	if _, err := querier.Count(ctx, NewQuery().AddCondition("status", OpEq, 1)); !errorx.IsCode(err, errorx.CodeInvalidParam) {
+		t.Errorf("Count() error = %v, want param error", err)
+	}
+	if _, err := querier.FindPage(ctx, NewQuery().AddCondition("userName", OpGt, "a")); !errorx.IsCode(err, errorx.CodeInvalidParam) {
+		t.Errorf("FindPage() error = %v, want param error", err)
+	}
+}
@@ -9,8 +9,10 @@ const (
 
 // Pagination 分页参数
 type Pagination struct {
-	Page     int `json:"page" form:"page"`         // 当前页码
-	PageSize int `json:"pageSize" form:"pageSize"` // 每页数量
+	Page       int    `json:"page" form:"page"`               // 当前页码
+	PageSize   int    `json:"pageSize" form:"pageSize"`       // 每页数量
+	Cursor     string `json:"cursor,omitempty" form:"cursor"` // 游标，游标模式下首页为空
+	CursorMode bool   `json:"cursorMode,omitempty" form:"-"`  // 是否使用游标分页
 }
 
 // NewPagination 创建分页参数
@@ -27,6 +29,14 @@ func NewPagination(page, pageSize int) *Pagination {
 	}
 }
 
+// NewCursorPagination 创建游标分页参数
+func NewCursorPagination(cursor string, pageSize int) *Pagination {
+	p := NewPagination(DefaultPage, pageSize)
+	p.Cursor = cursor
+	p.CursorMode = true
+	return p
+}
+
 // Operator 查询操作符
 type Operator string
 
@@ -54,12 +64,17 @@ type Condition struct {
 
 // Query 查询参数
 type Query struct {
-	Pagination       *Pagination `json:"pagination"`             // 分页参数
-	Conditions       []Condition `json:"conditions"`             // 查询条件
-	OrderBy          []string    `json:"orderBy,omitempty"`      // 排序字段，格式：字段名 ASC/DESC
-	GroupBy          []string    `json:"groupBy,omitempty"`      // 分组字段
-	SelectFields     []string    `json:"selectFields,omitempty"` // 选择的字段
-	EnablePagination bool        `json:"enablePagination"`       // 是否启用分页
+	Pagination       *Pagination      `json:"pagination"`             // 分页参数
+	Conditions       []Condition      `json:"conditions"`             // 查询条件
+	Groups           []ConditionGroup `json:"groups,omitempty"`       // 条件组，与 Conditions 以 AND 组合
+	OrderBy          []string         `json:"orderBy,omitempty"`      // 排序字段，格式：字段名 ASC/DESC
+	GroupBy          []string         `json:"groupBy,omitempty"`      // 分组字段
+	SelectFields     []string         `json:"selectFields,omitempty"` // 选择的字段
+	Aggregations     []Aggregation    `json:"aggregations,omitempty"` // 聚合项，仅用于 Aggregate
+	Having           []Condition      `json:"having,omitempty"`       // 分组过滤条件，仅用于 Aggregate
+	Preloads         []Relation       `json:"preloads,omitempty"`     // 预加载的关联
+	Joins            []Relation       `json:"joins,omitempty"`        // Join 的关联，可在条件中过滤关联字段
+	EnablePagination bool             `json:"enablePagination"`       // 是否启用分页
 }
 
 // NewQuery 创建新的查询参数
@@ -85,6 +100,17 @@ func (q *Query) SetPagination(pagination *Pagination) *Query {
 	return q
 }
 
+// SetCursor 设置游标分页参数，cursor 为空时查询首页
+func (q *Query) SetCursor(cursor string, pageSize int) *Query {
+	q.Pagination = NewCursorPagination(cursor, pageSize)
+	return q
+}
+
+// IsCursorMode 是否使用游标分页
+func (q *Query) IsCursorMode() bool {
+	return q.Pagination != nil && q.Pagination.CursorMode
+}
+
 // DisablePagination 禁用分页
 func (q *Query) DisablePagination() *Query {
 	q.EnablePagination = false
@@ -151,6 +151,16 @@ func (c *Client) HGet(ctx context.Context, key, field string, value interface{})
 	return json.Unmarshal(data, value)
 }
 
+// HGetAll 获取哈希的全部字段，值为 HSet 写入的 JSON 原文，键不存在时返回空
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, key).Result()
+}
+
+// HDel 删除哈希的字段
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.client.HDel(ctx, key, fields...).Err()
+}
+
 // Lock 分布式锁
 func (c *Client) Lock(ctx context.Context, key string, expiration time.Duration) (bool, error) {
 	value := fmt.Sprintf("%d", time.Now().UnixNano())
@@ -259,6 +269,22 @@ func (c *Client) Eval(ctx context.Context, script string, keys []string, args ..
 	return c.client.Eval(ctx, script, keys, args...)
 }
 
+// Incr 将键的整数值加一并返回新值，键不存在时从 0 开始
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	return c.client.Incr(ctx, key).Result()
+}
+
+// Publish 向频道发布消息，返回收到消息的订阅者数量
+func (c *Client) Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	return c.client.Publish(ctx, channel, message).Result()
+}
+
+// Subscribe 订阅频道，调用方负责关闭返回的 PubSub
+// 连接断开时会自动重连并重新订阅，断开期间的消息会丢失。
+func (c *Client) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return c.client.Subscribe(ctx, channels...)
+}
+
 // Expire sets key expiration
 func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
 	return c.client.Expire(ctx, key, expiration).Err()
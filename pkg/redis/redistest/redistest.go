@@ -0,0 +1,410 @@
+// Package redistest 提供测试用的内存 Redis 服务
+// 只支持字符串、哈希与过期时间的常用命令，不支持 Lua 脚本、发布订阅与集群。
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fiber_web/pkg/config"
+	"fiber_web/pkg/redis"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server 内存 Redis 服务，使用 RESP2 协议
+type Server struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	expires map[string]time.Time
+}
+
+// NewServer 启动内存 Redis 服务，测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动内存 Redis 失败: %v", err)
+	}
+	s := &Server{
+		ln:      ln,
+		strings: make(map[string]string),
+		hashes:  make(map[string]map[string]string),
+		expires: make(map[string]time.Time),
+	}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+// NewClient 启动内存 Redis 服务并返回连接它的客户端
+func NewClient(t testing.TB) *redis.Client {
+	t.Helper()
+	s := NewServer(t)
+	client, err := s.Client()
+	if err != nil {
+		t.Fatalf("连接内存 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Client 创建连接该服务的客户端
+func (s *Server) Client() (*redis.Client, error) {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	manager, err := redis.NewRedisManager(&config.RedisConfig{
+		Default: config.RedisInstanceConfig{Host: addr.IP.String(), Port: addr.Port},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manager.GetClient("default")
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.exec(w, args)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand 读取客户端发送的命令数组
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(header, "$") {
+			return nil, errors.New("expected bulk string")
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// exec 执行命令并写入响应
+func (s *Server) exec(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		writeError(w, "ERR empty command")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+	for _, key := range keysOf(cmd, args) {
+		s.expire(key)
+	}
+	switch cmd {
+	case "PING":
+		writeSimple(w, "PONG")
+	case "GET":
+		if !arity(w, args, 1) {
+			return
+		}
+		value, ok := s.strings[args[0]]
+		if !ok {
+			writeNil(w)
+			return
+		}
+		writeBulk(w, value)
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if s.exists(key) {
+				n++
+			}
+			s.remove(key)
+		}
+		writeInt(w, int64(n))
+	case "EXISTS":
+		n := 0
+		for _, key := range args {
+			if s.exists(key) {
+				n++
+			}
+		}
+		writeInt(w, int64(n))
+	case "INCR":
+		if !arity(w, args, 1) {
+			return
+		}
+		if _, ok := s.hashes[args[0]]; ok {
+			writeError(w, "WRONGTYPE Operation against a key holding the wrong kind of value")
+			return
+		}
+		n, err := strconv.ParseInt(s.strings[args[0]], 10, 64)
+		if err != nil && s.strings[args[0]] != "" {
+			writeError(w, "ERR value is not an integer or out of range")
+			return
+		}
+		n++
+		s.strings[args[0]] = strconv.FormatInt(n, 10)
+		writeInt(w, n)
+	case "EXPIRE", "PEXPIRE":
+		if !arity(w, args, 2) {
+			return
+		}
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			return
+		}
+		if !s.exists(args[0]) {
+			writeInt(w, 0)
+			return
+		}
+		unit := time.Second
+		if cmd == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		s.expires[args[0]] = time.Now().Add(time.Duration(n) * unit)
+		writeInt(w, 1)
+	case "TTL", "PTTL":
+		if !arity(w, args, 1) {
+			return
+		}
+		if !s.exists(args[0]) {
+			writeInt(w, -2)
+			return
+		}
+		at, ok := s.expires[args[0]]
+		if !ok {
+			writeInt(w, -1)
+			return
+		}
+		if cmd == "TTL" {
+			writeInt(w, int64(time.Until(at).Round(time.Second)/time.Second))
+			return
+		}
+		writeInt(w, time.Until(at).Milliseconds())
+	case "HGET":
+		if !arity(w, args, 2) || !s.isHash(w, args[0]) {
+			return
+		}
+		value, ok := s.hashes[args[0]][args[1]]
+		if !ok {
+			writeNil(w)
+			return
+		}
+		writeBulk(w, value)
+	case "HSET":
+		if len(args) < 3 || len(args)%2 == 0 {
+			writeError(w, "ERR wrong number of arguments for 'hset' command")
+			return
+		}
+		if !s.isHash(w, args[0]) {
+			return
+		}
+		hash := s.hashes[args[0]]
+		if hash == nil {
+			hash = make(map[string]string)
+			s.hashes[args[0]] = hash
+		}
+		n := 0
+		for i := 1; i+1 < len(args); i += 2 {
+			if _, ok := hash[args[i]]; !ok {
+				n++
+			}
+			hash[args[i]] = args[i+1]
+		}
+		writeInt(w, int64(n))
+	case "HDEL":
+		if len(args) < 2 {
+			writeError(w, "ERR wrong number of arguments for 'hdel' command")
+			return
+		}
+		if !s.isHash(w, args[0]) {
+			return
+		}
+		n := 0
+		for _, field := range args[1:] {
+			if _, ok := s.hashes[args[0]][field]; ok {
+				delete(s.hashes[args[0]], field)
+				n++
+			}
+		}
+		if len(s.hashes[args[0]]) == 0 {
+			s.remove(args[0])
+		}
+		writeInt(w, int64(n))
+	case "HGETALL":
+		if !arity(w, args, 1) || !s.isHash(w, args[0]) {
+			return
+		}
+		hash := s.hashes[args[0]]
+		fmt.Fprintf(w, "*%d\r\n", len(hash)*2)
+		for field, value := range hash {
+			writeBulk(w, field)
+			writeBulk(w, value)
+		}
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(cmd)))
+	}
+}
+
+// set 实现 SET key value [EX seconds|PX milliseconds] [NX|XX]
+func (s *Server) set(w *bufio.Writer, args []string) {
+	if len(args) < 2 {
+		writeError(w, "ERR wrong number of arguments for 'set' command")
+		return
+	}
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				writeError(w, "ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				writeError(w, "ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * time.Second
+			if strings.ToUpper(args[i]) == "PX" {
+				ttl = time.Duration(n) * time.Millisecond
+			}
+			i++
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+		default:
+			writeError(w, "ERR syntax error")
+			return
+		}
+	}
+	exists := s.exists(key)
+	if (nx && exists) || (xx && !exists) {
+		writeNil(w)
+		return
+	}
+	s.remove(key)
+	s.strings[key] = value
+	if ttl > 0 {
+		s.expires[key] = time.Now().Add(ttl)
+	}
+	writeSimple(w, "OK")
+}
+
+// keysOf 命令涉及的键，执行前清理其中已过期的键
+func keysOf(cmd string, args []string) []string {
+	switch cmd {
+	case "DEL", "EXISTS":
+		return args
+	case "PING":
+		return nil
+	}
+	if len(args) > 0 {
+		return args[:1]
+	}
+	return nil
+}
+
+func (s *Server) expire(key string) {
+	if at, ok := s.expires[key]; ok && !time.Now().Before(at) {
+		s.remove(key)
+	}
+}
+
+func (s *Server) exists(key string) bool {
+	_, isString := s.strings[key]
+	_, isHash := s.hashes[key]
+	return isString || isHash
+}
+
+func (s *Server) remove(key string) {
+	delete(s.strings, key)
+	delete(s.hashes, key)
+	delete(s.expires, key)
+}
+
+// isHash 键不存在或为哈希时返回 true，否则写入 WRONGTYPE 错误
+func (s *Server) isHash(w *bufio.Writer, key string) bool {
+	if _, ok := s.strings[key]; ok {
+		writeError(w, "WRONGTYPE Operation against a key holding the wrong kind of value")
+		return false
+	}
+	return true
+}
+
+func arity(w *bufio.Writer, args []string, n int) bool {
+	if len(args) != n {
+		writeError(w, "ERR wrong number of arguments")
+		return false
+	}
+	return true
+}
+
+func writeSimple(w *bufio.Writer, s string) { fmt.Fprintf(w, "+%s\r\n", s) }
+func writeError(w *bufio.Writer, s string)  { fmt.Fprintf(w, "-%s\r\n", s) }
+func writeInt(w *bufio.Writer, n int64)     { fmt.Fprintf(w, ":%d\r\n", n) }
+func writeNil(w *bufio.Writer)              { w.WriteString("$-1\r\n") }
+func writeBulk(w *bufio.Writer, s string)   { fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s) }
@@ -23,6 +23,12 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
+// IsPasswordHash 判断字符串是否已是 bcrypt 哈希，用于避免重复哈希
+func IsPasswordHash(s string) bool {
+	_, err := bcrypt.Cost([]byte(s))
+	return err == nil
+}
+
 // GenerateRandomKey 生成指定长度的随机密钥
 func GenerateRandomKey(length int) ([]byte, error) {
 	key := make([]byte, length)
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// GenerateECDSAKey 生成 P-256 ECDSA 私钥
+func GenerateECDSAKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// GenerateEd25519Key 生成 Ed25519 私钥
+func GenerateEd25519Key() (ed25519.PrivateKey, error) {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	return privKey, err
+}
+
+// EncodePKCS8PrivateKeyToPEM 将 RSA/ECDSA/Ed25519 私钥编码为 PKCS#8 PEM 格式
+func EncodePKCS8PrivateKeyToPEM(privKey crypto.Signer) ([]byte, error) {
+	privBytes, err := x509.MarshalPKCS8PrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}), nil
+}
+
+// ParseSignerFromPEM 从 PEM 格式解析签名私钥
+// 支持 PKCS#1（RSA PRIVATE KEY）、SEC 1（EC PRIVATE KEY）和 PKCS#8（PRIVATE KEY）编码的 RSA/ECDSA/Ed25519 私钥。
+func ParseSignerFromPEM(privPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privPEM)
+	if block == nil {
+		return nil, errors.New("failed to parse PEM block containing the private key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
@@ -0,0 +1,141 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPDigits 验证码位数
+	TOTPDigits = 6
+	// TOTPPeriod 验证码时间步长
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew 默认允许前后偏移的时间步数
+	TOTPSkew = 1
+)
+
+var (
+	ErrInvalidTOTPSecret = errors.New("无效的TOTP密钥")
+	ErrInvalidTOTPCode   = errors.New("无效的TOTP验证码")
+)
+
+// totpEncoding TOTP 密钥编码，与主流身份验证器一致使用无填充的 Base32
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret 生成 160 位随机 TOTP 密钥，返回 Base32 编码
+func GenerateTOTPSecret() (string, error) {
+	key, err := GenerateRandomKey(20)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(key), nil
+}
+
+// TOTPURI 生成供身份验证器扫码的 otpauth:// 地址
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCounter 获取时间对应的时间步
+func TOTPCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode 计算时间步对应的验证码（RFC 6238，HMAC-SHA1）
+func TOTPCode(secret string, counter int64) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, counter), nil
+}
+
+// ValidateTOTP 校验验证码，允许前后 skew 个时间步的时钟偏差
+// 返回匹配的时间步，调用方应记录并拒绝不大于已使用时间步的验证码以防止重放。
+func ValidateTOTP(secret, code string, t time.Time, skew int) (int64, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	if len(code) != TOTPDigits {
+		return 0, ErrInvalidTOTPCode
+	}
+	current := TOTPCounter(t)
+	// 从较新的时间步开始匹配，偏移窗口内同一验证码出现多次时取最大时间步
+	for offset := int64(skew); offset >= -int64(skew); offset-- {
+		counter := current + offset
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter)), []byte(code)) == 1 {
+			return counter, nil
+		}
+	}
+	return 0, ErrInvalidTOTPCode
+}
+
+// hotp 计算 HOTP 值（RFC 4226）
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for range TOTPDigits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
+
+// decodeTOTPSecret 解码 Base32 密钥，忽略大小写、空格与填充
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := totpEncoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidTOTPSecret
+	}
+	return key, nil
+}
+
+// GenerateRecoveryCodes 生成 n 个一次性恢复码，格式为 xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 计算恢复码的哈希，忽略大小写、空格与连字符
+// 恢复码为高熵随机值，使用 SHA256 即可，无需 bcrypt 的慢哈希。
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return SHA256(code)
+}
@@ -0,0 +1,121 @@
+package security
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录B中 SHA1 测试向量的密钥 "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 测试向量为 8 位，取后 6 位
+	tests := []struct {
+		name     string
+		unix     int64
+		expected string
+	}{
+		{"T=59", 59, "287082"},
+		{"T=1111111109", 1111111109, "081804"},
+		{"T=1111111111", 1111111111, "050471"},
+		{"T=1234567890", 1234567890, "005924"},
+		{"T=2000000000", 2000000000, "279037"},
+		{"T=20000000000", 20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOTPCode(rfc6238Secret, TOTPCounter(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("TOTPCode() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	current := TOTPCounter(now)
+
+	tests := []struct {
+		name    string
+		counter int64
+		wantErr error
+	}{
+		{"当前时间步", current, nil},
+		{"上一个时间步", current - 1, nil},
+		{"下一个时间步", current + 1, nil},
+		{"超出偏移窗口", current - 2, ErrInvalidTOTPCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := TOTPCode(secret, tt.counter)
+			counter, err := ValidateTOTP(secret, code, now, TOTPSkew)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateTOTP() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && counter != tt.counter {
+				t.Errorf("ValidateTOTP() counter = %v, want %v", counter, tt.counter)
+			}
+		})
+	}
+
+	if _, err := ValidateTOTP(secret, "12345", now, TOTPSkew); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Errorf("位数错误 ValidateTOTP() error = %v, want %v", err, ErrInvalidTOTPCode)
+	}
+	if _, err := ValidateTOTP("not-base32!", "123456", now, TOTPSkew); !errors.Is(err, ErrInvalidTOTPSecret) {
+		t.Errorf("密钥错误 ValidateTOTP() error = %v, want %v", err, ErrInvalidTOTPSecret)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Fiber Web", "admin@example.com", rfc6238Secret)
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Fiber Web:admin@example.com" {
+		t.Errorf("TOTPURI() = %v", uri)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfc6238Secret || q.Get("issuer") != "Fiber Web" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("TOTPURI() query = %v", q)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("恢复码格式错误: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("恢复码重复: %q", code)
+		}
+		seen[code] = true
+	}
+
+	// 哈希忽略大小写与连字符
+	code := codes[0]
+	if HashRecoveryCode(code) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) {
+		t.Error("HashRecoveryCode() 应忽略大小写与连字符")
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Error("不同恢复码的哈希不应相同")
+	}
+}
@@ -1,5 +1,10 @@
 package errorx
 
+import (
+	"math"
+	"time"
+)
+
 // 错误码定义
 const (
 	// 成功
@@ -12,6 +17,7 @@ const (
 	CodeNotFound         = 404 // 资源不存在
 	CodeMethodNotAllowed = 405 // 方法不允许
 	CodeConflict         = 409 // 资源冲突
+	CodeLocked           = 423 // 资源被锁定
 	CodeTooManyRequests  = 429 // 请求过多
 
 	// 服务端错误 (5xx)
@@ -96,6 +102,15 @@ func NewForbiddenError(message string) *Error {
 		WithContext("type", TypeAuth)
 }
 
+// NewAccountLockedError 创建账号锁定错误，retryAfter 为剩余锁定时间
+func NewAccountLockedError(retryAfter time.Duration) *Error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return Errorf("登录失败次数过多，请 %d 秒后重试", seconds).
+		WithCode(CodeLocked).
+		WithContext("type", TypeAuth).
+		WithContext("retry_after", seconds)
+}
+
 // NewTooManyRequestsError 创建请求过多错误
 func NewTooManyRequestsError() *Error {
 	return New("请求过于频繁，请稍后重试").
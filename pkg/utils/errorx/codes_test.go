@@ -1,6 +1,9 @@
 package errorx
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // TestNewBusinessError 测试业务错误创建
 func TestNewBusinessError(t *testing.T) {
@@ -108,6 +111,20 @@ func TestNewForbiddenError(t *testing.T) {
 	}
 }
 
+// TestNewAccountLockedError 测试账号锁定错误创建
+func TestNewAccountLockedError(t *testing.T) {
+	err := NewAccountLockedError(1500 * time.Millisecond)
+	if err.Code != CodeLocked {
+		t.Errorf("期望错误码为 %d，实际得到 %d", CodeLocked, err.Code)
+	}
+	if err.Context["type"] != TypeAuth {
+		t.Errorf("期望错误类型为 %s，实际得到 %s", TypeAuth, err.Context["type"])
+	}
+	if err.Context["retry_after"] != int64(2) {
+		t.Errorf("期望剩余锁定时间为 2 秒，实际得到 %v", err.Context["retry_after"])
+	}
+}
+
 // TestNewTooManyRequestsError 测试请求过多错误创建
 func TestNewTooManyRequestsError(t *testing.T) {
 	err := NewTooManyRequestsError()
@@ -0,0 +1,7 @@
+-- 模块: admin
+-- 管理员 OIDC 绑定字段，已按 v1 建表的数据库执行
+-- 未绑定时为 NULL，唯一索引保证一个身份只绑定一个管理员
+
+ALTER TABLE admin_users
+  ADD COLUMN oidc_subject VARCHAR(255) NULL DEFAULT NULL COMMENT '绑定的OIDC身份标识，未绑定时为NULL' AFTER recovery_codes,
+  ADD UNIQUE KEY `admin_users_oidc_subject_idx` (oidc_subject) COMMENT 'OIDC身份标识唯一索引';
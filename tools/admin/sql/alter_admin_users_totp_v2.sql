@@ -0,0 +1,8 @@
+-- 模块: admin
+-- 管理员两步验证字段，已按 v1 建表的数据库执行
+
+ALTER TABLE admin_users
+  ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'TOTP密钥' AFTER status,
+  ADD COLUMN totp_enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用两步验证' AFTER totp_secret,
+  ADD COLUMN totp_counter BIGINT NOT NULL DEFAULT 0 COMMENT '最近使用的验证码时间步' AFTER totp_enabled,
+  ADD COLUMN recovery_codes VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '恢复码哈希' AFTER totp_counter;
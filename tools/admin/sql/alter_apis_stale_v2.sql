@@ -0,0 +1,6 @@
+-- 模块: admin
+-- 接口路由同步字段，已按 v1 建表的数据库执行
+
+ALTER TABLE apis
+  ADD COLUMN stale TINYINT(1) NOT NULL DEFAULT 0 COMMENT '路由是否已不存在' AFTER method,
+  ADD KEY `apis_method_path_idx` (method,path) COMMENT '方法和路径索引';
@@ -8,6 +8,11 @@ CREATE TABLE IF NOT EXISTS admin_users (
   email VARCHAR(100) NOT NULL DEFAULT '' COMMENT '邮箱',
   password VARCHAR(100) NOT NULL DEFAULT '' COMMENT '密码',
   status TINYINT(3) NOT NULL DEFAULT 1 COMMENT '状态',
+  totp_secret VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'TOTP密钥',
+  totp_enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用两步验证',
+  totp_counter BIGINT NOT NULL DEFAULT 0 COMMENT '最近使用的验证码时间步',
+  recovery_codes VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '恢复码哈希',
+  oidc_subject VARCHAR(255) NULL DEFAULT NULL COMMENT '绑定的OIDC身份标识，未绑定时为NULL',
   created_at DATETIME COMMENT '创建时间',
   updated_at DATETIME COMMENT '更新时间',
   deleted_at DATETIME COMMENT '删除时间',
@@ -15,6 +20,7 @@ CREATE TABLE IF NOT EXISTS admin_users (
   UNIQUE KEY `admin_users_name_unique` (name) COMMENT '用户名唯一索引',
   UNIQUE KEY `admin_users_email_unique` (email) COMMENT '邮箱唯一索引',
   KEY `admin_users_status_idx` (status) COMMENT '状态索引',
-  KEY `admin_users_status_created_idx` (status,created_at) COMMENT '状态和创建时间复合索引'
+  KEY `admin_users_status_created_idx` (status,created_at) COMMENT '状态和创建时间复合索引',
+  UNIQUE KEY `admin_users_oidc_subject_idx` (oidc_subject) COMMENT 'OIDC身份标识唯一索引'
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员用户表';
 
@@ -0,0 +1,19 @@
+-- 模块: admin
+
+-- API密钥表
+CREATE TABLE IF NOT EXISTS api_keys (
+  ID INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  name VARCHAR(100) NOT NULL DEFAULT '' COMMENT '密钥名称',
+  prefix VARCHAR(16) NOT NULL DEFAULT '' COMMENT '密钥公开前缀',
+  key_hash CHAR(64) NOT NULL DEFAULT '' COMMENT '密钥哈希',
+  roles VARCHAR(255) NOT NULL DEFAULT '' COMMENT '授权角色',
+  created_by INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '创建者管理员ID',
+  expires_at DATETIME COMMENT '过期时间',
+  last_used_at DATETIME COMMENT '最近使用时间',
+  revoked_at DATETIME COMMENT '吊销时间',
+  created_at DATETIME COMMENT '创建时间',
+  updated_at DATETIME COMMENT '更新时间',
+  PRIMARY KEY (ID),
+  UNIQUE KEY `api_keys_prefix_unique` (prefix) COMMENT '前缀唯一索引',
+  KEY `api_keys_created_by_idx` (created_by) COMMENT '创建者索引'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API密钥表';